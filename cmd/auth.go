@@ -1,42 +1,11 @@
 package cmd
 
 import (
-	"net/http"
-
 	"github.com/spf13/cobra"
 
 	log "github.com/sirupsen/logrus"
 )
 
-type authAPIKeyAccess struct {
-	Events         bool `json:"events,omitempty"`
-	Markers        bool `json:"markers,omitempty"`
-	Triggers       bool `json:"triggers,omitempty"`
-	Boards         bool `json:"boards,omitempty"`
-	Queries        bool `json:"queries,omitempty"`
-	Columns        bool `json:"columns,omitempty"`
-	CreateDatasets bool `json:"createDatasets,omitempty"`
-	SLOs           bool `json:"slos,omitempty"`
-	Recipients     bool `json:"recipients,omitempty"`
-	PrivateBoards  bool `json:"privateBoards,omitempty"`
-}
-
-type authEnvironment struct {
-	Name string `json:"name,omitempty"`
-	Slug string `json:"slug,omitempty"`
-}
-
-type authTeam struct {
-	Name string `json:"name,omitempty"`
-	Slug string `json:"slug,omitempty"`
-}
-
-type auth struct {
-	APIKeyAccess authAPIKeyAccess `json:"api_key_access,omitempty"`
-	Environment  authEnvironment  `json:"environment,omitempty"`
-	Team         authTeam         `json:"team,omitempty"`
-}
-
 // Authorizations
 // https://docs.honeycomb.io/api/tag/Auth
 func newAuthCmd() *cobra.Command {
@@ -69,20 +38,14 @@ func newAuthListCmd() *cobra.Command {
 			"Note: a Honeycomb Classic API key will return an empty string for both of the\n" +
 			"environment values.",
 		Run: func(cmd *cobra.Command, args []string) {
-			var p = payload{
-				Method:   http.MethodGet,
-				Path:     "/1/auth",
-				Response: &auth{},
-			}
-
-			var err = p.GetResponse(true)
+			a, err := client.GetAuth(cmd.Context())
 			if err != nil {
-				log.WithFields(log.Fields{
+				fatal(log.Fields{
 					"_function": "newAuthListCmd",
-					"err":       err,
-					"payload":   p,
-				}).Fatal("Error received when attempting to list authorizations.")
+				}, err, "Error received when attempting to list authorizations.")
 			}
+
+			printResponse(a)
 		},
 	}
 
@@ -0,0 +1,282 @@
+package cmd
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+
+	"github.com/adz-anz/honeybadger/honeycomb"
+)
+
+const (
+	// backupFormatVersion is the version of the layout of backup archives,
+	// raised when restore could no longer read older ones as they are.
+	backupFormatVersion = 1
+
+	// backupManifestFile is the file in a backup archive that lists the
+	// resources in it.
+	backupManifestFile = "manifest.json"
+)
+
+// backupManifest lists the resources in a backup archive, with the checksums
+// of the files they are kept in.
+type backupManifest struct {
+	FormatVersion int           `json:"format_version"`
+	CreatedAt     time.Time     `json:"created_at"`
+	CreatedBy     string        `json:"created_by"`
+	Environment   string        `json:"environment"`
+	Resources     []backupEntry `json:"resources"`
+}
+
+// backupEntry is a resource in a backup archive.
+type backupEntry struct {
+	Kind    string `json:"kind"`
+	Dataset string `json:"dataset,omitempty"`
+	Name    string `json:"name"`
+	ID      string `json:"id"`
+	Path    string `json:"path"`
+	SHA256  string `json:"sha256"`
+}
+
+// CUSTOM
+// Write every resource in the environment to a backup archive.
+func newBackupCmd() *cobra.Command {
+	var bOut string
+
+	cmd := &cobra.Command{
+		Use:   "backup",
+		Short: "Back up every resource in the environment to an archive.",
+		Long: "Write every resource in the environment that can be read to a tar.gz archive,\n" +
+			"which restore can recreate them from. The resources are those export writes:\n" +
+			"Recipients, Boards, and the Datasets with their Dataset Definitions, Columns,\n" +
+			"Derived Columns, Marker Settings, SLOs, Burn Alerts, Triggers and Query\n" +
+			"Annotations.\n" +
+			"\n" +
+			"Each resource is kept as a JSON manifest under resources/, laid out as export\n" +
+			"lays out its files. The archive also holds manifest.json, which records the\n" +
+			"version of the archive format, when and from which environment it was taken,\n" +
+			"and the ID and SHA-256 checksum of each resource.\n" +
+			"\n" +
+			"The archive is named after the environment and the time unless --out is given.\n" +
+			"Secrets are masked unless --show-secrets is given, in which case Recipients that\n" +
+			"hold them can be restored, but the archive must be kept safe. With --dry-run the\n" +
+			"resources are listed rather than written.",
+		Example: "  honeybadger backup\n" +
+			"  honeybadger backup --out backups/prod.tar.gz --show-secrets",
+		Annotations: map[string]string{annotationLiveReads: ""},
+		Args:        cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			ctx := cmd.Context()
+			fields := log.Fields{"_function": "newBackupCmd"}
+
+			env, err := environmentKey(ctx)
+			if err != nil {
+				fatal(fields, err, "Error received when attempting to identify the environment.")
+			}
+			resources, err := collectResources(ctx, newRefResolver(nil), resourceKinds)
+			if err != nil {
+				fatal(fields, err, "Error received when attempting to read resources to back up.")
+			}
+
+			bm := &backupManifest{
+				FormatVersion: backupFormatVersion,
+				CreatedAt:     time.Now().UTC().Truncate(time.Second),
+				CreatedBy:     userAgent,
+				Environment:   env,
+				Resources:     []backupEntry{},
+			}
+			if bOut == "" {
+				bOut = fmt.Sprintf("honeybadger-backup-%s-%s.tar.gz",
+					strings.ReplaceAll(env, "/", "-"), bm.CreatedAt.Format("20060102T150405Z"))
+			}
+			fields["out"] = bOut
+
+			archive, err := writeBackup(bm, resources)
+			if err != nil {
+				fatal(fields, err, "Error received when attempting to create the backup.")
+			}
+			if dryRun {
+				fmt.Fprintf(os.Stderr, "Would write %s\n", bOut)
+			} else if err := writeFileAtomic(bOut, archive); err != nil {
+				fatal(fields, err, "Error received when attempting to write the backup.")
+			}
+
+			printResponse(bm.Resources)
+			if !dryRun {
+				fmt.Fprintf(os.Stderr, "Backed up %d resources from %s to %s\n", len(bm.Resources), env, bOut)
+			}
+		},
+	}
+
+	cmd.Flags().StringVar(&bOut, "out", "",
+		"The file to write the archive to. Defaults to honeybadger-backup-<team>-<environment>-<time>.tar.gz.")
+
+	return cmd
+}
+
+// writeBackup encodes the resources as a backup archive, adding them to the
+// manifest, which is written last.
+func writeBackup(bm *backupManifest, resources []collectedResource) ([]byte, error) {
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	add := func(name string, data []byte) error {
+		err := tw.WriteHeader(&tar.Header{
+			Typeflag: tar.TypeReg,
+			Name:     name,
+			Mode:     0o644,
+			Size:     int64(len(data)),
+			ModTime:  bm.CreatedAt,
+		})
+		if err == nil {
+			_, err = tw.Write(data)
+		}
+		return err
+	}
+
+	for _, res := range placeResources("resources", resources) {
+		name := filepath.ToSlash(strings.TrimSuffix(res.Path, ".yaml")) + ".json"
+		data, err := marshalBackupResource(res.manifest)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode %s: %w", res.ref(), err)
+		}
+		if err := add(name, data); err != nil {
+			return nil, err
+		}
+		sum := sha256.Sum256(data)
+		bm.Resources = append(bm.Resources, backupEntry{
+			Kind:    res.Kind,
+			Dataset: res.Metadata.Dataset,
+			Name:    res.Metadata.Name,
+			ID:      res.ID,
+			Path:    name,
+			SHA256:  hex.EncodeToString(sum[:]),
+		})
+	}
+
+	data, err := json.MarshalIndent(bm, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	if err := add(backupManifestFile, append(data, '\n')); err != nil {
+		return nil, err
+	}
+	if err := tw.Close(); err != nil {
+		return nil, err
+	}
+	if err := gz.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// marshalBackupResource encodes a manifest as indented JSON, masking its
+// secrets.
+func marshalBackupResource(m manifest) ([]byte, error) {
+	raw, err := json.Marshal(m)
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	if err := json.Indent(&buf, client.Redactor().JSON(raw), "", "  "); err != nil {
+		return nil, err
+	}
+	buf.WriteByte('\n')
+	return buf.Bytes(), nil
+}
+
+// readBackup reads a backup archive, checking the files of its resources
+// against the checksums in its manifest. The manifests of the resources are
+// returned in the order of its entries.
+func readBackup(archive string) (*backupManifest, []manifest, error) {
+	f, err := os.Open(archive)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer f.Close()
+
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%s is not a backup archive: %w", archive, err)
+	}
+	files := map[string][]byte{}
+	tr := tar.NewReader(gz)
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to read %s: %w", archive, err)
+		}
+		if hdr.Typeflag != tar.TypeReg {
+			continue
+		}
+		data, err := io.ReadAll(tr)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to read %s: %w", archive, err)
+		}
+		files[path.Clean(hdr.Name)] = data
+	}
+
+	raw, ok := files[backupManifestFile]
+	if !ok {
+		return nil, nil, fmt.Errorf("%s is not a backup archive: it has no %s", archive, backupManifestFile)
+	}
+	var bm backupManifest
+	if err := json.Unmarshal(raw, &bm); err != nil {
+		return nil, nil, fmt.Errorf("failed to parse %s in %s: %w", backupManifestFile, archive, err)
+	}
+	if bm.FormatVersion < 1 || bm.FormatVersion > backupFormatVersion {
+		return nil, nil, fmt.Errorf("%s has format version %d, but this version of honeybadger reads up to version %d",
+			archive, bm.FormatVersion, backupFormatVersion)
+	}
+
+	var problems []string
+	var ms []manifest
+	for _, e := range bm.Resources {
+		source := archive + ":" + e.Path
+		data, ok := files[path.Clean(e.Path)]
+		if !ok {
+			problems = append(problems, fmt.Sprintf("%s: the file is missing", source))
+			continue
+		}
+		if sum := sha256.Sum256(data); hex.EncodeToString(sum[:]) != e.SHA256 {
+			problems = append(problems, fmt.Sprintf("%s: the checksum does not match the manifest", source))
+			continue
+		}
+
+		var doc interface{}
+		dec := json.NewDecoder(bytes.NewReader(data))
+		dec.UseNumber()
+		var m manifest
+		err := dec.Decode(&doc)
+		if err == nil {
+			err = decodeStrict(doc, &m)
+		}
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("%s: %v", source, err))
+			continue
+		}
+		m.source = source
+		ms = append(ms, m)
+	}
+	if len(problems) > 0 {
+		return nil, nil, &honeycomb.ValidationError{Resource: "backup", Problems: problems}
+	}
+	return &bm, ms, nil
+}
@@ -1,46 +1,15 @@
 package cmd
 
 import (
-	"encoding/json"
-	"net/http"
+	"fmt"
 
 	"github.com/spf13/cobra"
 
+	"github.com/adz-anz/honeybadger/honeycomb"
+
 	log "github.com/sirupsen/logrus"
 )
 
-type boardGraphSettings struct {
-	HideMarkers       bool `json:"hide_markers"`
-	LogScale          bool `json:"log_scale"`
-	OmitMissingValues bool `json:"omit_missing_values"`
-	StackedGraphs     bool `json:"stacked_graphs"`
-	UTCXAxis          bool `json:"utc_xaxis"`
-	OverlaidCharts    bool `json:"overlaid_charts"`
-}
-
-type boardQuery struct {
-	Caption           string             `json:"caption,omitempty"`
-	GraphSettings     boardGraphSettings `json:"graph_settings,omitempty"`
-	QueryStyle        string             `json:"query_style,omitempty"`
-	Dataset           string             `json:"dataset,omitempty"`
-	QueryID           string             `json:"query_id,omitempty"`
-	QueryAnnotationID string             `json:"query_annotation_id,omitempty"`
-}
-
-type boardLinks struct {
-	BoardURL string `json:"board_url,omitempty"`
-}
-
-type board struct {
-	Name         string       `json:"name,omitempty"`
-	Description  string       `json:"description,omitempty"`
-	Style        string       `json:"style,omitempty"`
-	ColumnLayout string       `json:"column_layout,omitempty"`
-	Queries      []boardQuery `json:"queries,omitempty"`
-	Links        boardLinks   `json:"links,omitempty"`
-	ID           string       `json:"id,omitempty"`
-}
-
 func newBoardsCmd() *cobra.Command {
 	cmd := &cobra.Command{
 		Use:     "boards",
@@ -80,34 +49,66 @@ func newBoardsAddQueryCmd() *cobra.Command {
 		bQueryDataset                        string
 		bQueryID                             string
 		bQueryAnnotationID                   string
+		bQueryAnnotationName                 string
+		bQueryAnnotationDescription          string
 	)
 
 	cmd := &cobra.Command{
 		Use:     "add_query",
 		Aliases: []string{"aq"},
 		Short:   "Add a Query to a Board.",
-		Long:    "Add a Query to a Board.",
+		Long: "Add a Query to a Board.\n" +
+			"\n" +
+			"The Query can be given a name and description with an existing Query Annotation,\n" +
+			"using --annotation_id, or by creating one with --annotation-name and\n" +
+			"--annotation-description.",
+		Example: "  honeybadger boards add_query -i abc123 -d my-service -q def456 --annotation-name 'Slow requests'",
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			if cmd.Flags().Changed("annotation-description") && !cmd.Flags().Changed("annotation-name") {
+				return fmt.Errorf("--annotation-description requires --annotation-name")
+			}
+			if cmd.Flags().Changed("annotation-name") && bQueryID == "" {
+				return fmt.Errorf("--annotation-name requires --query_id, the Query to annotate")
+			}
+			return nil
+		},
 		Run: func(cmd *cobra.Command, args []string) {
 			// Get the board first, so we can append a new query to it.
-			var pGet = payload{
-				Method:   http.MethodGet,
-				Path:     "/1/boards/" + bID,
-				Response: &board{},
-			}
-
-			var err = pGet.GetResponse(false)
+			b, err := client.GetBoard(cmd.Context(), bID)
 			if err != nil {
-				log.WithFields(log.Fields{
+				fatal(log.Fields{
 					"_function": "newBoardsAddQueryCmd",
-					"err":       err,
-					"payload":   pGet,
-				}).Fatal("Error received when attempting to get the board to update.")
+					"board_id":  bID,
+				}, err, "Error received when attempting to get the board to update.")
+			}
+
+			if bQueryAnnotationName != "" {
+				// Query Annotations belong to the dataset of their Query.
+				dataset := bQueryDataset
+				if dataset == "" {
+					dataset = honeycomb.EnvironmentWide
+				}
+
+				qa, err := client.CreateQueryAnnotation(cmd.Context(), dataset, &honeycomb.QueryAnnotation{
+					Name:        bQueryAnnotationName,
+					Description: bQueryAnnotationDescription,
+					QueryID:     bQueryID,
+				})
+				if err != nil {
+					fatal(log.Fields{
+						"_function": "newBoardsAddQueryCmd",
+						"board_id":  bID,
+						"dataset":   dataset,
+						"query_id":  bQueryID,
+					}, err, "Error received when attempting to create a query annotation for the query.")
+				}
+				bQueryAnnotationID = qa.ID
 			}
 
 			// Update the returned board with the new query.
-			pGet.Response.(*board).Queries = append(pGet.Response.(*board).Queries, boardQuery{
+			b.Queries = append(b.Queries, honeycomb.BoardQuery{
 				Caption: bQueryCaption,
-				GraphSettings: boardGraphSettings{
+				GraphSettings: honeycomb.BoardGraphSettings{
 					HideMarkers:       bQueryGraphSettingsHideMarkers,
 					LogScale:          bQueryGraphSettingsLogScale,
 					OmitMissingValues: bQueryGraphSettingsOmitMissingValues,
@@ -121,29 +122,15 @@ func newBoardsAddQueryCmd() *cobra.Command {
 				QueryAnnotationID: bQueryAnnotationID,
 			})
 
-			bodyMarshal, err := json.Marshal(pGet.Response)
+			b, err = client.UpdateBoard(cmd.Context(), bID, b)
 			if err != nil {
-				log.WithFields(log.Fields{
+				fatal(log.Fields{
 					"_function": "newBoardsAddQueryCmd",
-					"err":       err,
-					"board":     pGet.Response,
-				}).Fatal("Error received when attempting to marshal a board.")
-			}
-			var pPut = payload{
-				Method:   http.MethodPut,
-				Path:     "/1/boards/" + bID,
-				Body:     bodyMarshal,
-				Response: &board{},
+					"board_id":  bID,
+				}, err, "Error received when attempting to add a new query to a board.")
 			}
 
-			err = pPut.GetResponse(true)
-			if err != nil {
-				log.WithFields(log.Fields{
-					"_function": "newBoardsAddQueryCmd",
-					"err":       err,
-					"payload":   pPut,
-				}).Fatal("Error received when attempting to add a new query to a board.")
-			}
+			printResponse(b)
 		},
 	}
 
@@ -172,6 +159,11 @@ func newBoardsAddQueryCmd() *cobra.Command {
 		"The ID of a Query object. Cannot be used with query. Query IDs can be retrieved from the UI or from the Query API.")
 	cmd.Flags().StringVarP(&bQueryAnnotationID, "annotation_id", "a", "",
 		"The ID of a Query Annotation that provides a name and description for the Query. The Query Annotation must apply to the query_id or query specified.")
+	cmd.Flags().StringVar(&bQueryAnnotationName, "annotation-name", "",
+		"Create a Query Annotation for the Query with this name, rather than giving --annotation_id.")
+	cmd.Flags().StringVar(&bQueryAnnotationDescription, "annotation-description", "",
+		"The description of the Query Annotation created with --annotation-name.")
+	cmd.MarkFlagsMutuallyExclusive("annotation_id", "annotation-name")
 
 	return cmd
 }
@@ -191,35 +183,21 @@ func newBoardsCreateCmd() *cobra.Command {
 		Short:   "Create a Board.",
 		Long:    "Create a Board without any Queries - these can be added after creation.",
 		Run: func(cmd *cobra.Command, args []string) {
-			var b = board{
+			var b = honeycomb.Board{
 				Name:         bName,
 				Description:  bDescription,
 				ColumnLayout: bColumnLayout,
 			}
 
-			var bodyMarshal, err = json.Marshal(b)
+			created, err := client.CreateBoard(cmd.Context(), &b)
 			if err != nil {
-				log.WithFields(log.Fields{
+				fatal(log.Fields{
 					"_function": "newBoardsCreateCmd",
-					"err":       err,
 					"board":     b,
-				}).Fatal("Error received when attempting to marshal a board.")
-			}
-			var p = payload{
-				Method:   http.MethodPost,
-				Path:     "/1/boards",
-				Body:     bodyMarshal,
-				Response: &board{},
+				}, err, "Error received when attempting to create a new board.")
 			}
 
-			err = p.GetResponse(true)
-			if err != nil {
-				log.WithFields(log.Fields{
-					"_function": "newBoardsCreateCmd",
-					"err":       err,
-					"payload":   p,
-				}).Fatal("Error received when attempting to create a new board.")
-			}
+			printResponse(created)
 		},
 	}
 
@@ -244,20 +222,14 @@ func newBoardsListCmd() *cobra.Command {
 			"\n" +
 			"Note: For Honeycomb Classic users, all boards within Classic will be returned.",
 		Run: func(cmd *cobra.Command, args []string) {
-			var p = payload{
-				Method:   http.MethodGet,
-				Path:     "/1/boards",
-				Response: &[]board{},
-			}
-
-			var err = p.GetResponse(true)
+			boards, err := client.ListBoards(cmd.Context())
 			if err != nil {
-				log.WithFields(log.Fields{
+				fatal(log.Fields{
 					"_function": "newBoardsListCmd",
-					"err":       err,
-					"payload":   p,
-				}).Fatal("Error received when attempting to list boards.")
+				}, err, "Error received when attempting to list boards.")
 			}
+
+			printResponse(boards)
 		},
 	}
 
@@ -275,20 +247,15 @@ func newBoardsGetCmd() *cobra.Command {
 		Short:   "Get a single Board by ID.",
 		Long:    "Get a single Board by ID.",
 		Run: func(cmd *cobra.Command, args []string) {
-			var p = payload{
-				Method:   http.MethodGet,
-				Path:     "/1/boards/" + bID,
-				Response: &board{},
-			}
-
-			var err = p.GetResponse(true)
+			b, err := client.GetBoard(cmd.Context(), bID)
 			if err != nil {
-				log.WithFields(log.Fields{
+				fatal(log.Fields{
 					"_function": "newBoardsGetCmd",
-					"err":       err,
-					"payload":   p,
-				}).Fatal("Error received when attempting to get a single board.")
+					"board_id":  bID,
+				}, err, "Error received when attempting to get a single board.")
 			}
+
+			printResponse(b)
 		},
 	}
 
@@ -312,54 +279,33 @@ func newBoardsUpdateCmd() *cobra.Command {
 		Short:   "Update a Board by ID.",
 		Long:    "Update a Board by ID, leaving existing queries as-is.",
 		Run: func(cmd *cobra.Command, args []string) {
-			// Get the board first, so we can append a new query to it.
-			var pGet = payload{
-				Method:   http.MethodGet,
-				Path:     "/1/boards/" + bID,
-				Response: &board{},
-			}
-
-			var err = pGet.GetResponse(false)
+			// Get the board first, so the existing queries are retained.
+			existing, err := client.GetBoard(cmd.Context(), bID)
 			if err != nil {
-				log.WithFields(log.Fields{
+				fatal(log.Fields{
 					"_function": "newBoardsUpdateCmd",
-					"err":       err,
-					"payload":   pGet,
-				}).Fatal("Error received when attempting to get the board to update.")
+					"board_id":  bID,
+				}, err, "Error received when attempting to get the board to update.")
 			}
 
 			// TODO: Change this to only overwriting the existing board w/ the
 			// 		specified values, ignoring nulls.
-			var b = board{
+			var b = honeycomb.Board{
 				Name:         bName,
 				Description:  bDescription,
 				ColumnLayout: bColumnLayout,
-				Queries:      pGet.Response.(*board).Queries,
+				Queries:      existing.Queries,
 			}
 
-			bodyMarshal, err := json.Marshal(b)
+			updated, err := client.UpdateBoard(cmd.Context(), bID, &b)
 			if err != nil {
-				log.WithFields(log.Fields{
+				fatal(log.Fields{
 					"_function": "newBoardsUpdateCmd",
-					"err":       err,
 					"board":     b,
-				}).Fatal("Error received when attempting to marshal a board.")
-			}
-			var pPut = payload{
-				Method:   http.MethodPut,
-				Path:     "/1/boards/" + bID,
-				Body:     bodyMarshal,
-				Response: &board{},
+				}, err, "Error received when attempting to update an existing board.")
 			}
 
-			err = pPut.GetResponse(true)
-			if err != nil {
-				log.WithFields(log.Fields{
-					"_function": "newBoardsUpdateCmd",
-					"err":       err,
-					"payload":   pPut,
-				}).Fatal("Error received when attempting to update an existing board.")
-			}
+			printResponse(updated)
 		},
 	}
 
@@ -386,19 +332,12 @@ func newBoardsDeleteCmd() *cobra.Command {
 		Short:   "Delete a single Board by ID.",
 		Long:    "Delete a single Board by ID.",
 		Run: func(cmd *cobra.Command, args []string) {
-			var p = payload{
-				Method:   http.MethodDelete,
-				Path:     "/1/boards/" + bID,
-				Response: nil,
-			}
-
-			var err = p.GetResponse(true)
+			err := client.DeleteBoard(cmd.Context(), bID)
 			if err != nil {
-				log.WithFields(log.Fields{
+				fatal(log.Fields{
 					"_function": "newBoardsDeleteCmd",
-					"err":       err,
-					"payload":   p,
-				}).Fatal("Error received when attempting to delete an existing board.")
+					"board_id":  bID,
+				}, err, "Error received when attempting to delete an existing board.")
 			}
 		},
 	}
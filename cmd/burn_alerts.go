@@ -0,0 +1,365 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/adz-anz/honeybadger/honeycomb"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// burnAlertFlags are the flags shared by the burn alert create and update
+// commands. Only flags that were explicitly set are applied, so that they can
+// be used to override a Burn Alert read from --from-file or fetched for an
+// update.
+type burnAlertFlags struct {
+	fromFile                     string
+	sloID                        string
+	alertType                    string
+	description                  string
+	exhaustionMinutes            int
+	budgetRateWindowMinutes      int
+	budgetRateDecrease           float64
+	budgetRateDecreasePerMillion int
+	recipients                   []string
+}
+
+func (f *burnAlertFlags) register(cmd *cobra.Command) {
+	cmd.Flags().StringVarP(&f.fromFile, "from-file", "f", "",
+		"A JSON or YAML file holding the Burn Alert, or - for stdin. Other flags override values in the file.")
+	cmd.Flags().StringVarP(&f.sloID, "slo", "s", "",
+		"The ID of the SLO the Burn Alert belongs to.")
+	cmd.Flags().StringVarP(&f.alertType, "alert-type", "t", "",
+		"The type of Burn Alert. Enum: \"exhaustion_time\" \"budget_rate\"")
+	cmd.Flags().StringVar(&f.description, "description", "",
+		"A description of the Burn Alert.")
+	cmd.Flags().IntVar(&f.exhaustionMinutes, "exhaustion-minutes", 0,
+		"For exhaustion_time alerts, alert when the budget is predicted to run out within this many minutes.")
+	cmd.Flags().IntVar(&f.budgetRateWindowMinutes, "budget-rate-window-minutes", 0,
+		"For budget_rate alerts, the window in minutes over which the decrease in budget is measured. At least 60.")
+	cmd.Flags().Float64Var(&f.budgetRateDecrease, "budget-rate-decrease", 0,
+		"For budget_rate alerts, the percentage decrease in budget within the window at which to alert, e.g. 1.5.")
+	cmd.Flags().IntVar(&f.budgetRateDecreasePerMillion, "budget-rate-decrease-per-million", 0,
+		"For budget_rate alerts, the decrease in budget per million, e.g. 15000. Cannot be used with --budget-rate-decrease.")
+	cmd.Flags().StringSliceVar(&f.recipients, "recipient", nil,
+		"The ID of a Recipient to notify when the Burn Alert fires. Give ID:severity to set the severity of a PagerDuty incident. Can be repeated, and replaces any existing Recipients.")
+	cmd.MarkFlagsMutuallyExclusive("budget-rate-decrease", "budget-rate-decrease-per-million")
+}
+
+// apply overlays the flags that were set onto b, reading --from-file first if
+// it was given.
+func (f *burnAlertFlags) apply(cmd *cobra.Command, b *honeycomb.BurnAlert) error {
+	if f.fromFile != "" {
+		*b = honeycomb.BurnAlert{}
+		if err := readResourceFile(f.fromFile, b); err != nil {
+			return err
+		}
+	}
+
+	flags := cmd.Flags()
+	if flags.Changed("slo") {
+		b.SLO.ID = f.sloID
+	}
+	if flags.Changed("alert-type") {
+		// The API rejects the fields of the old type of an existing alert,
+		// so they are cleared. Those in a file are kept, for Validate to
+		// reject.
+		if f.fromFile == "" && b.AlertType != f.alertType {
+			switch f.alertType {
+			case "exhaustion_time":
+				b.BudgetRateWindowMinutes, b.BudgetRateDecreaseThresholdPerMillion = 0, 0
+			case "budget_rate":
+				b.ExhaustionMinutes = nil
+			}
+		}
+		b.AlertType = f.alertType
+	}
+	if flags.Changed("description") {
+		b.Description = f.description
+	}
+	if flags.Changed("exhaustion-minutes") {
+		minutes := f.exhaustionMinutes
+		b.ExhaustionMinutes = &minutes
+	}
+	if flags.Changed("budget-rate-window-minutes") {
+		b.BudgetRateWindowMinutes = f.budgetRateWindowMinutes
+	}
+	if flags.Changed("budget-rate-decrease") {
+		if f.budgetRateDecrease <= 0 || f.budgetRateDecrease > 100 {
+			return fmt.Errorf("--budget-rate-decrease %g must be a percentage between 0 and 100", f.budgetRateDecrease)
+		}
+		b.BudgetRateDecreaseThresholdPerMillion = honeycomb.PercentToPerMillion(f.budgetRateDecrease)
+	}
+	if flags.Changed("budget-rate-decrease-per-million") {
+		b.BudgetRateDecreaseThresholdPerMillion = f.budgetRateDecreasePerMillion
+	}
+	if flags.Changed("recipient") {
+		b.Recipients = parseRecipientFlags(f.recipients)
+	}
+
+	return nil
+}
+
+// Burn Alerts
+// https://docs.honeycomb.io/api/tag/Burn-Alerts
+func newBurnAlertsCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "burn_alerts",
+		Aliases: []string{"ba"},
+		Short:   "Manage Burn Alerts",
+		Long: "Burn Alerts notify you when the error budget of an SLO is being consumed too\n" +
+			"quickly, either because it is predicted to run out soon (exhaustion_time), or\n" +
+			"because it has fallen too far within a window of time (budget_rate).\n" +
+			"\n" +
+			"These commands allow you to list, create, update, and delete Burn Alerts.",
+	}
+
+	cmd.PersistentFlags().StringVarP(&targetDataset, "dataset", "d", honeycomb.EnvironmentWide,
+		"The dataset slug or use __all__ (or omit) for endpoints that support environment-wide operations.")
+
+	cmd.AddCommand(
+		newBurnAlertsCreateCmd(),
+		newBurnAlertsListCmd(),
+		newBurnAlertsGetCmd(),
+		newBurnAlertsUpdateCmd(),
+		newBurnAlertsDeleteCmd(),
+	)
+
+	return cmd
+}
+
+// Create a Burn Alert
+// https://docs.honeycomb.io/api/tag/Burn-Alerts#operation/createBurnAlert
+func newBurnAlertsCreateCmd() *cobra.Command {
+	var f burnAlertFlags
+
+	cmd := &cobra.Command{
+		Use:     "create",
+		Aliases: []string{"add", "new"},
+		Short:   "Create a Burn Alert for an SLO in the specified dataset.",
+		Long: "Create a Burn Alert for an SLO in the specified dataset. The alert type defaults to\n" +
+			"exhaustion_time.",
+		Example: "  honeybadger burn_alerts create -d my-service -s abc123 --exhaustion-minutes 240 --recipient def456\n" +
+			"  honeybadger burn_alerts create -d my-service -s abc123 -t budget_rate \\\n" +
+			"    --budget-rate-window-minutes 60 --budget-rate-decrease 1 --recipient def456",
+		Run: func(cmd *cobra.Command, args []string) {
+			var b honeycomb.BurnAlert
+			if err := f.apply(cmd, &b); err != nil {
+				fatal(log.Fields{
+					"_function": "newBurnAlertsCreateCmd",
+					"from_file": f.fromFile,
+				}, err, "Error received when attempting to read a burn alert.")
+			}
+			if b.AlertType == "" {
+				b.AlertType = "exhaustion_time"
+			}
+
+			if err := b.Validate(); err != nil {
+				fatal(log.Fields{
+					"_function":  "newBurnAlertsCreateCmd",
+					"burn_alert": b,
+				}, err, "Error received when attempting to validate a burn alert.")
+			}
+
+			created, err := client.CreateBurnAlert(cmd.Context(), targetDataset, &b)
+			if err != nil {
+				fatal(log.Fields{
+					"_function":  "newBurnAlertsCreateCmd",
+					"dataset":    targetDataset,
+					"burn_alert": b,
+				}, err, "Error received when attempting to create a new burn alert.")
+			}
+
+			printResponse(created)
+		},
+	}
+
+	f.register(cmd)
+
+	return cmd
+}
+
+// List All Burn Alerts for an SLO
+// https://docs.honeycomb.io/api/tag/Burn-Alerts#operation/listBurnAlertsBySlo
+func newBurnAlertsListCmd() *cobra.Command {
+	var (
+		sID string
+	)
+
+	cmd := &cobra.Command{
+		Use:     "list",
+		Aliases: []string{"ls"},
+		Short:   "List the Burn Alerts in the specified dataset.",
+		Long: "List the Burn Alerts for an SLO in the specified dataset. Without --slo, the Burn\n" +
+			"Alerts for every SLO in the dataset are listed.",
+		Run: func(cmd *cobra.Command, args []string) {
+			sloIDs := []string{sID}
+			if sID == "" {
+				slos, err := client.ListSLOs(cmd.Context(), targetDataset)
+				if err != nil {
+					fatal(log.Fields{
+						"_function": "newBurnAlertsListCmd",
+						"dataset":   targetDataset,
+					}, err, "Error received when attempting to list all SLOs.")
+				}
+				sloIDs = sloIDs[:0]
+				for _, s := range slos {
+					sloIDs = append(sloIDs, s.ID)
+				}
+			}
+
+			burnAlerts := []honeycomb.BurnAlert{}
+			for _, id := range sloIDs {
+				bas, err := client.ListBurnAlerts(cmd.Context(), targetDataset, id)
+				if err != nil {
+					fatal(log.Fields{
+						"_function": "newBurnAlertsListCmd",
+						"dataset":   targetDataset,
+						"slo_id":    id,
+					}, err, "Error received when attempting to list all burn alerts.")
+				}
+				burnAlerts = append(burnAlerts, bas...)
+			}
+
+			printResponse(burnAlerts)
+		},
+	}
+
+	cmd.Flags().StringVarP(&sID, "slo", "s", "", "Only list the Burn Alerts for the SLO with this ID.")
+
+	return cmd
+}
+
+// Get a Burn Alert
+// https://docs.honeycomb.io/api/tag/Burn-Alerts#operation/getBurnAlert
+func newBurnAlertsGetCmd() *cobra.Command {
+	var (
+		baID string
+	)
+
+	cmd := &cobra.Command{
+		Use:     "get",
+		Aliases: []string{},
+		Short:   "Get a single Burn Alert by ID.",
+		Long:    "Get a single Burn Alert by ID.",
+		Run: func(cmd *cobra.Command, args []string) {
+			b, err := client.GetBurnAlert(cmd.Context(), targetDataset, baID)
+			if err != nil {
+				fatal(log.Fields{
+					"_function":     "newBurnAlertsGetCmd",
+					"dataset":       targetDataset,
+					"burn_alert_id": baID,
+				}, err, "Error received when attempting to get a burn alert.")
+			}
+
+			printResponse(b)
+		},
+	}
+
+	cmd.Flags().StringVarP(&baID, "id", "i", "", "The unique identifier (ID) of a Burn Alert.")
+	cmd.MarkFlagRequired("id")
+
+	return cmd
+}
+
+// Update a Burn Alert
+// https://docs.honeycomb.io/api/tag/Burn-Alerts#operation/updateBurnAlert
+func newBurnAlertsUpdateCmd() *cobra.Command {
+	var (
+		baID string
+		f    burnAlertFlags
+	)
+
+	cmd := &cobra.Command{
+		Use:     "update",
+		Aliases: []string{"up", "edit", "modify", "change", "set"},
+		Short:   "Update a Burn Alert by ID.",
+		Long: "Update a Burn Alert by ID.\n" +
+			"\n" +
+			"Without --from-file, the existing Burn Alert is fetched and only the values given\n" +
+			"as flags are changed. With --from-file, the Burn Alert is replaced by the contents\n" +
+			"of the file, with any flags applied over it.",
+		Annotations: map[string]string{annotationLiveReads: ""},
+		Run: func(cmd *cobra.Command, args []string) {
+			var b honeycomb.BurnAlert
+			if f.fromFile == "" {
+				existing, err := client.GetBurnAlert(cmd.Context(), targetDataset, baID)
+				if err != nil {
+					fatal(log.Fields{
+						"_function":     "newBurnAlertsUpdateCmd",
+						"dataset":       targetDataset,
+						"burn_alert_id": baID,
+					}, err, "Error received when attempting to get the burn alert to update.")
+				}
+				b = *existing
+			}
+
+			if err := f.apply(cmd, &b); err != nil {
+				fatal(log.Fields{
+					"_function": "newBurnAlertsUpdateCmd",
+					"from_file": f.fromFile,
+				}, err, "Error received when attempting to read a burn alert.")
+			}
+
+			// Read only fields are not accepted in an update.
+			b.ID, b.CreatedAt, b.UpdatedAt = "", nil, nil
+
+			if err := b.Validate(); err != nil {
+				fatal(log.Fields{
+					"_function":  "newBurnAlertsUpdateCmd",
+					"burn_alert": b,
+				}, err, "Error received when attempting to validate a burn alert.")
+			}
+
+			updated, err := client.UpdateBurnAlert(cmd.Context(), targetDataset, baID, &b)
+			if err != nil {
+				fatal(log.Fields{
+					"_function":     "newBurnAlertsUpdateCmd",
+					"dataset":       targetDataset,
+					"burn_alert_id": baID,
+					"burn_alert":    b,
+				}, err, "Error received when attempting to update an existing burn alert.")
+			}
+
+			printResponse(updated)
+		},
+	}
+
+	cmd.Flags().StringVarP(&baID, "id", "i", "", "The unique identifier (ID) of a Burn Alert.")
+	cmd.MarkFlagRequired("id")
+	f.register(cmd)
+
+	return cmd
+}
+
+// Delete a Burn Alert
+// https://docs.honeycomb.io/api/tag/Burn-Alerts#operation/deleteBurnAlert
+func newBurnAlertsDeleteCmd() *cobra.Command {
+	var (
+		baID string
+	)
+
+	cmd := &cobra.Command{
+		Use:     "delete",
+		Aliases: []string{"rm", "remove", "del"},
+		Short:   "Delete a Burn Alert by ID.",
+		Long:    "Delete a Burn Alert by ID.",
+		Run: func(cmd *cobra.Command, args []string) {
+			err := client.DeleteBurnAlert(cmd.Context(), targetDataset, baID)
+			if err != nil {
+				fatal(log.Fields{
+					"_function":     "newBurnAlertsDeleteCmd",
+					"dataset":       targetDataset,
+					"burn_alert_id": baID,
+				}, err, "Error received when attempting to delete an existing burn alert.")
+			}
+		},
+	}
+
+	cmd.Flags().StringVarP(&baID, "id", "i", "", "The unique identifier (ID) of a Burn Alert.")
+	cmd.MarkFlagRequired("id")
+
+	return cmd
+}
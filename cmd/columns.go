@@ -0,0 +1,295 @@
+package cmd
+
+import (
+	"errors"
+
+	"github.com/spf13/cobra"
+
+	"github.com/adz-anz/honeybadger/honeycomb"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// resolveColumn gets a Column by ID, or by key name if no ID was given.
+func resolveColumn(cmd *cobra.Command, function, id, keyName string) *honeycomb.Column {
+	var (
+		col *honeycomb.Column
+		err error
+	)
+	if id != "" {
+		col, err = client.GetColumn(cmd.Context(), targetDataset, id)
+	} else {
+		col, err = client.GetColumnByKeyName(cmd.Context(), targetDataset, keyName)
+	}
+	if err != nil {
+		fatal(log.Fields{
+			"_function": function,
+			"dataset":   targetDataset,
+			"column_id": id,
+			"key_name":  keyName,
+		}, err, "Error received when attempting to get a column.")
+	}
+	return col
+}
+
+// requireColumnsDataset ensures a dataset was given, as Columns always belong
+// to one and cannot be managed environment-wide.
+func requireColumnsDataset(cmd *cobra.Command, args []string) error {
+	if targetDataset == "" || targetDataset == honeycomb.EnvironmentWide {
+		return errors.New("columns belong to a single dataset, given with --dataset")
+	}
+	return nil
+}
+
+// Columns
+// https://docs.honeycomb.io/api/tag/Columns
+func newColumnsCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "columns",
+		Aliases: []string{"c"},
+		Short:   "Manage Columns",
+		Long: "Columns are the fields of the events in a dataset. They are created automatically\n" +
+			"when events are sent, but can also be created ahead of time, and given a type,\n" +
+			"a description, or hidden from the query builder.\n" +
+			"\n" +
+			"These commands allow you to list, create, update, and delete Columns.",
+	}
+
+	cmd.PersistentFlags().StringVarP(&targetDataset, "dataset", "d", "",
+		"The slug of the dataset the Columns belong to.")
+
+	cmd.AddCommand(
+		newColumnsCreateCmd(),
+		newColumnsListCmd(),
+		newColumnsGetCmd(),
+		newColumnsUpdateCmd(),
+		newColumnsDeleteCmd(),
+	)
+
+	return cmd
+}
+
+// Create a Column
+// https://docs.honeycomb.io/api/tag/Columns#operation/createColumn
+func newColumnsCreateCmd() *cobra.Command {
+	var (
+		cKeyName     string
+		cType        string
+		cDescription string
+		cHidden      bool
+	)
+
+	cmd := &cobra.Command{
+		Use:     "create",
+		Aliases: []string{"add", "new"},
+		Short:   "Create a Column in the specified dataset.",
+		Long:    "Create a Column in the specified dataset.",
+		PreRunE: requireColumnsDataset,
+		Run: func(cmd *cobra.Command, args []string) {
+			var col = honeycomb.Column{
+				KeyName:     cKeyName,
+				Type:        cType,
+				Description: cDescription,
+				Hidden:      cHidden,
+			}
+
+			if err := col.Validate(); err != nil {
+				fatal(log.Fields{
+					"_function": "newColumnsCreateCmd",
+					"column":    col,
+				}, err, "Error received when attempting to validate a column.")
+			}
+
+			created, err := client.CreateColumn(cmd.Context(), targetDataset, &col)
+			if err != nil {
+				fatal(log.Fields{
+					"_function": "newColumnsCreateCmd",
+					"dataset":   targetDataset,
+					"column":    col,
+				}, err, "Error received when attempting to create a new column.")
+			}
+
+			printResponse(created)
+		},
+	}
+
+	cmd.Flags().StringVarP(&cKeyName, "key-name", "n", "",
+		"The name of the Column.")
+	cmd.MarkFlagRequired("key-name")
+	cmd.Flags().StringVarP(&cType, "type", "t", "",
+		"The type of the Column's values. Enum: \"string\" \"float\" \"integer\" \"boolean\"")
+	cmd.Flags().StringVar(&cDescription, "description", "",
+		"A description of the Column.")
+	cmd.Flags().BoolVar(&cHidden, "hidden", false,
+		"Hide the Column from autocomplete and the sidebar in the query builder.")
+
+	return cmd
+}
+
+// List All Columns
+// https://docs.honeycomb.io/api/tag/Columns#operation/listColumns
+func newColumnsListCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "list",
+		Aliases: []string{"ls"},
+		Short:   "List all Columns in the specified dataset.",
+		Long:    "List all Columns in the specified dataset.",
+		PreRunE: requireColumnsDataset,
+		Run: func(cmd *cobra.Command, args []string) {
+			columns, err := client.ListColumns(cmd.Context(), targetDataset)
+			if err != nil {
+				fatal(log.Fields{
+					"_function": "newColumnsListCmd",
+					"dataset":   targetDataset,
+				}, err, "Error received when attempting to list all columns.")
+			}
+
+			printResponse(columns)
+		},
+	}
+
+	return cmd
+}
+
+// Get a Column
+// https://docs.honeycomb.io/api/tag/Columns#operation/getColumn
+func newColumnsGetCmd() *cobra.Command {
+	var (
+		cID      string
+		cKeyName string
+	)
+
+	cmd := &cobra.Command{
+		Use:     "get",
+		Aliases: []string{},
+		Short:   "Get a single Column by ID or name.",
+		Long:    "Get a single Column by ID or name.",
+		PreRunE: requireColumnsDataset,
+		Run: func(cmd *cobra.Command, args []string) {
+			printResponse(resolveColumn(cmd, "newColumnsGetCmd", cID, cKeyName))
+		},
+	}
+
+	cmd.Flags().StringVarP(&cID, "id", "i", "", "The unique identifier (ID) of a Column.")
+	cmd.Flags().StringVarP(&cKeyName, "key-name", "n", "", "The name of a Column.")
+	cmd.MarkFlagsOneRequired("id", "key-name")
+	cmd.MarkFlagsMutuallyExclusive("id", "key-name")
+
+	return cmd
+}
+
+// Update a Column
+// https://docs.honeycomb.io/api/tag/Columns#operation/updateColumn
+func newColumnsUpdateCmd() *cobra.Command {
+	var (
+		cID          string
+		cKeyName     string
+		cType        string
+		cDescription string
+		cHidden      bool
+	)
+
+	cmd := &cobra.Command{
+		Use:     "update",
+		Aliases: []string{"up", "edit", "modify", "change", "set"},
+		Short:   "Update a Column by ID or name.",
+		Long: "Update a Column by ID or name.\n" +
+			"\n" +
+			"The existing Column is fetched and only the values given as flags are changed.\n" +
+			"A Column's name cannot be changed.",
+		Annotations: map[string]string{annotationLiveReads: ""},
+		PreRunE:     requireColumnsDataset,
+		Run: func(cmd *cobra.Command, args []string) {
+			col := resolveColumn(cmd, "newColumnsUpdateCmd", cID, cKeyName)
+			if cID == "" {
+				cID = col.ID
+			}
+
+			if cmd.Flags().Changed("type") {
+				col.Type = cType
+			}
+			if cmd.Flags().Changed("description") {
+				col.Description = cDescription
+			}
+			if cmd.Flags().Changed("hidden") {
+				col.Hidden = cHidden
+			}
+
+			// Read only fields are not accepted in an update.
+			col.ID, col.LastWritten, col.CreatedAt, col.UpdatedAt = "", nil, nil, nil
+
+			if err := col.Validate(); err != nil {
+				fatal(log.Fields{
+					"_function": "newColumnsUpdateCmd",
+					"column":    col,
+				}, err, "Error received when attempting to validate a column.")
+			}
+
+			updated, err := client.UpdateColumn(cmd.Context(), targetDataset, cID, col)
+			if err != nil {
+				fatal(log.Fields{
+					"_function": "newColumnsUpdateCmd",
+					"dataset":   targetDataset,
+					"column_id": cID,
+					"column":    col,
+				}, err, "Error received when attempting to update an existing column.")
+			}
+
+			printResponse(updated)
+		},
+	}
+
+	cmd.Flags().StringVarP(&cID, "id", "i", "", "The unique identifier (ID) of a Column.")
+	cmd.Flags().StringVarP(&cKeyName, "key-name", "n", "", "The name of a Column.")
+	cmd.MarkFlagsOneRequired("id", "key-name")
+	cmd.MarkFlagsMutuallyExclusive("id", "key-name")
+	cmd.Flags().StringVarP(&cType, "type", "t", "",
+		"The type of the Column's values. Enum: \"string\" \"float\" \"integer\" \"boolean\"")
+	cmd.Flags().StringVar(&cDescription, "description", "",
+		"A description of the Column.")
+	cmd.Flags().BoolVar(&cHidden, "hidden", false,
+		"Hide the Column from autocomplete and the sidebar in the query builder. Use --hidden=false to unhide it.")
+
+	return cmd
+}
+
+// Delete a Column
+// https://docs.honeycomb.io/api/tag/Columns#operation/deleteColumn
+func newColumnsDeleteCmd() *cobra.Command {
+	var (
+		cID      string
+		cKeyName string
+	)
+
+	cmd := &cobra.Command{
+		Use:     "delete",
+		Aliases: []string{"rm", "remove", "del"},
+		Short:   "Delete a Column by ID or name.",
+		Long: "Delete a Column by ID or name.\n" +
+			"\n" +
+			"Deleting a Column also deletes its data, and cannot be undone.",
+		Annotations: map[string]string{annotationLiveReads: ""},
+		PreRunE:     requireColumnsDataset,
+		Run: func(cmd *cobra.Command, args []string) {
+			if cID == "" {
+				cID = resolveColumn(cmd, "newColumnsDeleteCmd", cID, cKeyName).ID
+			}
+
+			err := client.DeleteColumn(cmd.Context(), targetDataset, cID)
+			if err != nil {
+				fatal(log.Fields{
+					"_function": "newColumnsDeleteCmd",
+					"dataset":   targetDataset,
+					"column_id": cID,
+				}, err, "Error received when attempting to delete an existing column.")
+			}
+		},
+	}
+
+	cmd.Flags().StringVarP(&cID, "id", "i", "", "The unique identifier (ID) of a Column.")
+	cmd.Flags().StringVarP(&cKeyName, "key-name", "n", "", "The name of a Column.")
+	cmd.MarkFlagsOneRequired("id", "key-name")
+	cmd.MarkFlagsMutuallyExclusive("id", "key-name")
+
+	return cmd
+}
@@ -0,0 +1,496 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"gopkg.in/yaml.v3"
+
+	"github.com/adz-anz/honeybadger/honeycomb"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// The key holding the name of the profile used when --profile is not given.
+	configCurrentProfileKey = "current-profile"
+
+	// The key holding the named profiles.
+	configProfilesKey = "profiles"
+
+	// Commands carrying this annotation, or whose parent does, can be run
+	// without a configuration key.
+	annotationNoConfigKey = "honeybadger/no-configkey"
+
+	// Commands carrying this annotation read the current state even on a dry
+	// run, so that only the changes they would make are skipped.
+	annotationLiveReads = "honeybadger/live-reads"
+)
+
+var (
+	// The profile selected with --profile or HONEYBADGER_PROFILE.
+	profileName string
+
+	// The config file that was read, if any.
+	configFileUsed string
+
+	// profileKeys are the settings a profile may hold, named after the flags
+	// they provide defaults for.
+	profileKeys = []string{"configkey", "api_host", "dataset", "output"}
+)
+
+// profileSummary describes a profile for config list-profiles.
+type profileSummary struct {
+	Name    string `json:"name"`
+	Current bool   `json:"current"`
+	APIHost string `json:"api_host,omitempty"`
+	Dataset string `json:"dataset,omitempty"`
+	Output  string `json:"output,omitempty"`
+}
+
+// userConfigDir returns $XDG_CONFIG_HOME/honeybadger, falling back to
+// ~/.config/honeybadger when XDG_CONFIG_HOME is not set.
+func userConfigDir() (string, error) {
+	base := os.Getenv("XDG_CONFIG_HOME")
+	if base == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", err
+		}
+		base = filepath.Join(home, ".config")
+	}
+	return filepath.Join(base, appName), nil
+}
+
+// resolveProfile selects the active profile and merges its settings over the
+// top-level settings of the config file. Environment variables and flags still
+// take precedence over both.
+func resolveProfile(cmd *cobra.Command, v *viper.Viper) error {
+	name := profileName
+	if !cmd.Flags().Changed("profile") {
+		name = v.GetString("profile")
+	}
+	if name == "" {
+		name = v.GetString(configCurrentProfileKey)
+	}
+	if name == "" {
+		return nil
+	}
+	profileName = name
+
+	key := configProfilesKey + "." + name
+	if !v.IsSet(key) {
+		// A profile that does not exist yet is fine for the config
+		// commands, which may be about to create it.
+		if skipsConfigKey(cmd) {
+			return nil
+		}
+		return fmt.Errorf("profile %q not found in %s", name, configFileDescription())
+	}
+
+	return v.MergeConfigMap(v.GetStringMap(key))
+}
+
+// skipsConfigKey reports whether cmd can be run without a configuration key.
+func skipsConfigKey(cmd *cobra.Command) bool {
+	for c := cmd; c != nil; c = c.Parent() {
+		if _, ok := c.Annotations[annotationNoConfigKey]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+func configFileDescription() string {
+	if configFileUsed == "" {
+		return "the config file"
+	}
+	return configFileUsed
+}
+
+// configWritePath returns the config file that the config commands modify:
+// the one that was read, or a new file in the user config directory.
+func configWritePath() (string, error) {
+	if configFileUsed != "" {
+		return configFileUsed, nil
+	}
+	dir, err := userConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, viperDefaultConfigFilename+".yaml"), nil
+}
+
+// readConfigFile reads a YAML or JSON config file into a map. A file that does
+// not exist yet reads as empty.
+func readConfigFile(path string) (map[string]interface{}, error) {
+	data := map[string]interface{}{}
+
+	raw, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return data, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		err = json.Unmarshal(raw, &data)
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(raw, &data)
+	default:
+		return nil, fmt.Errorf("the config commands only support YAML and JSON files, not %s", path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	if data == nil {
+		data = map[string]interface{}{}
+	}
+	return data, nil
+}
+
+// writeConfigFile writes a config file, creating its directory if needed. The
+// file is only readable by the current user, as it holds configuration keys.
+func writeConfigFile(path string, data map[string]interface{}) error {
+	var (
+		raw []byte
+		err error
+	)
+	if strings.ToLower(filepath.Ext(path)) == ".json" {
+		raw, err = json.MarshalIndent(data, "", "  ")
+	} else {
+		raw, err = yaml.Marshal(data)
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return err
+	}
+	return os.WriteFile(path, raw, 0o600)
+}
+
+// profilesOf returns the profiles held in a config file, creating the map if
+// it does not exist yet.
+func profilesOf(data map[string]interface{}) map[string]interface{} {
+	profiles, ok := data[configProfilesKey].(map[string]interface{})
+	if !ok {
+		profiles = map[string]interface{}{}
+		data[configProfilesKey] = profiles
+	}
+	return profiles
+}
+
+// validateProfileKey ensures key is one of the settings a profile may hold.
+func validateProfileKey(key string) error {
+	for _, k := range profileKeys {
+		if k == key {
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown setting %q, must be one of: %s", key, strings.Join(profileKeys, ", "))
+}
+
+// profileClient creates a Client with the configuration key and API host of a
+// named profile, for commands that act on more than one environment. The API
+// host defaults to the one given by --api_host.
+func profileClient(cmd *cobra.Command, name string) (*honeycomb.Client, error) {
+	path, err := configWritePath()
+	if err != nil {
+		return nil, err
+	}
+	data, err := readConfigFile(path)
+	if err != nil {
+		return nil, err
+	}
+	profile, ok := profilesOf(data)[name].(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("profile %q not found in %s", name, path)
+	}
+
+	key, _ := profile["configkey"].(string)
+	if key == "" {
+		return nil, fmt.Errorf("profile %q has no configkey", name)
+	}
+	host, _ := profile["api_host"].(string)
+	if host == "" || cmd.Flags().Changed("api_host") {
+		host = apiHost
+	}
+	if redactor != nil {
+		redactor.AddValues(key)
+	}
+	return newClient(cmd, host, key)
+}
+
+// targetProfile returns the profile the config get/set commands act on.
+func targetProfile() (string, error) {
+	if profileName == "" {
+		return "", errors.New("no profile selected, use --profile or `config use`")
+	}
+	return profileName, nil
+}
+
+// Config
+// CUSTOM
+func newConfigCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "config",
+		Aliases: []string{"cfg"},
+		Short:   "Manage configuration profiles",
+		Long: "Profiles hold the configuration key, API host, default dataset and output format\n" +
+			"for a Honeycomb team and environment, so that switching between environments\n" +
+			"does not require re-exporting HONEYBADGER_CONFIGKEY.\n" +
+			"\n" +
+			"Profiles are read from ./honeybadger.yaml, or from\n" +
+			"$XDG_CONFIG_HOME/honeybadger/honeybadger.yaml, and selected with --profile,\n" +
+			"HONEYBADGER_PROFILE or `config use`.",
+		Annotations: map[string]string{annotationNoConfigKey: ""},
+	}
+
+	cmd.AddCommand(
+		newConfigListProfilesCmd(),
+		newConfigUseCmd(),
+		newConfigSetCmd(),
+		newConfigGetCmd(),
+		newConfigViewCmd(),
+		newConfigCurrentCmd(),
+	)
+
+	return cmd
+}
+
+func newConfigListProfilesCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "list-profiles",
+		Aliases: []string{"ls", "list"},
+		Short:   "List all profiles.",
+		Long:    "List all profiles in the config file, marking the one currently selected.",
+		Run: func(cmd *cobra.Command, args []string) {
+			_, data := mustReadConfigFile("newConfigListProfilesCmd")
+
+			profiles := profilesOf(data)
+			names := make([]string, 0, len(profiles))
+			for name := range profiles {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+
+			summaries := make([]profileSummary, 0, len(names))
+			for _, name := range names {
+				p, _ := profiles[name].(map[string]interface{})
+				summaries = append(summaries, profileSummary{
+					Name:    name,
+					Current: name == profileName,
+					APIHost: fmt.Sprint(valueOr(p["api_host"], "")),
+					Dataset: fmt.Sprint(valueOr(p["dataset"], "")),
+					Output:  fmt.Sprint(valueOr(p["output"], "")),
+				})
+			}
+
+			printResponse(summaries)
+		},
+	}
+
+	return cmd
+}
+
+func newConfigUseCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "use <profile>",
+		Short: "Select the profile used by default.",
+		Long:  "Select the profile used when neither --profile nor HONEYBADGER_PROFILE is set.",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			path, data := mustReadConfigFile("newConfigUseCmd")
+
+			if _, ok := profilesOf(data)[args[0]]; !ok {
+				fatal(log.Fields{
+					"_function": "newConfigUseCmd",
+					"profile":   args[0],
+				}, fmt.Errorf("profile %q not found, create it with `config set --profile %s <key> <value>`", args[0], args[0]),
+					"Error received when attempting to select a profile.")
+			}
+			data[configCurrentProfileKey] = args[0]
+
+			mustWriteConfigFile("newConfigUseCmd", path, data)
+		},
+	}
+
+	return cmd
+}
+
+func newConfigSetCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "set <key> <value>",
+		Short: "Set a setting of a profile.",
+		Long: "Set a setting of the selected profile, creating the profile if it does not exist.\n" +
+			"\n" +
+			"The key must be one of: " + strings.Join(profileKeys, ", ") + ".",
+		Example: "  honeybadger config set --profile staging configkey abc123\n" +
+			"  honeybadger config set --profile staging dataset my-service",
+		Args: cobra.ExactArgs(2),
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := validateProfileKey(args[0]); err != nil {
+				fatal(log.Fields{
+					"_function": "newConfigSetCmd",
+				}, err, "Error received when attempting to set a profile setting.")
+			}
+			name, err := targetProfile()
+			if err != nil {
+				fatal(log.Fields{
+					"_function": "newConfigSetCmd",
+				}, err, "Error received when attempting to set a profile setting.")
+			}
+
+			path, data := mustReadConfigFile("newConfigSetCmd")
+
+			profiles := profilesOf(data)
+			p, ok := profiles[name].(map[string]interface{})
+			if !ok {
+				p = map[string]interface{}{}
+				profiles[name] = p
+			}
+			p[args[0]] = args[1]
+
+			// The first profile created becomes the current one.
+			if _, ok := data[configCurrentProfileKey]; !ok {
+				data[configCurrentProfileKey] = name
+			}
+
+			mustWriteConfigFile("newConfigSetCmd", path, data)
+		},
+	}
+
+	return cmd
+}
+
+func newConfigGetCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "get <key>",
+		Short: "Get a setting of a profile.",
+		Long: "Get a setting of the selected profile.\n" +
+			"\n" +
+			"The configuration key is masked unless --show-secrets is given.",
+		Args: cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := validateProfileKey(args[0]); err != nil {
+				fatal(log.Fields{
+					"_function": "newConfigGetCmd",
+				}, err, "Error received when attempting to get a profile setting.")
+			}
+			name, err := targetProfile()
+			if err != nil {
+				fatal(log.Fields{
+					"_function": "newConfigGetCmd",
+				}, err, "Error received when attempting to get a profile setting.")
+			}
+
+			_, data := mustReadConfigFile("newConfigGetCmd")
+
+			p, _ := profilesOf(data)[name].(map[string]interface{})
+			val := fmt.Sprint(valueOr(p[args[0]], ""))
+			if args[0] == "configkey" && !showSecrets && val != "" {
+				val = honeycomb.RedactedValue
+			}
+
+			fmt.Println(val)
+		},
+	}
+
+	return cmd
+}
+
+func newConfigViewCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "view",
+		Short: "Show the config file.",
+		Long: "Show the contents of the config file.\n" +
+			"\n" +
+			"Configuration keys are masked unless --show-secrets is given.",
+		Run: func(cmd *cobra.Command, args []string) {
+			_, data := mustReadConfigFile("newConfigViewCmd")
+
+			if !showSecrets {
+				if _, ok := data["configkey"]; ok {
+					data["configkey"] = honeycomb.RedactedValue
+				}
+				for _, p := range profilesOf(data) {
+					if p, ok := p.(map[string]interface{}); ok {
+						if _, ok := p["configkey"]; ok {
+							p["configkey"] = honeycomb.RedactedValue
+						}
+					}
+				}
+			}
+
+			printResponse(data)
+		},
+	}
+
+	return cmd
+}
+
+func newConfigCurrentCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "current",
+		Short: "Show the profile in use.",
+		Long:  "Show the name of the profile in use, taking --profile and HONEYBADGER_PROFILE into account.",
+		Run: func(cmd *cobra.Command, args []string) {
+			if profileName == "" {
+				fatal(log.Fields{
+					"_function": "newConfigCurrentCmd",
+				}, errors.New("no profile selected"), "Error received when attempting to show the current profile.")
+			}
+			fmt.Println(profileName)
+		},
+	}
+
+	return cmd
+}
+
+// mustReadConfigFile reads the config file the config commands act on,
+// exiting if it cannot be read.
+func mustReadConfigFile(function string) (string, map[string]interface{}) {
+	path, err := configWritePath()
+	if err != nil {
+		fatal(log.Fields{
+			"_function": function,
+		}, err, "Error received when attempting to locate the config file.")
+	}
+	data, err := readConfigFile(path)
+	if err != nil {
+		fatal(log.Fields{
+			"_function": function,
+			"path":      path,
+		}, err, "Error received when attempting to read the config file.")
+	}
+	return path, data
+}
+
+// mustWriteConfigFile writes the config file, exiting if it cannot be written.
+func mustWriteConfigFile(function, path string, data map[string]interface{}) {
+	if err := writeConfigFile(path, data); err != nil {
+		fatal(log.Fields{
+			"_function": function,
+			"path":      path,
+		}, err, "Error received when attempting to write the config file.")
+	}
+}
+
+// valueOr returns v, or def if v is nil.
+func valueOr(v, def interface{}) interface{} {
+	if v == nil {
+		return def
+	}
+	return v
+}
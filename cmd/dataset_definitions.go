@@ -1,79 +1,13 @@
 package cmd
 
 import (
-	"encoding/json"
-	"net/http"
-
 	"github.com/spf13/cobra"
 
+	"github.com/adz-anz/honeybadger/honeycomb"
+
 	log "github.com/sirupsen/logrus"
 )
 
-type datasetDefinitionColumn struct {
-	// The name of the Column or Derived Column to map to this Dataset
-	// Definition Type. An empty string clears the mapping, potentially
-	// reverting to a default mapping.
-	Name string `json:"name,omitempty"`
-
-	// Optional: column for regular columns and derived_column for derived
-	// columns when setting Dataset Definitions. Honeycomb does not use this
-	// field when updating Dataset definitions.
-	ColumnType string `json:"column_type,omitempty"`
-}
-
-type datasetDefinition struct {
-	// The unique identifier (ID) for each span.
-	SpanID datasetDefinitionColumn `json:"span_id,omitempty"`
-
-	// The ID of the trace this span belongs to.
-	TraceID datasetDefinitionColumn `json:"trace_id,omitempty"`
-
-	// The Parent Span ID - The ID of this span's parent span, the call
-	// location the current span was called from.
-	ParentID datasetDefinitionColumn `json:"parent_id,omitempty"`
-
-	// The name of the function or method where the span was created.
-	Name datasetDefinitionColumn `json:"name,omitempty"`
-
-	// The name of the instrumented service.
-	ServiceName datasetDefinitionColumn `json:"service_name,omitempty"`
-
-	// Span Duration - How much time the span took, in milliseconds.
-	DurationMs datasetDefinitionColumn `json:"duration_ms,omitempty"`
-
-	// Metadata: Kind - The kind of Span. For example, client or server. The
-	// use of this field to identify Span Events and Links is deprecated. Use
-	// the field Metadata: Annotation Type.
-	SpanKind datasetDefinitionColumn `json:"span_kind,omitempty"`
-
-	// Metadata: Annotation Type - The type of span annotation. For example,
-	// span_event or link. This lets Honeycomb visualize this type of event
-	// differently in a trace. Do not use this field for other purposes.
-	AnnotationType datasetDefinitionColumn `json:"annotation_type,omitempty"`
-
-	// Metadata: Link Span ID - Links let you tie traces and spans to one
-	// another. The Link Span ID lets you link to a different span (when used
-	// with Link Trace ID).
-	LinkSpanID datasetDefinitionColumn `json:"link_span_id,omitempty"`
-
-	// Metadata: Link Trace ID - Links let you tie traces and spans to one
-	// another. The Link Trace Id lets you link to a different trace or a
-	// different span in the same trace (when used with Link Span ID).
-	LinkTraceID datasetDefinitionColumn `json:"link_trace_id,omitempty"`
-
-	// Use a Boolean or String to indicate error.
-	Error datasetDefinitionColumn `json:"error,omitempty"`
-
-	// Indicates the success, failure, or other status of a request.
-	Status datasetDefinitionColumn `json:"status,omitempty"`
-
-	// The HTTP URL or equivalent route processed by the request.
-	Route datasetDefinitionColumn `json:"route,omitempty"`
-
-	// The user making the request in the system.
-	User datasetDefinitionColumn `json:"user,omitempty"`
-}
-
 func newDatasetDefinitionsCmd() *cobra.Command {
 	cmd := &cobra.Command{
 		Use:     "dataset_definitions",
@@ -124,73 +58,61 @@ func newDatasetDefinitionsUpdateCmd() *cobra.Command {
 			"Note: While the PATCH payload can include the column_type, Honeycomb does not use\n" +
 			"this field when updating Dataset Definitions.",
 		Run: func(cmd *cobra.Command, args []string) {
-			var dd = datasetDefinition{
-				SpanID: datasetDefinitionColumn{
+			var dd = honeycomb.DatasetDefinition{
+				SpanID: honeycomb.DatasetDefinitionColumn{
 					Name: ddSpanIDName,
 				},
-				TraceID: datasetDefinitionColumn{
+				TraceID: honeycomb.DatasetDefinitionColumn{
 					Name: ddTraceIDName,
 				},
-				ParentID: datasetDefinitionColumn{
+				ParentID: honeycomb.DatasetDefinitionColumn{
 					Name: ddParentIDName,
 				},
-				Name: datasetDefinitionColumn{
+				Name: honeycomb.DatasetDefinitionColumn{
 					Name: ddName,
 				},
-				ServiceName: datasetDefinitionColumn{
+				ServiceName: honeycomb.DatasetDefinitionColumn{
 					Name: ddServiceName,
 				},
-				DurationMs: datasetDefinitionColumn{
+				DurationMs: honeycomb.DatasetDefinitionColumn{
 					Name: ddDurationMs,
 				},
-				SpanKind: datasetDefinitionColumn{
+				SpanKind: honeycomb.DatasetDefinitionColumn{
 					Name: ddSpanKind,
 				},
-				AnnotationType: datasetDefinitionColumn{
+				AnnotationType: honeycomb.DatasetDefinitionColumn{
 					Name: ddAnnotationType,
 				},
-				LinkSpanID: datasetDefinitionColumn{
+				LinkSpanID: honeycomb.DatasetDefinitionColumn{
 					Name: ddLinkSpanID,
 				},
-				LinkTraceID: datasetDefinitionColumn{
+				LinkTraceID: honeycomb.DatasetDefinitionColumn{
 					Name: ddLinkTraceID,
 				},
-				Error: datasetDefinitionColumn{
+				Error: honeycomb.DatasetDefinitionColumn{
 					Name: ddError,
 				},
-				Status: datasetDefinitionColumn{
+				Status: honeycomb.DatasetDefinitionColumn{
 					Name: ddStatus,
 				},
-				Route: datasetDefinitionColumn{
+				Route: honeycomb.DatasetDefinitionColumn{
 					Name: ddRoute,
 				},
-				User: datasetDefinitionColumn{
+				User: honeycomb.DatasetDefinitionColumn{
 					Name: ddUser,
 				},
 			}
-			var bodyMarshal, err = json.Marshal(dd)
+
+			updated, err := client.UpdateDatasetDefinitions(cmd.Context(), dSlug, &dd)
 			if err != nil {
-				log.WithFields(log.Fields{
+				fatal(log.Fields{
 					"_function":          "newDatasetDefinitionsUpdateCmd",
-					"err":                err,
+					"dataset_slug":       dSlug,
 					"dataset_definition": dd,
-				}).Fatal("Error received when attempting to marshal a dataset definition.")
-			}
-			var p = payload{
-				Method:   http.MethodPatch,
-				Path:     "/1/dataset_definitions/" + dSlug,
-				Body:     bodyMarshal,
-				Response: &datasetDefinition{},
+				}, err, "Error received when attempting to update a dataset definition.")
 			}
 
-			err = p.GetResponse(true)
-			if err != nil {
-				log.WithFields(log.Fields{
-					"_function": "newDatasetDefinitionsUpdateCmd",
-					"err":       err,
-					"payload":   p,
-				}).Fatal("Error received when attempting to update a dataset definition.")
-			}
+			printResponse(updated)
 		},
 	}
 
@@ -247,20 +169,15 @@ func newDatasetDefinitionsGetCmd() *cobra.Command {
 			"\n" +
 			"The response returns an object with a Dataset Definition for each set Dataset Definition type.",
 		Run: func(cmd *cobra.Command, args []string) {
-			var p = payload{
-				Method:   http.MethodGet,
-				Path:     "/1/dataset_definitions/" + dSlug,
-				Response: &datasetDefinition{},
-			}
-
-			var err = p.GetResponse(true)
+			dd, err := client.GetDatasetDefinitions(cmd.Context(), dSlug)
 			if err != nil {
-				log.WithFields(log.Fields{
-					"_function": "newDatasetDefinitionsGetCmd",
-					"err":       err,
-					"payload":   p,
-				}).Fatal("Error received when attempting to get a dataset definition.")
+				fatal(log.Fields{
+					"_function":    "newDatasetDefinitionsGetCmd",
+					"dataset_slug": dSlug,
+				}, err, "Error received when attempting to get a dataset definition.")
 			}
+
+			printResponse(dd)
 		},
 	}
 
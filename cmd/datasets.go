@@ -1,39 +1,13 @@
 package cmd
 
 import (
-	"encoding/json"
-	"net/http"
-
 	"github.com/spf13/cobra"
 
+	"github.com/adz-anz/honeybadger/honeycomb"
+
 	log "github.com/sirupsen/logrus"
 )
 
-type dataset struct {
-	// The name of the dataset.
-	Name string `json:"name,omitempty"`
-
-	// A description for the dataset.
-	Description string `json:"description,omitempty"`
-
-	// The maximum unpacking depth of nested JSON fields.
-	ExpandJSONDepth int `json:"expand_json_depth,omitempty"`
-
-	// The 'slug' of the dataset to be used in URLs.
-	Slug string `json:"slug,omitempty"`
-
-	// The total number of unique fields for this Dataset. The value will be
-	// null if the dataset does not contain any fields yet.
-	RegularColumnsCount int `json:"regular_columns_count,omitempty"`
-
-	// The ISO8601-formatted time when the dataset last received event data.
-	// The value will be null if no data has been received yet.
-	LastWrittenAt string `json:"last_written_at,omitempty"`
-
-	// The ISO8601-formatted time when the dataset was created.
-	CreatedAt string `json:"created_at,omitempty"`
-}
-
 func newDatasetsCmd() *cobra.Command {
 	cmd := &cobra.Command{
 		Use:     "datasets",
@@ -71,34 +45,21 @@ func newDatasetsCreateCmd() *cobra.Command {
 		Long: "Create a Dataset.  If a Dataset already exists by that name (or slug), then\n" +
 			"the existing dataset will be returned.",
 		Run: func(cmd *cobra.Command, args []string) {
-			var d = dataset{
+			var d = honeycomb.Dataset{
 				Name:            dName,
 				Description:     dDescription,
 				ExpandJSONDepth: dExpandJSONDepth,
 			}
-			var bodyMarshal, err = json.Marshal(d)
+
+			created, err := client.CreateDataset(cmd.Context(), &d)
 			if err != nil {
-				log.WithFields(log.Fields{
+				fatal(log.Fields{
 					"_function": "newDatasetsCreateCmd",
-					"err":       err,
 					"dataset":   d,
-				}).Fatal("Error received when attempting to marshal a dataset.")
-			}
-			var p = payload{
-				Method:   http.MethodPost,
-				Path:     "/1/datasets",
-				Body:     bodyMarshal,
-				Response: &dataset{},
+				}, err, "Error received when attempting to create a new dataset.")
 			}
 
-			err = p.GetResponse(true)
-			if err != nil {
-				log.WithFields(log.Fields{
-					"_function": "newDatasetsCreateCmd",
-					"err":       err,
-					"payload":   p,
-				}).Fatal("Error received when attempting to create a new dataset.")
-			}
+			printResponse(created)
 		},
 	}
 
@@ -122,20 +83,14 @@ func newDatasetsListCmd() *cobra.Command {
 		Short:   "List all Datasets.",
 		Long:    "Lists all Datasets for an environment.",
 		Run: func(cmd *cobra.Command, args []string) {
-			var p = payload{
-				Method:   http.MethodGet,
-				Path:     "/1/datasets",
-				Response: &[]dataset{},
-			}
-
-			var err = p.GetResponse(true)
+			datasets, err := client.ListDatasets(cmd.Context())
 			if err != nil {
-				log.WithFields(log.Fields{
+				fatal(log.Fields{
 					"_function": "newDatasetsListCmd",
-					"err":       err,
-					"payload":   p,
-				}).Fatal("Error received when attempting to list all datasets.")
+				}, err, "Error received when attempting to list all datasets.")
 			}
+
+			printResponse(datasets)
 		},
 	}
 
@@ -154,20 +109,15 @@ func newDatasetsGetCmd() *cobra.Command {
 		Short:   "Get a Dataset.",
 		Long:    "Get a single Dataset by slug.",
 		Run: func(cmd *cobra.Command, args []string) {
-			var p = payload{
-				Method:   http.MethodGet,
-				Path:     "/1/datasets/" + dSlug,
-				Response: &dataset{},
-			}
-
-			var err = p.GetResponse(true)
+			d, err := client.GetDataset(cmd.Context(), dSlug)
 			if err != nil {
-				log.WithFields(log.Fields{
-					"_function": "newDatasetsGetCmd",
-					"err":       err,
-					"payload":   p,
-				}).Fatal("Error received when attempting to get a dataset.")
+				fatal(log.Fields{
+					"_function":    "newDatasetsGetCmd",
+					"dataset_slug": dSlug,
+				}, err, "Error received when attempting to get a dataset.")
 			}
+
+			printResponse(d)
 		},
 	}
 
@@ -199,19 +149,12 @@ func newDatasetsDeleteCmd() *cobra.Command {
 			"use it. If you would like access to this endpoint despite the above-listed risks, please\n" +
 			"have your Honeycomb team owner contact Honeycomb Support or email Support.",
 		Run: func(cmd *cobra.Command, args []string) {
-			var p = payload{
-				Method:   http.MethodDelete,
-				Path:     "/1/datasets/" + dSlug,
-				Response: nil,
-			}
-
-			var err = p.GetResponse(true)
+			err := client.DeleteDataset(cmd.Context(), dSlug)
 			if err != nil {
-				log.WithFields(log.Fields{
-					"_function": "newDatasetsDeleteCmd",
-					"err":       err,
-					"payload":   p,
-				}).Fatal("Error received when attempting to delete a dataset.")
+				fatal(log.Fields{
+					"_function":    "newDatasetsDeleteCmd",
+					"dataset_slug": dSlug,
+				}, err, "Error received when attempting to delete a dataset.")
 			}
 		},
 	}
@@ -240,33 +183,21 @@ func newDatasetsUpdateCmd() *cobra.Command {
 			"Both fields must be specified, as omitting one will have the effect of reverting the\n" +
 			"setting to the default.",
 		Run: func(cmd *cobra.Command, args []string) {
-			var d = dataset{
+			var d = honeycomb.Dataset{
 				Description:     dDescription,
 				ExpandJSONDepth: dExpandJSONDepth,
 			}
-			var bodyMarshal, err = json.Marshal(d)
-			if err != nil {
-				log.WithFields(log.Fields{
-					"_function": "newDatasetsUpdateCmd",
-					"err":       err,
-					"dataset":   d,
-				}).Fatal("Error received when attempting to marshal a dataset.")
-			}
-			var p = payload{
-				Method:   http.MethodPut,
-				Path:     "/1/datasets/" + dSlug,
-				Body:     bodyMarshal,
-				Response: &dataset{},
-			}
 
-			err = p.GetResponse(true)
+			updated, err := client.UpdateDataset(cmd.Context(), dSlug, &d)
 			if err != nil {
-				log.WithFields(log.Fields{
-					"_function": "newDatasetsUpdateCmd",
-					"err":       err,
-					"payload":   p,
-				}).Fatal("Error received when attempting to update a dataset.")
+				fatal(log.Fields{
+					"_function":    "newDatasetsUpdateCmd",
+					"dataset_slug": dSlug,
+					"dataset":      d,
+				}, err, "Error received when attempting to update a dataset.")
 			}
+
+			printResponse(updated)
 		},
 	}
 
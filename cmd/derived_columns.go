@@ -0,0 +1,305 @@
+package cmd
+
+import (
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/adz-anz/honeybadger/honeycomb"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// readExpression returns the expression given with --expression, or read from
+// --expression-file, so that long expressions can be kept in version control.
+func readExpression(function, expression, expressionFile string) string {
+	if expressionFile == "" {
+		return expression
+	}
+
+	raw, err := readFileOrStdin(expressionFile)
+	if err != nil {
+		fatal(log.Fields{
+			"_function":       function,
+			"expression_file": expressionFile,
+		}, err, "Error received when attempting to read an expression.")
+	}
+	return strings.TrimSpace(string(raw))
+}
+
+// resolveDerivedColumn gets a Derived Column by ID, or by alias if no ID was
+// given.
+func resolveDerivedColumn(cmd *cobra.Command, function, id, alias string) *honeycomb.DerivedColumn {
+	var (
+		dc  *honeycomb.DerivedColumn
+		err error
+	)
+	if id != "" {
+		dc, err = client.GetDerivedColumn(cmd.Context(), targetDataset, id)
+	} else {
+		dc, err = client.GetDerivedColumnByAlias(cmd.Context(), targetDataset, alias)
+	}
+	if err != nil {
+		fatal(log.Fields{
+			"_function":         function,
+			"dataset":           targetDataset,
+			"derived_column_id": id,
+			"alias":             alias,
+		}, err, "Error received when attempting to get a derived column.")
+	}
+	return dc
+}
+
+// Derived Columns
+// https://docs.honeycomb.io/api/tag/Derived-Columns
+func newDerivedColumnsCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "derived_columns",
+		Aliases: []string{"dc"},
+		Short:   "Manage Derived Columns",
+		Long: "Derived Columns calculate a value for each event from the other columns of the\n" +
+			"event, using an expression. They can be created for a single dataset, or for the\n" +
+			"whole environment using the __all__ dataset.\n" +
+			"\n" +
+			"These commands allow you to list, create, update, and delete Derived Columns.",
+	}
+
+	cmd.PersistentFlags().StringVarP(&targetDataset, "dataset", "d", honeycomb.EnvironmentWide,
+		"The dataset slug or use __all__ (or omit) for endpoints that support environment-wide operations.")
+
+	cmd.AddCommand(
+		newDerivedColumnsCreateCmd(),
+		newDerivedColumnsListCmd(),
+		newDerivedColumnsGetCmd(),
+		newDerivedColumnsUpdateCmd(),
+		newDerivedColumnsDeleteCmd(),
+	)
+
+	return cmd
+}
+
+// Create a Derived Column
+// https://docs.honeycomb.io/api/tag/Derived-Columns#operation/createDerivedColumn
+func newDerivedColumnsCreateCmd() *cobra.Command {
+	var (
+		dcAlias          string
+		dcExpression     string
+		dcExpressionFile string
+		dcDescription    string
+	)
+
+	cmd := &cobra.Command{
+		Use:     "create",
+		Aliases: []string{"add", "new"},
+		Short:   "Create a Derived Column in the specified dataset.",
+		Long:    "Create a Derived Column in the specified dataset, or environment-wide with __all__.",
+		Example: "  honeybadger derived_columns create -d my-service -a is_error -e 'GTE($status_code, 500)'\n" +
+			"  honeybadger derived_columns create -a is_success -E derived/is_success.hcdc",
+		Run: func(cmd *cobra.Command, args []string) {
+			var dc = honeycomb.DerivedColumn{
+				Alias:       dcAlias,
+				Expression:  readExpression("newDerivedColumnsCreateCmd", dcExpression, dcExpressionFile),
+				Description: dcDescription,
+			}
+
+			if err := dc.Validate(); err != nil {
+				fatal(log.Fields{
+					"_function":      "newDerivedColumnsCreateCmd",
+					"derived_column": dc,
+				}, err, "Error received when attempting to validate a derived column.")
+			}
+
+			created, err := client.CreateDerivedColumn(cmd.Context(), targetDataset, &dc)
+			if err != nil {
+				fatal(log.Fields{
+					"_function":      "newDerivedColumnsCreateCmd",
+					"dataset":        targetDataset,
+					"derived_column": dc,
+				}, err, "Error received when attempting to create a new derived column.")
+			}
+
+			printResponse(created)
+		},
+	}
+
+	cmd.Flags().StringVarP(&dcAlias, "alias", "a", "",
+		"The name of the Derived Column. Must be unique within the dataset.")
+	cmd.MarkFlagRequired("alias")
+	cmd.Flags().StringVarP(&dcExpression, "expression", "e", "",
+		"The expression used to calculate the value of the Derived Column.")
+	cmd.Flags().StringVarP(&dcExpressionFile, "expression-file", "E", "",
+		"A file holding the expression, or - for stdin.")
+	cmd.MarkFlagsOneRequired("expression", "expression-file")
+	cmd.MarkFlagsMutuallyExclusive("expression", "expression-file")
+	cmd.Flags().StringVar(&dcDescription, "description", "",
+		"A description of the Derived Column.")
+
+	return cmd
+}
+
+// List All Derived Columns
+// https://docs.honeycomb.io/api/tag/Derived-Columns#operation/listDerivedColumns
+func newDerivedColumnsListCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "list",
+		Aliases: []string{"ls"},
+		Short:   "List all Derived Columns in the specified dataset.",
+		Long:    "List all Derived Columns in the specified dataset, or environment-wide with __all__.",
+		Run: func(cmd *cobra.Command, args []string) {
+			derivedColumns, err := client.ListDerivedColumns(cmd.Context(), targetDataset)
+			if err != nil {
+				fatal(log.Fields{
+					"_function": "newDerivedColumnsListCmd",
+					"dataset":   targetDataset,
+				}, err, "Error received when attempting to list all derived columns.")
+			}
+
+			printResponse(derivedColumns)
+		},
+	}
+
+	return cmd
+}
+
+// Get a Derived Column
+// https://docs.honeycomb.io/api/tag/Derived-Columns#operation/getDerivedColumn
+func newDerivedColumnsGetCmd() *cobra.Command {
+	var (
+		dcID    string
+		dcAlias string
+	)
+
+	cmd := &cobra.Command{
+		Use:     "get",
+		Aliases: []string{},
+		Short:   "Get a single Derived Column by ID or alias.",
+		Long:    "Get a single Derived Column by ID or alias.",
+		Run: func(cmd *cobra.Command, args []string) {
+			printResponse(resolveDerivedColumn(cmd, "newDerivedColumnsGetCmd", dcID, dcAlias))
+		},
+	}
+
+	cmd.Flags().StringVarP(&dcID, "id", "i", "", "The unique identifier (ID) of a Derived Column.")
+	cmd.Flags().StringVarP(&dcAlias, "alias", "a", "", "The alias of a Derived Column.")
+	cmd.MarkFlagsOneRequired("id", "alias")
+	cmd.MarkFlagsMutuallyExclusive("id", "alias")
+
+	return cmd
+}
+
+// Update a Derived Column
+// https://docs.honeycomb.io/api/tag/Derived-Columns#operation/updateDerivedColumn
+func newDerivedColumnsUpdateCmd() *cobra.Command {
+	var (
+		dcID             string
+		dcAlias          string
+		dcNewAlias       string
+		dcExpression     string
+		dcExpressionFile string
+		dcDescription    string
+	)
+
+	cmd := &cobra.Command{
+		Use:     "update",
+		Aliases: []string{"up", "edit", "modify", "change", "set"},
+		Short:   "Update a Derived Column by ID or alias.",
+		Long: "Update a Derived Column by ID or alias.\n" +
+			"\n" +
+			"The existing Derived Column is fetched and only the values given as flags are\n" +
+			"changed.",
+		Annotations: map[string]string{annotationLiveReads: ""},
+		Run: func(cmd *cobra.Command, args []string) {
+			dc := resolveDerivedColumn(cmd, "newDerivedColumnsUpdateCmd", dcID, dcAlias)
+			if dcID == "" {
+				dcID = dc.ID
+			}
+
+			if cmd.Flags().Changed("new-alias") {
+				dc.Alias = dcNewAlias
+			}
+			if cmd.Flags().Changed("expression") || cmd.Flags().Changed("expression-file") {
+				dc.Expression = readExpression("newDerivedColumnsUpdateCmd", dcExpression, dcExpressionFile)
+			}
+			if cmd.Flags().Changed("description") {
+				dc.Description = dcDescription
+			}
+
+			// Read only fields are not accepted in an update.
+			dc.ID, dc.CreatedAt, dc.UpdatedAt = "", nil, nil
+
+			if err := dc.Validate(); err != nil {
+				fatal(log.Fields{
+					"_function":      "newDerivedColumnsUpdateCmd",
+					"derived_column": dc,
+				}, err, "Error received when attempting to validate a derived column.")
+			}
+
+			updated, err := client.UpdateDerivedColumn(cmd.Context(), targetDataset, dcID, dc)
+			if err != nil {
+				fatal(log.Fields{
+					"_function":         "newDerivedColumnsUpdateCmd",
+					"dataset":           targetDataset,
+					"derived_column_id": dcID,
+					"derived_column":    dc,
+				}, err, "Error received when attempting to update an existing derived column.")
+			}
+
+			printResponse(updated)
+		},
+	}
+
+	cmd.Flags().StringVarP(&dcID, "id", "i", "", "The unique identifier (ID) of a Derived Column.")
+	cmd.Flags().StringVarP(&dcAlias, "alias", "a", "", "The alias of a Derived Column.")
+	cmd.MarkFlagsOneRequired("id", "alias")
+	cmd.MarkFlagsMutuallyExclusive("id", "alias")
+	cmd.Flags().StringVar(&dcNewAlias, "new-alias", "",
+		"A new alias for the Derived Column.")
+	cmd.Flags().StringVarP(&dcExpression, "expression", "e", "",
+		"The expression used to calculate the value of the Derived Column.")
+	cmd.Flags().StringVarP(&dcExpressionFile, "expression-file", "E", "",
+		"A file holding the expression, or - for stdin.")
+	cmd.MarkFlagsMutuallyExclusive("expression", "expression-file")
+	cmd.Flags().StringVar(&dcDescription, "description", "",
+		"A description of the Derived Column.")
+
+	return cmd
+}
+
+// Delete a Derived Column
+// https://docs.honeycomb.io/api/tag/Derived-Columns#operation/deleteDerivedColumn
+func newDerivedColumnsDeleteCmd() *cobra.Command {
+	var (
+		dcID    string
+		dcAlias string
+	)
+
+	cmd := &cobra.Command{
+		Use:     "delete",
+		Aliases: []string{"rm", "remove", "del"},
+		Short:   "Delete a Derived Column by ID or alias.",
+		Long: "Delete a Derived Column by ID or alias. A Derived Column that is used by an SLO\n" +
+			"cannot be deleted.",
+		Annotations: map[string]string{annotationLiveReads: ""},
+		Run: func(cmd *cobra.Command, args []string) {
+			if dcID == "" {
+				dcID = resolveDerivedColumn(cmd, "newDerivedColumnsDeleteCmd", dcID, dcAlias).ID
+			}
+
+			err := client.DeleteDerivedColumn(cmd.Context(), targetDataset, dcID)
+			if err != nil {
+				fatal(log.Fields{
+					"_function":         "newDerivedColumnsDeleteCmd",
+					"dataset":           targetDataset,
+					"derived_column_id": dcID,
+				}, err, "Error received when attempting to delete an existing derived column.")
+			}
+		},
+	}
+
+	cmd.Flags().StringVarP(&dcID, "id", "i", "", "The unique identifier (ID) of a Derived Column.")
+	cmd.Flags().StringVarP(&dcAlias, "alias", "a", "", "The alias of a Derived Column.")
+	cmd.MarkFlagsOneRequired("id", "alias")
+	cmd.MarkFlagsMutuallyExclusive("id", "alias")
+
+	return cmd
+}
@@ -0,0 +1,301 @@
+package cmd
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+// The statuses of a resource checked for drift.
+const (
+	driftInSync   = "in_sync"
+	driftChanged  = "changed"
+	driftMissing  = "missing"
+	driftOrphaned = "orphaned"
+)
+
+// driftReport is the result of comparing manifests with the environment.
+type driftReport struct {
+	Environment string        `json:"environment"`
+	CheckedAt   time.Time     `json:"checked_at"`
+	Drifted     bool          `json:"drifted"`
+	Resources   []driftResult `json:"resources"`
+}
+
+// driftResult is the result of checking a single resource for drift.
+type driftResult struct {
+	Kind    string       `json:"kind"`
+	Dataset string       `json:"dataset,omitempty"`
+	Name    string       `json:"name"`
+	ID      string       `json:"id,omitempty"`
+	Status  string       `json:"status"`
+	Changes []specChange `json:"changes,omitempty"`
+
+	// The manifest the resource is declared in, if it is.
+	Source string `json:"source,omitempty"`
+}
+
+func (r driftResult) ref() resourceRef {
+	return resourceRef{Kind: r.Kind, Dataset: r.Dataset, Name: r.Name}
+}
+
+// CUSTOM
+// Report the resources that differ from their manifests.
+func newDriftCmd() *cobra.Command {
+	var (
+		dFiles       []string
+		dStateFile   string
+		dJSONReport  string
+		dJUnitReport string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "drift",
+		Short: "Report the resources that differ from their manifests.",
+		Long: "Compare the resources declared in manifests with those in the environment, as\n" +
+			"plan does, and report those that have changed since they were applied, or are\n" +
+			"missing. The command exits with code 8 if any have, so that it can be run on a\n" +
+			"schedule to catch changes made outside of the manifests.\n" +
+			"\n" +
+			"Only the fields a manifest declares are compared, so one left out, such as\n" +
+			"disabled on a Trigger, is not checked. Manifests written by export declare every\n" +
+			"field the API returns, including those that are false or 0.\n" +
+			"\n" +
+			"Resources that apply owns but are no longer declared are reported as orphaned,\n" +
+			"without being counted as drift.\n" +
+			"\n" +
+			"The report can be written as JSON with --json-report, and as JUnit XML with\n" +
+			"--junit-report, with a test case for each resource.",
+		Example: "  honeybadger drift -f manifests/\n" +
+			"  honeybadger drift -f manifests/ --json-report drift.json --junit-report drift.xml",
+		Annotations: map[string]string{annotationLiveReads: ""},
+		Args:        cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			ctx := cmd.Context()
+			plan, _, _ := planManifests(ctx, "newDriftCmd", dFiles, dStateFile, false)
+			report := newDriftReport(plan)
+
+			reports := []struct {
+				path  string
+				write func(io.Writer, *driftReport) error
+			}{
+				{dJSONReport, writeDriftJSON},
+				{dJUnitReport, writeDriftJUnit},
+			}
+			for _, r := range reports {
+				if r.path == "" {
+					continue
+				}
+				if err := writeReportFile(r.path, report, r.write); err != nil {
+					fatal(log.Fields{
+						"_function": "newDriftCmd",
+						"report":    r.path,
+					}, err, "Error received when attempting to write the drift report.")
+				}
+			}
+
+			if cmd.Flags().Changed("output") {
+				printResponse(report)
+			} else {
+				writeDrift(os.Stdout, report)
+			}
+			if report.Drifted {
+				os.Exit(exitDrift)
+			}
+		},
+	}
+
+	registerManifestFlags(cmd, &dFiles, &dStateFile)
+	cmd.Flags().StringVar(&dJSONReport, "json-report", "",
+		"A file to write the report to as JSON.")
+	cmd.Flags().StringVar(&dJUnitReport, "junit-report", "",
+		"A file to write the report to as JUnit XML.")
+
+	return cmd
+}
+
+// newDriftReport describes a plan as drift: what it would create is missing,
+// and what it would update has changed.
+func newDriftReport(plan *resourcePlan) *driftReport {
+	report := &driftReport{
+		Environment: plan.Environment,
+		CheckedAt:   time.Now().UTC(),
+		Resources:   []driftResult{},
+	}
+	add := func(a planAction, status string) {
+		report.Resources = append(report.Resources, driftResult{
+			Kind:    a.Kind,
+			Dataset: a.Dataset,
+			Name:    a.Name,
+			ID:      a.ID,
+			Status:  status,
+			Changes: a.Changes,
+			Source:  a.source,
+		})
+		if status == driftChanged || status == driftMissing {
+			report.Drifted = true
+		}
+	}
+
+	for _, a := range plan.Actions {
+		switch {
+		case a.Action == planCreate:
+			add(a, driftMissing)
+		case len(a.Changes) > 0:
+			add(a, driftChanged)
+		default:
+			add(a, driftInSync)
+		}
+	}
+	for _, a := range plan.inSync {
+		add(a, driftInSync)
+	}
+	for _, o := range plan.Orphaned {
+		add(planAction{Kind: o.Kind, Dataset: o.Dataset, Name: o.Name, ID: o.ID}, driftOrphaned)
+	}
+	return report
+}
+
+// writeDrift writes a report in a readable form.
+func writeDrift(w io.Writer, report *driftReport) {
+	counts := map[string]int{}
+	for _, r := range report.Resources {
+		counts[r.Status]++
+		id := ""
+		if r.ID != "" {
+			id = " (" + r.ID + ")"
+		}
+		switch r.Status {
+		case driftMissing:
+			fmt.Fprintf(w, "- %s is missing\n", r.ref())
+		case driftChanged:
+			fmt.Fprintf(w, "~ %s%s has changed\n", r.ref(), id)
+		case driftOrphaned:
+			fmt.Fprintf(w, "! %s%s is no longer declared\n", r.ref(), id)
+		}
+		for _, c := range r.Changes {
+			fmt.Fprintf(w, "    %s: declared %s, found %s\n", c.Path, formatSpecValue(c.To), formatSpecValue(c.From))
+		}
+	}
+
+	if !report.Drifted {
+		fmt.Fprintf(w, "No drift in %s. %d resources are as declared.\n", report.Environment, counts[driftInSync])
+		return
+	}
+	fmt.Fprintf(w, "\nDrift in %s: %d changed, %d missing, %d as declared.\n",
+		report.Environment, counts[driftChanged], counts[driftMissing], counts[driftInSync])
+}
+
+func writeDriftJSON(w io.Writer, report *driftReport) error {
+	return writeJSON(w, report, "  ")
+}
+
+// junitTestSuites is the root of a JUnit XML report.
+type junitTestSuites struct {
+	XMLName  xml.Name         `xml:"testsuites"`
+	Name     string           `xml:"name,attr"`
+	Tests    int              `xml:"tests,attr"`
+	Failures int              `xml:"failures,attr"`
+	Suites   []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name      string          `xml:"name,attr"`
+	Tests     int             `xml:"tests,attr"`
+	Failures  int             `xml:"failures,attr"`
+	Skipped   int             `xml:"skipped,attr"`
+	Timestamp string          `xml:"timestamp,attr"`
+	Cases     []junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	ClassName string        `xml:"classname,attr"`
+	Name      string        `xml:"name,attr"`
+	File      string        `xml:"file,attr,omitempty"`
+	Failure   *junitFailure `xml:"failure,omitempty"`
+	Skipped   *junitSkipped `xml:"skipped,omitempty"`
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr"`
+	Text    string `xml:",chardata"`
+}
+
+type junitSkipped struct {
+	Message string `xml:"message,attr"`
+}
+
+// writeDriftJUnit writes a report as JUnit XML, with a test case for each
+// resource, failing those that have drifted and skipping orphaned ones.
+func writeDriftJUnit(w io.Writer, report *driftReport) error {
+	suite := junitTestSuite{
+		Name:      report.Environment,
+		Timestamp: report.CheckedAt.Format("2006-01-02T15:04:05"),
+		Cases:     []junitTestCase{},
+	}
+	for _, r := range report.Resources {
+		tc := junitTestCase{
+			ClassName: strings.TrimSuffix(r.Kind+"."+r.Dataset, "."),
+			Name:      r.Name,
+			File:      strings.SplitN(r.Source, " (", 2)[0],
+		}
+		switch r.Status {
+		case driftMissing:
+			tc.Failure = &junitFailure{
+				Message: fmt.Sprintf("%s is missing", r.ref()),
+				Type:    r.Status,
+			}
+		case driftChanged:
+			var text strings.Builder
+			for _, c := range r.Changes {
+				fmt.Fprintf(&text, "%s: declared %s, found %s\n", c.Path, formatSpecValue(c.To), formatSpecValue(c.From))
+			}
+			tc.Failure = &junitFailure{
+				Message: fmt.Sprintf("%s has changed", r.ref()),
+				Type:    r.Status,
+				Text:    strings.TrimSuffix(text.String(), "\n"),
+			}
+		case driftOrphaned:
+			tc.Skipped = &junitSkipped{Message: fmt.Sprintf("%s is no longer declared", r.ref())}
+			suite.Skipped++
+		}
+		if tc.Failure != nil {
+			suite.Failures++
+		}
+		suite.Tests++
+		suite.Cases = append(suite.Cases, tc)
+	}
+
+	doc := junitTestSuites{
+		Name:     "honeybadger drift",
+		Tests:    suite.Tests,
+		Failures: suite.Failures,
+		Suites:   []junitTestSuite{suite},
+	}
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(doc); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+// writeReportFile writes a report to path in the form written by write.
+func writeReportFile(path string, report *driftReport, write func(io.Writer, *driftReport) error) error {
+	var buf strings.Builder
+	if err := write(&buf, report); err != nil {
+		return err
+	}
+	return writeFileAtomic(path, []byte(buf.String()))
+}
@@ -0,0 +1,112 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/adz-anz/honeybadger/honeycomb"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// Exit codes, so that scripts wrapping honeybadger can branch on the class of
+// failure without parsing its output.
+const (
+	exitGeneral    = 1
+	exitUsage      = 2
+	exitAuth       = 3
+	exitNotFound   = 4
+	exitValidation = 5
+	exitRateLimit  = 6
+	exitServer     = 7
+	exitDrift      = 8
+)
+
+// exitCode returns the process exit code for err.
+func exitCode(err error) int {
+	var apiErr *honeycomb.APIError
+	if !errors.As(err, &apiErr) {
+		switch {
+		case errors.Is(err, honeycomb.ErrValidation):
+			return exitValidation
+		case errors.Is(err, honeycomb.ErrNotFound):
+			return exitNotFound
+		}
+		return exitGeneral
+	}
+
+	switch apiErr.Class() {
+	case honeycomb.ClassAuth:
+		return exitAuth
+	case honeycomb.ClassNotFound:
+		return exitNotFound
+	case honeycomb.ClassValidation:
+		return exitValidation
+	case honeycomb.ClassRateLimit:
+		return exitRateLimit
+	case honeycomb.ClassServer:
+		return exitServer
+	default:
+		return exitGeneral
+	}
+}
+
+// renderError writes a human-readable description of err to w, masking any
+// secrets it contains.
+func renderError(w io.Writer, msg string, err error) {
+	fmt.Fprintf(w, "Error: %s\n", msg)
+
+	var valErr *honeycomb.ValidationError
+	if errors.As(err, &valErr) {
+		fmt.Fprintf(w, "  invalid %s\n", valErr.Resource)
+		for _, p := range valErr.Problems {
+			fmt.Fprintf(w, "    - %s\n", redactor.String(p))
+		}
+		return
+	}
+
+	var synErr *honeycomb.QuerySyntaxError
+	if errors.As(err, &synErr) {
+		fmt.Fprintf(w, "  %s\n", synErr.Error())
+		for _, line := range strings.Split(synErr.Caret(), "\n") {
+			fmt.Fprintf(w, "    %s\n", line)
+		}
+		return
+	}
+
+	var apiErr *honeycomb.APIError
+	if !errors.As(err, &apiErr) {
+		fmt.Fprintf(w, "  %s\n", redactor.String(err.Error()))
+		return
+	}
+
+	title := apiErr.Title
+	if title == "" {
+		title = http.StatusText(apiErr.StatusCode)
+	}
+	fmt.Fprintf(w, "  %d %s (%s)\n", apiErr.StatusCode, title, apiErr.Class())
+	if apiErr.Detail != "" && apiErr.Detail != title {
+		fmt.Fprintf(w, "  %s\n", apiErr.Detail)
+	}
+	for _, d := range apiErr.TypeDetail {
+		fmt.Fprintf(w, "    - %s: %s\n", d.Field, d.Description)
+	}
+	fmt.Fprintf(w, "  Request: %s %s\n", apiErr.Method, apiErr.Path)
+	if apiErr.RequestID != "" {
+		fmt.Fprintf(w, "  Request ID: %s\n", apiErr.RequestID)
+	}
+}
+
+// fatal logs err with the given fields, renders it on stderr and exits with a
+// code reflecting the class of error.
+func fatal(fields log.Fields, err error, msg string) {
+	fields["err"] = err
+	log.WithFields(fields).Debug(msg)
+
+	renderError(os.Stderr, msg, err)
+	os.Exit(exitCode(err))
+}
@@ -0,0 +1,652 @@
+package cmd
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/spf13/cobra"
+	"golang.org/x/exp/slices"
+
+	"github.com/adz-anz/honeybadger/honeycomb"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// requireDataset returns an error if no dataset was given, as events cannot be
+// sent environment-wide.
+func requireDataset(cmd *cobra.Command, args []string) error {
+	if targetDataset == "" || targetDataset == honeycomb.EnvironmentWide {
+		return errors.New("events must be sent to a dataset, given with --dataset")
+	}
+	return nil
+}
+
+// inferValue converts a value given as text to the type it looks like: a whole
+// number, a number, a boolean, null, or a JSON object or array. Anything else,
+// including a value wrapped in double quotes, is a string.
+func inferValue(s string) interface{} {
+	if i, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return i
+	}
+	if f, err := strconv.ParseFloat(s, 64); err == nil && !math.IsInf(f, 0) && !math.IsNaN(f) {
+		return f
+	}
+	switch s {
+	case "true":
+		return true
+	case "false":
+		return false
+	case "null":
+		return nil
+	}
+	if len(s) >= 2 && (s[0] == '"' || s[0] == '{' || s[0] == '[') {
+		var v interface{}
+		if err := json.Unmarshal([]byte(s), &v); err == nil {
+			return v
+		}
+	}
+	return s
+}
+
+// parseFieldFlags parses key=value flags into the fields of an event, inferring
+// the type of each value.
+func parseFieldFlags(values []string) (map[string]interface{}, error) {
+	fields := make(map[string]interface{}, len(values))
+	for _, v := range values {
+		key, value, ok := strings.Cut(v, "=")
+		if !ok || key == "" {
+			return nil, fmt.Errorf("invalid field %q, must be key=value", v)
+		}
+		fields[key] = inferValue(value)
+	}
+	return fields, nil
+}
+
+// parseEventTime parses the time of an event, given as an RFC3339 string, or as
+// seconds since the UNIX epoch. Epoch times too large to be in seconds are
+// taken as milliseconds. If layout is given, strings are parsed with it
+// instead, as by time.Parse.
+func parseEventTime(v interface{}, layout string) (time.Time, error) {
+	var text string
+	switch t := v.(type) {
+	case string:
+		if layout != "" {
+			ts, err := time.Parse(layout, t)
+			if err != nil {
+				return time.Time{}, fmt.Errorf("invalid time %q, must be in the form %s", t, layout)
+			}
+			return ts, nil
+		}
+		if ts, err := time.Parse(time.RFC3339Nano, t); err == nil {
+			return ts, nil
+		}
+		text = t
+	case json.Number:
+		text = t.String()
+	case float64:
+		text = strconv.FormatFloat(t, 'f', -1, 64)
+	case int64:
+		text = strconv.FormatInt(t, 10)
+	}
+
+	// Whole numbers are converted exactly, as float64 cannot hold every
+	// millisecond since the epoch.
+	if n, err := strconv.ParseInt(text, 10, 64); err == nil {
+		if n > 1e11 {
+			return time.UnixMilli(n).UTC(), nil
+		}
+		return time.Unix(n, 0).UTC(), nil
+	}
+	if f, err := strconv.ParseFloat(text, 64); err == nil && !math.IsInf(f, 0) && !math.IsNaN(f) {
+		if f > 1e11 {
+			f /= 1000
+		}
+		sec, frac := math.Modf(f)
+		return time.Unix(int64(sec), int64(math.Round(frac*1e6))*1e3).UTC(), nil
+	}
+	return time.Time{}, fmt.Errorf("invalid time %v, must be RFC3339 or seconds since the UNIX epoch", v)
+}
+
+// eventStatus is the outcome of sending a single event from an input.
+type eventStatus struct {
+	// The file the event was read from, or - for stdin.
+	Source string `json:"source"`
+
+	// The line of the event in NDJSON or a log, its position in a JSON
+	// array, or its row in a CSV file, counting from 1.
+	Index int `json:"index"`
+
+	// The status code returned for the event, or 0 if it was not sent.
+	Status int `json:"status"`
+
+	// Why the event was not accepted.
+	Error string `json:"error,omitempty"`
+}
+
+// eventBatchFlags are the flags bounding the batches of events sent by a
+// single request.
+type eventBatchFlags struct {
+	maxEvents int
+	maxBytes  int
+}
+
+func (f *eventBatchFlags) register(cmd *cobra.Command) {
+	cmd.Flags().IntVar(&f.maxEvents, "batch-events", 100,
+		"The most events to send in a single request.")
+	cmd.Flags().IntVar(&f.maxBytes, "batch-bytes", honeycomb.MaxBatchBytes,
+		"The largest request to send, in bytes.")
+}
+
+// validate checks the flags before any request is sent.
+func (f *eventBatchFlags) validate() error {
+	if f.maxEvents < 1 {
+		return errors.New("--batch-events must be at least 1")
+	}
+	if f.maxBytes < 1 || f.maxBytes > honeycomb.MaxBatchBytes {
+		return fmt.Errorf("--batch-bytes must be between 1 and %d", honeycomb.MaxBatchBytes)
+	}
+	return nil
+}
+
+// eventSender sends events to a dataset in batches bounded by count and size,
+// keeping count of the outcomes.
+type eventSender struct {
+	dataset string
+	limits  eventBatchFlags
+
+	// Whether to keep the status of accepted events, as well as of those that
+	// were not accepted.
+	keepAccepted bool
+
+	batch []honeycomb.Event
+	refs  []eventStatus
+	size  int
+
+	// If set, report is given each status in place of keeping it, for
+	// commands that send events for too long to keep them all.
+	report func(eventStatus)
+
+	// If set, dispatch is given each full batch to send with send, in place
+	// of it being sent by flush, so that batches can be sent concurrently.
+	dispatch func(events []honeycomb.Event, refs []eventStatus)
+
+	// mu guards the counts and statuses, which batches being sent
+	// concurrently update.
+	mu                          sync.Mutex
+	batches, accepted, rejected int
+	statuses                    []eventStatus
+}
+
+func newEventSender(dataset string, limits eventBatchFlags, keepAccepted bool) *eventSender {
+	return &eventSender{
+		dataset:      dataset,
+		limits:       limits,
+		keepAccepted: keepAccepted,
+		statuses:     []eventStatus{},
+	}
+}
+
+// add queues an event, sending the queued events first if it would not fit in
+// the batch. An error is returned only if a batch could not be sent at all.
+func (s *eventSender) add(ctx context.Context, ref eventStatus, e honeycomb.Event) error {
+	encoded, err := json.Marshal(e)
+	if err != nil {
+		s.reject(ref, err)
+		return nil
+	}
+	if len(encoded) > honeycomb.MaxEventBytes || len(encoded)+2 > s.limits.maxBytes {
+		s.reject(ref, fmt.Errorf("the event is %d bytes, more than can be sent", len(encoded)))
+		return nil
+	}
+
+	// Each event after the first is preceded by a comma, and the batch is
+	// wrapped in brackets.
+	if len(s.batch) > 0 && s.size+1+len(encoded)+2 > s.limits.maxBytes {
+		if err := s.flush(ctx); err != nil {
+			return err
+		}
+	}
+
+	if len(s.batch) > 0 {
+		s.size++
+	}
+	s.size += len(encoded)
+	s.batch = append(s.batch, e)
+	s.refs = append(s.refs, ref)
+
+	if len(s.batch) >= s.limits.maxEvents {
+		return s.flush(ctx)
+	}
+	return nil
+}
+
+// reject records an event that could not be sent.
+func (s *eventSender) reject(ref eventStatus, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	ref.Error = err.Error()
+	s.rejected++
+	s.record(ref)
+}
+
+// record keeps the status of an event, or reports it. s.mu must be held.
+func (s *eventSender) record(ref eventStatus) {
+	if s.report != nil {
+		s.report(ref)
+		return
+	}
+	s.statuses = append(s.statuses, ref)
+}
+
+// flush sends the queued events, or passes them to dispatch. They are dropped
+// if the batch cannot be sent.
+func (s *eventSender) flush(ctx context.Context) error {
+	if len(s.batch) == 0 {
+		return nil
+	}
+	defer func() {
+		s.batch, s.refs, s.size = s.batch[:0], s.refs[:0], 0
+	}()
+
+	if s.dispatch != nil {
+		s.dispatch(slices.Clone(s.batch), slices.Clone(s.refs))
+		return nil
+	}
+	return s.send(ctx, s.batch, s.refs)
+}
+
+// send sends a batch of events, recording the status of each.
+func (s *eventSender) send(ctx context.Context, events []honeycomb.Event, refs []eventStatus) error {
+	statuses, err := client.SendBatch(ctx, s.dataset, events)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.batches++
+
+	// Nothing is sent on a dry run, so there is no status to record.
+	if dryRun {
+		return nil
+	}
+	for i, ref := range refs {
+		if i >= len(statuses) {
+			ref.Error = "no status was returned for the event"
+			s.rejected++
+			s.record(ref)
+			continue
+		}
+		ref.Status, ref.Error = statuses[i].Status, statuses[i].Error
+		if statuses[i].Accepted() {
+			s.accepted++
+			if !s.keepAccepted {
+				continue
+			}
+		} else {
+			s.rejected++
+		}
+		s.record(ref)
+	}
+	return nil
+}
+
+// summary describes the outcome of sending the events.
+func (s *eventSender) summary() string {
+	return fmt.Sprintf("Sent %d events in %d batches to %s: %d accepted, %d not accepted.",
+		s.accepted+s.rejected, s.batches, s.dataset, s.accepted, s.rejected)
+}
+
+// Events
+// https://docs.honeycomb.io/api/tag/Events
+func newEventsCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "events",
+		Aliases: []string{"e"},
+		Short:   "Send Events",
+		Long: "Events are the data sent to a dataset, each holding a set of fields. A dataset\n" +
+			"is created when the first event is sent to it.\n" +
+			"\n" +
+			"These commands allow you to send single events, batches of events from files\n" +
+			"or stdin, the lines of a log file as they are written, or historical events\n" +
+			"from a CSV file.",
+	}
+
+	cmd.PersistentFlags().StringVarP(&targetDataset, "dataset", "d", "",
+		"The slug of the dataset to send the events to.")
+
+	cmd.AddCommand(
+		newEventsSendCmd(),
+		newEventsBatchCmd(),
+		newEventsTailCmd(),
+		newEventsImportCSVCmd(),
+		newEventsKinesisCmd(),
+	)
+
+	return cmd
+}
+
+// Create an Event
+// https://docs.honeycomb.io/api/tag/Events#operation/createEvent
+func newEventsSendCmd() *cobra.Command {
+	var (
+		eFields     []string
+		eJSON       string
+		eTime       string
+		eSampleRate int
+	)
+
+	cmd := &cobra.Command{
+		Use:   "send",
+		Short: "Send a single Event to the specified dataset.",
+		Long: "Send a single Event to the specified dataset.\n" +
+			"\n" +
+			"Fields are given as key=value with --field, and their types are inferred: whole\n" +
+			"numbers, numbers, true, false and null, and JSON objects and arrays, are sent as\n" +
+			"such, and anything else as a string. Wrap a value in double quotes to send it as\n" +
+			"a string regardless. Fields can also be given as a JSON object with --json, which\n" +
+			"--field values are added to.",
+		Example: "  honeybadger events send -d my-service -f service.name=api -f duration_ms=12.5 -f error=false\n" +
+			"  honeybadger events send -d my-service --json '{\"name\": \"deploy\"}' --time 2024-05-01T12:00:00Z",
+		PreRunE: requireDataset,
+		Run: func(cmd *cobra.Command, args []string) {
+			e := honeycomb.Event{Data: map[string]interface{}{}, SampleRate: eSampleRate}
+
+			if eJSON != "" {
+				raw := []byte(eJSON)
+				if eJSON == "-" {
+					var err error
+					if raw, err = readFileOrStdin(eJSON); err != nil {
+						fatal(log.Fields{
+							"_function": "newEventsSendCmd",
+						}, err, "Error received when attempting to read an event.")
+					}
+				}
+				if err := json.Unmarshal(raw, &e.Data); err != nil {
+					fatal(log.Fields{
+						"_function": "newEventsSendCmd",
+					}, fmt.Errorf("the event must be a JSON object: %w", err), "Error received when attempting to read an event.")
+				}
+			}
+
+			fields, err := parseFieldFlags(eFields)
+			if err != nil {
+				fatal(log.Fields{
+					"_function": "newEventsSendCmd",
+					"fields":    eFields,
+				}, err, "Error received when attempting to read an event.")
+			}
+			for k, v := range fields {
+				e.Data[k] = v
+			}
+
+			if eTime != "" {
+				t, err := parseEventTime(eTime, "")
+				if err != nil {
+					fatal(log.Fields{
+						"_function": "newEventsSendCmd",
+						"time":      eTime,
+					}, err, "Error received when attempting to read an event.")
+				}
+				e.Time = &t
+			}
+
+			if err := client.SendEvent(cmd.Context(), targetDataset, &e); err != nil {
+				fatal(log.Fields{
+					"_function": "newEventsSendCmd",
+					"dataset":   targetDataset,
+				}, err, "Error received when attempting to send an event.")
+			}
+		},
+	}
+
+	cmd.Flags().StringArrayVarP(&eFields, "field", "f", nil,
+		"A field of the event as key=value. Can be given more than once.")
+	cmd.Flags().StringVar(&eJSON, "json", "",
+		"The fields of the event as a JSON object, or - to read it from stdin.")
+	cmd.MarkFlagsOneRequired("field", "json")
+	cmd.Flags().StringVar(&eTime, "time", "",
+		"The time of the event, as RFC3339 or seconds since the UNIX epoch. Defaults to now.")
+	cmd.Flags().IntVar(&eSampleRate, "samplerate", 0,
+		"The sample rate of the event, if it was sampled.")
+
+	return cmd
+}
+
+// Create Events
+// https://docs.honeycomb.io/api/tag/Events#operation/createEvents
+func newEventsBatchCmd() *cobra.Command {
+	var (
+		eTimeField   string
+		eSampleRate  int
+		eAllStatuses bool
+		eBatchLimits eventBatchFlags
+	)
+
+	cmd := &cobra.Command{
+		Use:   "batch [file]...",
+		Short: "Send Events in batches from files or stdin.",
+		Long: "Send Events in batches from files, or from stdin if no files are given or a file\n" +
+			"is -.\n" +
+			"\n" +
+			"Each input is either NDJSON, with an event on each line, or a JSON array of\n" +
+			"events. An event is either an object of fields, or an object in the form of the\n" +
+			"batch API, with the fields in data and an optional time and samplerate:\n" +
+			"\n" +
+			"  {\"time\": \"2024-05-01T12:00:00Z\", \"samplerate\": 10, \"data\": {\"status_code\": 200}}\n" +
+			"\n" +
+			"Events are sent in batches of at most --batch-events events and --batch-bytes\n" +
+			"bytes. The status of each event that was not accepted is printed, along with\n" +
+			"where it was read from, and the command fails if any were not accepted.",
+		Example: "  honeybadger events batch -d my-service events.ndjson\n" +
+			"  kubectl get events -o json | jq -c '.items[]' | honeybadger events batch -d k8s --time-field lastTimestamp",
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			if err := requireDataset(cmd, args); err != nil {
+				return err
+			}
+			return eBatchLimits.validate()
+		},
+		Run: func(cmd *cobra.Command, args []string) {
+			if len(args) == 0 {
+				args = []string{"-"}
+			}
+
+			sender := newEventSender(targetDataset, eBatchLimits, eAllStatuses)
+
+			toEvent := func(ref eventStatus, fields map[string]interface{}) {
+				e, err := decodeBatchEvent(fields, eTimeField, eSampleRate)
+				if err != nil {
+					sender.reject(ref, err)
+					return
+				}
+				if err := sender.add(cmd.Context(), ref, e); err != nil {
+					fatal(log.Fields{
+						"_function": "newEventsBatchCmd",
+						"dataset":   targetDataset,
+					}, err, "Error received when attempting to send a batch of events.")
+				}
+			}
+
+			for _, source := range args {
+				if err := readEvents(source, sender.reject, toEvent); err != nil {
+					fatal(log.Fields{
+						"_function": "newEventsBatchCmd",
+						"source":    source,
+					}, err, "Error received when attempting to read events.")
+				}
+			}
+			if err := sender.flush(cmd.Context()); err != nil {
+				fatal(log.Fields{
+					"_function": "newEventsBatchCmd",
+					"dataset":   targetDataset,
+				}, err, "Error received when attempting to send a batch of events.")
+			}
+			if dryRun {
+				return
+			}
+
+			fmt.Fprintln(os.Stderr, sender.summary())
+			printResponse(sender.statuses)
+			if sender.rejected > 0 {
+				fatal(log.Fields{
+					"_function": "newEventsBatchCmd",
+					"dataset":   targetDataset,
+				}, fmt.Errorf("%d of %d events were not accepted", sender.rejected, sender.accepted+sender.rejected),
+					"Error received when attempting to send a batch of events.")
+			}
+		},
+	}
+
+	cmd.Flags().StringVar(&eTimeField, "time-field", "",
+		"A field holding the time of each event, as RFC3339 or seconds since the UNIX epoch. It is removed from the event.")
+	cmd.Flags().IntVar(&eSampleRate, "samplerate", 0,
+		"The sample rate of events that do not give their own.")
+	cmd.Flags().BoolVar(&eAllStatuses, "all-statuses", false,
+		"Print the status of every event, not just those that were not accepted.")
+	eBatchLimits.register(cmd)
+
+	return cmd
+}
+
+// readEvents reads the events from a file, or stdin if source is -, as NDJSON
+// or a JSON array, calling event for each. Events that cannot be decoded are
+// passed to reject, and reading continues with the next. An error is returned
+// only if the input cannot be read at all.
+func readEvents(source string, reject func(eventStatus, error), event func(eventStatus, map[string]interface{})) error {
+	var r io.Reader = os.Stdin
+	if source != "-" {
+		f, err := os.Open(source)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		r = f
+	}
+
+	br := bufio.NewReader(r)
+	first, err := peekNonSpace(br)
+	if err == io.EOF {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	if first == '[' {
+		dec := json.NewDecoder(br)
+		dec.UseNumber()
+		if _, err := dec.Token(); err != nil {
+			return err
+		}
+		for i := 1; dec.More(); i++ {
+			var fields map[string]interface{}
+			if err := dec.Decode(&fields); err != nil {
+				// The rest of the array cannot be found after a syntax error.
+				return fmt.Errorf("event %d: %w", i, err)
+			}
+			event(eventStatus{Source: source, Index: i}, fields)
+		}
+		return nil
+	}
+
+	scanner := bufio.NewScanner(br)
+	// Allow for lines longer than the largest event, so that they are
+	// rejected by the sender with a clear reason rather than failing the scan.
+	scanner.Buffer(make([]byte, 64*1024), 2*honeycomb.MaxEventBytes)
+	for line := 1; scanner.Scan(); line++ {
+		raw := bytes.TrimSpace(scanner.Bytes())
+		if len(raw) == 0 {
+			continue
+		}
+
+		ref := eventStatus{Source: source, Index: line}
+		dec := json.NewDecoder(bytes.NewReader(raw))
+		dec.UseNumber()
+		var fields map[string]interface{}
+		if err := dec.Decode(&fields); err != nil {
+			reject(ref, fmt.Errorf("invalid JSON: %w", err))
+			continue
+		}
+		if fields == nil {
+			reject(ref, errors.New("the event must be a JSON object"))
+			continue
+		}
+		event(ref, fields)
+	}
+	return scanner.Err()
+}
+
+// peekNonSpace returns the first byte of r that is not white space, without
+// consuming it.
+func peekNonSpace(r *bufio.Reader) (byte, error) {
+	for {
+		b, err := r.ReadByte()
+		if err != nil {
+			return 0, err
+		}
+		if b != ' ' && b != '\t' && b != '\r' && b != '\n' {
+			return b, r.UnreadByte()
+		}
+	}
+}
+
+// decodeBatchEvent converts an object read from an input to an Event. An object
+// with a data object, and only time and samplerate besides, is in the form of
+// the batch API; any other object holds the fields of the event.
+func decodeBatchEvent(fields map[string]interface{}, timeField string, sampleRate int) (honeycomb.Event, error) {
+	e := honeycomb.Event{Data: fields, SampleRate: sampleRate}
+
+	if data, ok := fields["data"].(map[string]interface{}); ok && isBatchEnvelope(fields) {
+		e.Data = data
+		if v, ok := fields["time"]; ok {
+			t, err := parseEventTime(v, "")
+			if err != nil {
+				return e, err
+			}
+			e.Time = &t
+		}
+		if v, ok := fields["samplerate"]; ok {
+			n, ok := v.(json.Number)
+			rate, err := n.Int64()
+			if !ok || err != nil || rate < 1 {
+				return e, fmt.Errorf("invalid samplerate %v, must be a whole number of at least 1", v)
+			}
+			e.SampleRate = int(rate)
+		}
+	}
+
+	if timeField != "" && e.Time == nil {
+		if v, ok := e.Data[timeField]; ok {
+			t, err := parseEventTime(v, "")
+			if err != nil {
+				return e, fmt.Errorf("field %s: %w", timeField, err)
+			}
+			e.Time = &t
+			delete(e.Data, timeField)
+		}
+	}
+
+	return e, nil
+}
+
+// isBatchEnvelope reports whether every key of fields is one of those of an
+// event in the batch API.
+func isBatchEnvelope(fields map[string]interface{}) bool {
+	for k := range fields {
+		if k != "data" && k != "time" && k != "samplerate" {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,639 @@
+package cmd
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"os"
+	"os/signal"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+	"golang.org/x/exp/slices"
+
+	"github.com/adz-anz/honeybadger/honeycomb"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// Column types accepted in a CSV schema file.
+const (
+	csvString = "string"
+	csvInt    = "int"
+	csvFloat  = "float"
+	csvBool   = "bool"
+	csvJSON   = "json"
+	csvSkip   = "skip"
+)
+
+var csvColumnTypes = []string{csvString, csvInt, csvFloat, csvBool, csvJSON, csvSkip}
+
+// csvSchema gives the types of the columns of a CSV file. Columns that are not
+// listed have their types inferred.
+type csvSchema struct {
+	Columns map[string]string `json:"columns"`
+}
+
+// validate checks that every listed column is in header, with a known type.
+func (s csvSchema) validate(header []string) error {
+	var problems []string
+	for col, typ := range s.Columns {
+		if !slices.Contains(header, col) {
+			problems = append(problems, fmt.Sprintf("column %s is not in the file", col))
+		}
+		if !slices.Contains(csvColumnTypes, typ) {
+			problems = append(problems, fmt.Sprintf("column %s has type %q, must be one of %s", col, typ, strings.Join(csvColumnTypes, ", ")))
+		}
+	}
+	sort.Strings(problems)
+	if len(problems) > 0 {
+		return fmt.Errorf("invalid schema: %s", strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+// convertCell converts the text of a cell to the given column type, inferring
+// it if typ is empty.
+func convertCell(text, typ string) (interface{}, error) {
+	switch typ {
+	case "":
+		return inferValue(text), nil
+	case csvString:
+		return text, nil
+	case csvInt:
+		return strconv.ParseInt(text, 10, 64)
+	case csvFloat:
+		return strconv.ParseFloat(text, 64)
+	case csvBool:
+		return strconv.ParseBool(text)
+	case csvJSON:
+		var v interface{}
+		err := json.Unmarshal([]byte(text), &v)
+		return v, err
+	}
+	return nil, fmt.Errorf("unknown column type %s", typ)
+}
+
+// rowFilter is a condition on a column, given with --where, that a row must
+// meet to be imported.
+type rowFilter struct {
+	column string
+	op     string
+	value  string
+	re     *regexp.Regexp
+}
+
+var rowFilterPattern = regexp.MustCompile(`^\s*([^=!<>~\s]+)\s*(=~|!=|>=|<=|=|>|<)\s*(.*)$`)
+
+// parseRowFilter parses a condition such as status_code>=500 or
+// path=~^/api/.
+func parseRowFilter(s string) (rowFilter, error) {
+	m := rowFilterPattern.FindStringSubmatch(s)
+	if m == nil {
+		return rowFilter{}, fmt.Errorf("invalid --where %q, must be <column><op><value> with an op of =, !=, >, >=, <, <= or =~", s)
+	}
+	f := rowFilter{column: m[1], op: m[2], value: m[3]}
+	if f.op == "=~" {
+		re, err := regexp.Compile(f.value)
+		if err != nil {
+			return rowFilter{}, fmt.Errorf("invalid --where %q: %w", s, err)
+		}
+		f.re = re
+	}
+	return f, nil
+}
+
+// match reports whether the text of a cell meets the condition. Values are
+// compared as numbers if both are numbers, and as text otherwise.
+func (f rowFilter) match(text string) bool {
+	if f.re != nil {
+		return f.re.MatchString(text)
+	}
+
+	var cmp int
+	a, aErr := strconv.ParseFloat(text, 64)
+	b, bErr := strconv.ParseFloat(f.value, 64)
+	switch {
+	case aErr == nil && bErr == nil && a < b:
+		cmp = -1
+	case aErr == nil && bErr == nil && a > b:
+		cmp = 1
+	case aErr == nil && bErr == nil:
+		cmp = 0
+	default:
+		cmp = strings.Compare(text, f.value)
+	}
+
+	switch f.op {
+	case "=":
+		return cmp == 0
+	case "!=":
+		return cmp != 0
+	case ">":
+		return cmp > 0
+	case ">=":
+		return cmp >= 0
+	case "<":
+		return cmp < 0
+	default:
+		return cmp <= 0
+	}
+}
+
+// csvCheckpoint records how far an import got, so that it can be resumed.
+type csvCheckpoint struct {
+	Source    string    `json:"source"`
+	Dataset   string    `json:"dataset"`
+	Row       int       `json:"row"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// readCSVCheckpoint returns the last row of source imported to dataset, as
+// recorded in the checkpoint file at path, or 0 if there is no such file.
+func readCSVCheckpoint(path, source, dataset string) (int, error) {
+	var c csvCheckpoint
+	raw, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	if err := json.Unmarshal(raw, &c); err != nil {
+		return 0, fmt.Errorf("failed to decode checkpoint %s: %w", path, err)
+	}
+	if c.Source != source || c.Dataset != dataset {
+		return 0, fmt.Errorf("checkpoint %s is for importing %s to %s, not %s to %s", path, c.Source, c.Dataset, source, dataset)
+	}
+	return c.Row, nil
+}
+
+// importProgress tracks the rows that have been sent. Batches are sent
+// concurrently and can finish in any order, so the checkpoint only moves past
+// a batch once it and every batch before it have been sent.
+type importProgress struct {
+	mu         sync.Mutex
+	checkpoint csvCheckpoint
+	path       string
+
+	// The next batch to finish in order, and the last row of each batch that
+	// has finished after it.
+	next int
+	done map[int]int
+}
+
+// complete records that the batch numbered seq, ending at lastRow, was sent.
+func (p *importProgress) complete(seq, lastRow int) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.done[seq] = lastRow
+	row := p.checkpoint.Row
+	for {
+		r, ok := p.done[p.next]
+		if !ok {
+			break
+		}
+		delete(p.done, p.next)
+		p.next++
+		row = r
+	}
+	return p.save(row)
+}
+
+// finish records that every row up to lastRow has been imported.
+func (p *importProgress) finish(lastRow int) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.save(lastRow)
+}
+
+// save writes the checkpoint if it has moved. p.mu must be held.
+func (p *importProgress) save(row int) error {
+	if p.path == "" || row <= p.checkpoint.Row {
+		return nil
+	}
+	p.checkpoint.Row, p.checkpoint.UpdatedAt = row, time.Now().UTC()
+
+	raw, err := json.MarshalIndent(p.checkpoint, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := writeFileAtomic(p.path, append(raw, '\n')); err != nil {
+		return fmt.Errorf("failed to write checkpoint: %w", err)
+	}
+	return nil
+}
+
+// csvImportSummary is the outcome of importing a CSV file.
+type csvImportSummary struct {
+	Source  string `json:"source"`
+	Dataset string `json:"dataset"`
+
+	// Rows already imported, according to the checkpoint.
+	Skipped int `json:"skipped"`
+
+	// Rows read after those skipped.
+	Rows int `json:"rows"`
+
+	// Rows not sent because they did not match --where, or were not chosen
+	// by --sample.
+	Filtered   int `json:"filtered"`
+	SampledOut int `json:"sampled_out"`
+
+	Batches  int `json:"batches"`
+	Accepted int `json:"accepted"`
+	Rejected int `json:"rejected"`
+
+	// The rows that were not accepted, and why.
+	Rejections []eventStatus `json:"rejections"`
+
+	// The last row imported, as recorded in the checkpoint.
+	Checkpoint int `json:"checkpoint,omitempty"`
+}
+
+// csvImportBatch is a batch of events waiting for a worker to send it.
+type csvImportBatch struct {
+	seq     int
+	lastRow int
+	events  []honeycomb.Event
+	refs    []eventStatus
+}
+
+// CUSTOM
+func newEventsImportCSVCmd() *cobra.Command {
+	var (
+		eTimeColumn  string
+		eTimeFormat  string
+		eSchemaFile  string
+		eWhere       []string
+		eSample      int
+		eWorkers     int
+		eCheckpoint  string
+		eDelimiter   string
+		eBatchLimits eventBatchFlags
+	)
+
+	cmd := &cobra.Command{
+		Use:   "import-csv <file>",
+		Short: "Import historical Events from a CSV file.",
+		Long: "Import historical Events from a CSV file, or stdin if the file is -, sending a row\n" +
+			"as each Event.\n" +
+			"\n" +
+			"The header row names the fields of the events. The types of the values are\n" +
+			"inferred as with events send, unless given by a schema file, and empty cells\n" +
+			"are left out:\n" +
+			"\n" +
+			"  columns:\n" +
+			"    zip: string\n" +
+			"    duration: float\n" +
+			"    internal_id: skip\n" +
+			"\n" +
+			"The types are string, int, float, bool, json and skip, which leaves a column\n" +
+			"out. Rows whose values cannot be converted are not sent.\n" +
+			"\n" +
+			"Batches are sent by --workers workers at once. With --checkpoint, the last row\n" +
+			"imported is written to a file as the import goes, and an import that was\n" +
+			"interrupted or failed resumes after it when run again with the same file.",
+		Example: "  honeybadger events import-csv -d legacy --time-column ts requests.csv\n" +
+			"  honeybadger events import-csv -d legacy --time-column ts --time-format '2006-01-02 15:04:05' --where status>=500 --checkpoint import.json requests.csv",
+		Args: cobra.ExactArgs(1),
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			if err := requireDataset(cmd, args); err != nil {
+				return err
+			}
+			for _, w := range eWhere {
+				if _, err := parseRowFilter(w); err != nil {
+					return err
+				}
+			}
+			if _, err := csvDelimiter(eDelimiter); err != nil {
+				return err
+			}
+			if eSample < 1 {
+				return errors.New("--sample must be at least 1")
+			}
+			if eWorkers < 1 {
+				return errors.New("--workers must be at least 1")
+			}
+			if eTimeFormat != "" && eTimeColumn == "" {
+				return errors.New("--time-format requires --time-column")
+			}
+			if eCheckpoint != "" && args[0] == "-" {
+				return errors.New("--checkpoint cannot be used when reading from stdin")
+			}
+			return eBatchLimits.validate()
+		},
+		Run: func(cmd *cobra.Command, args []string) {
+			source := args[0]
+			summary, err := importCSV(cmd.Context(), source, csvImportOptions{
+				timeColumn: eTimeColumn,
+				timeFormat: eTimeFormat,
+				schemaFile: eSchemaFile,
+				where:      eWhere,
+				sample:     eSample,
+				workers:    eWorkers,
+				checkpoint: eCheckpoint,
+				delimiter:  eDelimiter,
+				limits:     eBatchLimits,
+			})
+			if summary != nil {
+				printResponse(summary)
+			}
+			if err != nil {
+				if summary != nil && eCheckpoint != "" && !dryRun {
+					fmt.Fprintf(os.Stderr, "Imported up to row %d. Run the import again with the same --checkpoint to resume after it.\n", summary.Checkpoint)
+				}
+				fatal(log.Fields{
+					"_function":  "newEventsImportCSVCmd",
+					"source":     source,
+					"checkpoint": eCheckpoint,
+				}, err, "Error received when attempting to import events.")
+			}
+			if summary.Rejected > 0 && !dryRun {
+				fatal(log.Fields{
+					"_function": "newEventsImportCSVCmd",
+					"source":    source,
+				}, fmt.Errorf("%d of %d rows were not accepted", summary.Rejected, summary.Accepted+summary.Rejected),
+					"Error received when attempting to import events.")
+			}
+		},
+	}
+
+	cmd.Flags().StringVar(&eTimeColumn, "time-column", "",
+		"The column holding the time of each event. It is removed from the event. Defaults to the time it is received.")
+	cmd.Flags().StringVar(&eTimeFormat, "time-format", "",
+		"The layout of --time-column, as used by Go's time.Parse. Defaults to RFC3339 or seconds since the UNIX epoch.")
+	cmd.Flags().StringVar(&eSchemaFile, "schema", "",
+		"A JSON or YAML file giving the types of columns.")
+	cmd.Flags().StringArrayVar(&eWhere, "where", nil,
+		"Only import rows where a column matches, as <column><op><value> with an op of =, !=, >, >=, <, <= or =~ (regex). Can be given more than once.")
+	cmd.Flags().IntVar(&eSample, "sample", 1,
+		"Import 1 in this many rows, chosen at random, sending them with this sample rate.")
+	cmd.Flags().IntVar(&eWorkers, "workers", 4,
+		"The number of batches to send at once.")
+	cmd.Flags().StringVar(&eCheckpoint, "checkpoint", "",
+		"A file recording the last row imported, which the import resumes after if it exists.")
+	cmd.Flags().StringVar(&eDelimiter, "delimiter", ",",
+		"The character separating the values of a row, or \\t for a tab.")
+	eBatchLimits.register(cmd)
+
+	return cmd
+}
+
+// csvImportOptions are the options of an import of a CSV file.
+type csvImportOptions struct {
+	timeColumn string
+	timeFormat string
+	schemaFile string
+	where      []string
+	sample     int
+	workers    int
+	checkpoint string
+	delimiter  string
+	limits     eventBatchFlags
+}
+
+// csvDelimiter returns the single character given by --delimiter.
+func csvDelimiter(s string) (rune, error) {
+	if s == `\t` {
+		return '\t', nil
+	}
+	r := []rune(s)
+	if len(r) != 1 || r[0] == '"' || r[0] == '\r' || r[0] == '\n' {
+		return 0, errors.New("--delimiter must be a single character other than a quote or newline")
+	}
+	return r[0], nil
+}
+
+// importCSV imports the rows of source as events. The summary is returned
+// whenever the file could be read, even if the import did not finish.
+func importCSV(ctx context.Context, source string, opts csvImportOptions) (*csvImportSummary, error) {
+	var in io.Reader = os.Stdin
+	if source != "-" {
+		f, err := os.Open(source)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		in = f
+	}
+
+	r := csv.NewReader(in)
+	r.Comma, _ = csvDelimiter(opts.delimiter)
+	header, err := r.Read()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the header row: %w", err)
+	}
+
+	var schema csvSchema
+	if opts.schemaFile != "" {
+		if err := readResourceFile(opts.schemaFile, &schema); err != nil {
+			return nil, err
+		}
+	}
+	if opts.timeColumn != "" && !slices.Contains(header, opts.timeColumn) {
+		return nil, fmt.Errorf("--time-column %s is not in the file", opts.timeColumn)
+	}
+	if err := schema.validate(header); err != nil {
+		return nil, err
+	}
+	filters := map[int][]rowFilter{}
+	for _, w := range opts.where {
+		f, _ := parseRowFilter(w)
+		i := slices.Index(header, f.column)
+		if i == -1 {
+			return nil, fmt.Errorf("--where column %s is not in the file", f.column)
+		}
+		filters[i] = append(filters[i], f)
+	}
+
+	progress := &importProgress{
+		checkpoint: csvCheckpoint{Source: source, Dataset: targetDataset},
+		done:       map[int]int{},
+	}
+	if opts.checkpoint != "" && !dryRun {
+		progress.path = opts.checkpoint
+		if progress.checkpoint.Row, err = readCSVCheckpoint(opts.checkpoint, source, targetDataset); err != nil {
+			return nil, err
+		}
+	}
+	start := progress.checkpoint.Row
+
+	// Reading stops on SIGINT or SIGTERM, or when a batch fails. Batches
+	// already being sent are finished either way, so that the checkpoint
+	// covers them.
+	sigCtx, stop := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	readCtx, cancel := context.WithCancel(sigCtx)
+	defer cancel()
+	sendCtx := context.WithoutCancel(readCtx)
+
+	var (
+		failOnce sync.Once
+		failed   atomic.Bool
+		failErr  error
+	)
+	fail := func(err error) {
+		failOnce.Do(func() {
+			failErr = err
+			failed.Store(true)
+			cancel()
+		})
+	}
+
+	sender := newEventSender(targetDataset, opts.limits, false)
+	jobs := make(chan csvImportBatch, opts.workers)
+	var wg sync.WaitGroup
+	for i := 0; i < opts.workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for b := range jobs {
+				if failed.Load() {
+					continue
+				}
+				if err := sender.send(sendCtx, b.events, b.refs); err != nil {
+					fail(fmt.Errorf("rows %d to %d could not be sent: %w", b.refs[0].Index, b.lastRow, err))
+					continue
+				}
+				if err := progress.complete(b.seq, b.lastRow); err != nil {
+					fail(err)
+				}
+			}
+		}()
+	}
+
+	seq := 0
+	sender.dispatch = func(events []honeycomb.Event, refs []eventStatus) {
+		b := csvImportBatch{seq: seq, lastRow: refs[len(refs)-1].Index, events: events, refs: refs}
+		seq++
+		select {
+		case jobs <- b:
+		case <-readCtx.Done():
+		}
+	}
+
+	summary := &csvImportSummary{Source: source, Dataset: targetDataset}
+	row := 0
+	readErr := func() error {
+		for readCtx.Err() == nil {
+			record, err := r.Read()
+			if err == io.EOF {
+				return nil
+			}
+			row++
+			if row <= start {
+				summary.Skipped++
+				continue
+			}
+			summary.Rows++
+
+			ref := eventStatus{Source: source, Index: row}
+			var parseErr *csv.ParseError
+			if errors.As(err, &parseErr) {
+				sender.reject(ref, err)
+				continue
+			}
+			if err != nil {
+				return err
+			}
+
+			matched := true
+			for i, fs := range filters {
+				for _, f := range fs {
+					matched = matched && i < len(record) && f.match(record[i])
+				}
+			}
+			if !matched {
+				summary.Filtered++
+				continue
+			}
+			if opts.sample > 1 && rand.Intn(opts.sample) != 0 {
+				summary.SampledOut++
+				continue
+			}
+
+			e, err := csvRowEvent(header, record, schema, opts)
+			if err != nil {
+				sender.reject(ref, err)
+				continue
+			}
+			if err := sender.add(sendCtx, ref, e); err != nil {
+				return err
+			}
+		}
+		return nil
+	}()
+	if readErr == nil {
+		readErr = sender.flush(sendCtx)
+	}
+	close(jobs)
+	wg.Wait()
+
+	switch {
+	case readErr != nil:
+		err = readErr
+	case failErr != nil:
+		err = failErr
+	case sigCtx.Err() != nil:
+		err = errors.New("the import was interrupted")
+	default:
+		err = progress.finish(row)
+	}
+
+	summary.Batches, summary.Accepted, summary.Rejected = sender.batches, sender.accepted, sender.rejected
+	summary.Rejections = sender.statuses
+	summary.Checkpoint = progress.checkpoint.Row
+	return summary, err
+}
+
+// csvRowEvent converts a row of a CSV file to an event.
+func csvRowEvent(header, record []string, schema csvSchema, opts csvImportOptions) (honeycomb.Event, error) {
+	e := honeycomb.Event{Data: map[string]interface{}{}}
+	if opts.sample > 1 {
+		e.SampleRate = opts.sample
+	}
+
+	for i, text := range record {
+		if i >= len(header) || text == "" {
+			continue
+		}
+		col := header[i]
+
+		if col == opts.timeColumn {
+			t, err := parseEventTime(text, opts.timeFormat)
+			if err != nil {
+				return e, fmt.Errorf("column %s: %w", col, err)
+			}
+			e.Time = &t
+			continue
+		}
+
+		typ := schema.Columns[col]
+		if typ == csvSkip {
+			continue
+		}
+		v, err := convertCell(text, typ)
+		if err != nil {
+			return e, fmt.Errorf("column %s: invalid %s %q", col, typ, text)
+		}
+		e.Data[col] = v
+	}
+
+	if opts.timeColumn != "" && e.Time == nil {
+		return e, fmt.Errorf("column %s is empty", opts.timeColumn)
+	}
+	return e, nil
+}
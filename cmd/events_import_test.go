@@ -0,0 +1,82 @@
+package cmd
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestImportProgressComplete(t *testing.T) {
+	type batch struct{ seq, lastRow int }
+
+	tests := []struct {
+		name    string
+		start   int
+		batches []batch
+
+		// The row checkpointed after each batch completes.
+		want []int
+	}{
+		{
+			name:    "in order",
+			batches: []batch{{0, 100}, {1, 200}, {2, 250}},
+			want:    []int{100, 200, 250},
+		},
+		{
+			name:    "out of order",
+			batches: []batch{{1, 200}, {2, 300}, {0, 100}},
+			want:    []int{0, 0, 300},
+		},
+		{
+			name:    "gap filled later",
+			batches: []batch{{0, 100}, {2, 300}, {3, 400}, {1, 200}},
+			want:    []int{100, 100, 100, 400},
+		},
+		{
+			name:    "resumed",
+			start:   1000,
+			batches: []batch{{1, 1200}, {0, 1100}},
+			want:    []int{1000, 1200},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "import.json")
+			p := &importProgress{
+				checkpoint: csvCheckpoint{Source: "requests.csv", Dataset: "api", Row: tt.start},
+				path:       path,
+				done:       map[int]int{},
+			}
+
+			for i, b := range tt.batches {
+				if err := p.complete(b.seq, b.lastRow); err != nil {
+					t.Fatalf("complete(%d, %d): %v", b.seq, b.lastRow, err)
+				}
+				if p.checkpoint.Row != tt.want[i] {
+					t.Errorf("after batch %d, checkpoint = %d, want %d", b.seq, p.checkpoint.Row, tt.want[i])
+				}
+			}
+
+			row, err := readCSVCheckpoint(path, "requests.csv", "api")
+			if err != nil {
+				t.Fatalf("readCSVCheckpoint(): %v", err)
+			}
+			if want := tt.want[len(tt.want)-1]; row != want {
+				t.Errorf("checkpoint file has row %d, want %d", row, want)
+			}
+			if len(p.done) != 0 {
+				t.Errorf("%d batches still waiting, want none", len(p.done))
+			}
+		})
+	}
+}
+
+func TestImportProgressWithoutCheckpoint(t *testing.T) {
+	p := &importProgress{done: map[int]int{}}
+	if err := p.complete(0, 100); err != nil {
+		t.Fatalf("complete(): %v", err)
+	}
+	if p.checkpoint.Row != 0 {
+		t.Errorf("checkpoint = %d without a file, want 0", p.checkpoint.Row)
+	}
+}
@@ -0,0 +1,218 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/adz-anz/honeybadger/honeycomb"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// kinesisRecordView is a record of a Kinesis envelope, decoded to show what
+// Honeycomb receives.
+type kinesisRecordView struct {
+	// The position of the record in the envelope, counting from 1.
+	Index int `json:"index"`
+
+	// Whether the data of the record was gzipped.
+	Gzipped bool `json:"gzipped"`
+
+	// The size of the data, once decompressed.
+	Bytes int `json:"bytes"`
+
+	// The data of the record, decoded from JSON if it is JSON.
+	Data interface{} `json:"data"`
+}
+
+// Kinesis Events
+// https://docs.honeycomb.io/api/tag/Kinesis-Events
+func newEventsKinesisCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "kinesis",
+		Short: "Send and inspect Events in the form forwarded by Kinesis Data Firehose.",
+		Long: "Amazon Kinesis Data Firehose forwards records to Honeycomb in an envelope holding\n" +
+			"a request ID, a timestamp and the base64 encoded data of each record.\n" +
+			"\n" +
+			"These commands allow you to send events in such an envelope, as Firehose would,\n" +
+			"and to decode an existing envelope to show the records Honeycomb would receive.",
+	}
+
+	cmd.AddCommand(
+		newEventsKinesisSendCmd(),
+		newEventsKinesisDecodeCmd(),
+	)
+
+	return cmd
+}
+
+// Send Kinesis Events
+// https://docs.honeycomb.io/api/tag/Kinesis-Events
+func newEventsKinesisSendCmd() *cobra.Command {
+	var (
+		kGzip        bool
+		kGzipRecords bool
+		kSave        string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "send [file]...",
+		Short: "Send Events to the specified dataset in a Kinesis Firehose envelope.",
+		Long: "Send Events to the specified dataset in a Kinesis Firehose envelope, read from\n" +
+			"files, or from stdin if no files are given or a file is -.\n" +
+			"\n" +
+			"Each input is either NDJSON, with an event on each line, or a JSON array of\n" +
+			"events, and each event becomes a record of the envelope. The request is sent as\n" +
+			"Firehose sends it, with the configuration key as the Firehose access key.\n" +
+			"\n" +
+			"With --save, the envelope is written to a file instead of being sent, so that it\n" +
+			"can be replayed or decoded later. With --dry-run the file is named rather than\n" +
+			"written.",
+		Example: "  honeybadger events kinesis send -d my-service --gzip events.ndjson\n" +
+			"  honeybadger events kinesis send -d my-service --save envelope.json events.ndjson",
+		PreRunE: requireDataset,
+		Run: func(cmd *cobra.Command, args []string) {
+			if len(args) == 0 {
+				args = []string{"-"}
+			}
+
+			var records [][]byte
+			var readErr error
+			for _, source := range args {
+				err := readEvents(source, func(ref eventStatus, err error) {
+					if readErr == nil {
+						readErr = fmt.Errorf("%s:%d: %w", ref.Source, ref.Index, err)
+					}
+				}, func(ref eventStatus, fields map[string]interface{}) {
+					data, err := json.Marshal(fields)
+					if err != nil && readErr == nil {
+						readErr = fmt.Errorf("%s:%d: %w", ref.Source, ref.Index, err)
+					}
+					records = append(records, data)
+				})
+				if err == nil {
+					err = readErr
+				}
+				if err != nil {
+					fatal(log.Fields{
+						"_function": "newEventsKinesisSendCmd",
+						"source":    source,
+					}, err, "Error received when attempting to read events.")
+				}
+			}
+			if len(records) == 0 {
+				fatal(log.Fields{
+					"_function": "newEventsKinesisSendCmd",
+				}, errors.New("no events were read"), "Error received when attempting to read events.")
+			}
+
+			env, err := honeycomb.NewKinesisEnvelope(records, kGzipRecords)
+			if err != nil {
+				fatal(log.Fields{
+					"_function": "newEventsKinesisSendCmd",
+				}, err, "Error received when attempting to create a kinesis envelope.")
+			}
+
+			if kSave != "" {
+				raw, err := env.Encode(kGzip)
+				if err == nil && !dryRun {
+					err = writeFileAtomic(kSave, raw)
+				}
+				if err != nil {
+					fatal(log.Fields{
+						"_function": "newEventsKinesisSendCmd",
+						"save":      kSave,
+					}, err, "Error received when attempting to save a kinesis envelope.")
+				}
+				if dryRun {
+					fmt.Fprintf(os.Stderr, "Would write %s\n", kSave)
+				}
+				return
+			}
+
+			resp, err := client.SendKinesisEvents(cmd.Context(), targetDataset, env, kGzip)
+			if err != nil {
+				fatal(log.Fields{
+					"_function": "newEventsKinesisSendCmd",
+					"dataset":   targetDataset,
+				}, err, "Error received when attempting to send kinesis events.")
+			}
+
+			printResponse(resp)
+		},
+	}
+
+	cmd.Flags().BoolVar(&kGzip, "gzip", false,
+		"Compress the request with gzip, as Firehose does when GZIP content encoding is enabled.")
+	cmd.Flags().BoolVar(&kGzipRecords, "gzip-records", false,
+		"Compress the data of each record with gzip, as CloudWatch Logs subscriptions do.")
+	cmd.Flags().StringVar(&kSave, "save", "",
+		"Write the envelope to this file instead of sending it.")
+
+	return cmd
+}
+
+// CUSTOM
+func newEventsKinesisDecodeCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "decode <file>",
+		Short: "Decode a Kinesis Firehose envelope to show the records Honeycomb would receive.",
+		Long: "Decode a Kinesis Firehose envelope from a file, or stdin if the file is -, to show\n" +
+			"the records Honeycomb would receive.\n" +
+			"\n" +
+			"The envelope, and the data of each record, may be gzipped. Records holding JSON,\n" +
+			"such as events or CloudWatch Logs subscription messages, are shown decoded, and\n" +
+			"any other records as text. The request ID and time of the envelope are written\n" +
+			"to stderr.",
+		Example: "  honeybadger events kinesis decode envelope.json\n" +
+			"  honeybadger events kinesis decode -o table envelope.json.gz",
+		Args:        cobra.ExactArgs(1),
+		Annotations: map[string]string{annotationNoConfigKey: ""},
+		Run: func(cmd *cobra.Command, args []string) {
+			raw, err := readFileOrStdin(args[0])
+			var env *honeycomb.KinesisEnvelope
+			if err == nil {
+				env, err = honeycomb.DecodeKinesisEnvelope(raw)
+			}
+			if err != nil {
+				fatal(log.Fields{
+					"_function": "newEventsKinesisDecodeCmd",
+					"file":      args[0],
+				}, err, "Error received when attempting to decode a kinesis envelope.")
+			}
+
+			views := make([]kinesisRecordView, 0, len(env.Records))
+			for i, r := range env.Records {
+				data, gzipped, err := r.Decoded()
+				if err != nil {
+					fatal(log.Fields{
+						"_function": "newEventsKinesisDecodeCmd",
+						"file":      args[0],
+						"record":    i + 1,
+					}, err, "Error received when attempting to decode a kinesis envelope.")
+				}
+
+				view := kinesisRecordView{Index: i + 1, Gzipped: gzipped, Bytes: len(data), Data: string(data)}
+				var decoded interface{}
+				dec := json.NewDecoder(bytes.NewReader(data))
+				dec.UseNumber()
+				if dec.Decode(&decoded) == nil && !dec.More() {
+					view.Data = decoded
+				}
+				views = append(views, view)
+			}
+
+			fmt.Fprintf(os.Stderr, "Request %s sent at %s with %d records.\n",
+				env.RequestID, time.UnixMilli(env.Timestamp).UTC().Format(time.RFC3339Nano), len(env.Records))
+			printResponse(views)
+		},
+	}
+
+	return cmd
+}
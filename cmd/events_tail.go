@@ -0,0 +1,316 @@
+package cmd
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/adz-anz/honeybadger/honeycomb"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// tailedEvent is an event parsed from a line of a followed file, waiting in
+// the queue to be sent.
+type tailedEvent struct {
+	ref   eventStatus
+	event honeycomb.Event
+}
+
+// CUSTOM
+func newEventsTailCmd() *cobra.Command {
+	var (
+		eParser        string
+		eRegex         string
+		eFields        []string
+		eTimeField     string
+		eTimeFormat    string
+		eSampleRate    int
+		eFromStart     bool
+		ePollInterval  time.Duration
+		eQueueSize     int
+		eFlushInterval time.Duration
+		eBatchLimits   eventBatchFlags
+	)
+
+	cmd := &cobra.Command{
+		Use:   "tail <file>",
+		Short: "Follow a log file, sending each line as an Event.",
+		Long: "Follow a log file, parsing each line that is written to it into an Event and\n" +
+			"sending the Events in batches to the specified dataset, until interrupted.\n" +
+			"\n" +
+			"Lines are parsed with --parser:\n" +
+			"\n" +
+			"  json     a JSON object on each line\n" +
+			"  logfmt   key=value pairs, with values in double quotes if they hold spaces\n" +
+			"  nginx    the combined log format, the default of nginx\n" +
+			"  apache   the combined (or common) log format of Apache, the same as nginx\n" +
+			"  regex    the named groups of --regex, such as (?P<status>\\d+)\n" +
+			"\n" +
+			"Lines that cannot be parsed are skipped, and counted in the summary printed on\n" +
+			"exit. The file is followed by name: when it is rotated, anything left in the old\n" +
+			"file is read before the new one is followed from its start, and when it is\n" +
+			"truncated it is read again from its start.\n" +
+			"\n" +
+			"Events wait in a queue of at most --queue-size events until they are sent. When\n" +
+			"the queue is full, reading stops until there is room, so nothing is dropped\n" +
+			"while Honeycomb is slow to accept events. On SIGINT or SIGTERM the file stops\n" +
+			"being read, and the events already in the queue are sent before exiting.",
+		Example: "  honeybadger events tail -d nginx -p nginx -f host=web-1 /var/log/nginx/access.log\n" +
+			"  honeybadger events tail -d app -p logfmt --time-field ts /var/log/app.log\n" +
+			"  honeybadger events tail -d jobs -p regex --regex '^(?P<job>\\S+) took (?P<duration_ms>\\d+)ms' jobs.log",
+		Args: cobra.ExactArgs(1),
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			if err := requireDataset(cmd, args); err != nil {
+				return err
+			}
+			if _, err := newLogParser(eParser, eRegex); err != nil {
+				return err
+			}
+			if _, err := parseFieldFlags(eFields); err != nil {
+				return err
+			}
+			if eQueueSize < 1 {
+				return errors.New("--queue-size must be at least 1")
+			}
+			if ePollInterval <= 0 || eFlushInterval <= 0 {
+				return errors.New("--poll-interval and --flush-interval must be more than 0")
+			}
+			return eBatchLimits.validate()
+		},
+		Run: func(cmd *cobra.Command, args []string) {
+			path := args[0]
+			parse, _ := newLogParser(eParser, eRegex)
+			static, _ := parseFieldFlags(eFields)
+
+			ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt, syscall.SIGTERM)
+			defer stop()
+
+			sender := newEventSender(targetDataset, eBatchLimits, false)
+			sender.report = func(ref eventStatus) {
+				log.WithFields(log.Fields{
+					"source": ref.Source,
+					"line":   ref.Index,
+					"status": ref.Status,
+				}).Warn("Event was not accepted: " + ref.Error)
+			}
+
+			// Events are sent from a separate goroutine, so that lines are read
+			// while a batch is being sent. Sending is not stopped by a signal,
+			// so that the queue can be drained.
+			queue := make(chan tailedEvent, eQueueSize)
+			sendCtx := context.WithoutCancel(ctx)
+			var wg sync.WaitGroup
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				ticker := time.NewTicker(eFlushInterval)
+				defer ticker.Stop()
+
+				send := func(err error) {
+					if err != nil {
+						log.WithFields(log.Fields{
+							"_function": "newEventsTailCmd",
+							"dataset":   targetDataset,
+						}).Error("Error received when attempting to send a batch of events; it was dropped: " + err.Error())
+					}
+				}
+				for {
+					select {
+					case item, ok := <-queue:
+						if !ok {
+							send(sender.flush(sendCtx))
+							return
+						}
+						send(sender.add(sendCtx, item.ref, item.event))
+					case <-ticker.C:
+						send(sender.flush(sendCtx))
+					}
+				}
+			}()
+
+			var lines, unparsed int
+			err := tailFile(ctx, path, eFromStart, ePollInterval, func(line string) {
+				lines++
+				if strings.TrimSpace(line) == "" {
+					return
+				}
+				ref := eventStatus{Source: path, Index: lines}
+
+				fields, t, err := parse(line)
+				if err == nil && eTimeField != "" {
+					if v, ok := fields[eTimeField]; ok {
+						var ft time.Time
+						if ft, err = parseEventTime(v, eTimeFormat); err == nil {
+							t = &ft
+							delete(fields, eTimeField)
+						} else {
+							err = fmt.Errorf("field %s: %w", eTimeField, err)
+						}
+					}
+				}
+				if err != nil {
+					unparsed++
+					log.WithFields(log.Fields{
+						"source": path,
+						"line":   lines,
+					}).Debug("Skipped a line that could not be parsed: " + err.Error())
+					return
+				}
+				for k, v := range static {
+					fields[k] = v
+				}
+
+				// Block while the queue is full, unless interrupted.
+				select {
+				case queue <- tailedEvent{ref: ref, event: honeycomb.Event{Time: t, SampleRate: eSampleRate, Data: fields}}:
+				case <-ctx.Done():
+				}
+			})
+			close(queue)
+			wg.Wait()
+
+			if !dryRun {
+				fmt.Fprintf(os.Stderr, "Read %d lines, %d of which could not be parsed. %s\n", lines, unparsed, sender.summary())
+			}
+			if err != nil {
+				fatal(log.Fields{
+					"_function": "newEventsTailCmd",
+					"source":    path,
+				}, err, "Error received when attempting to follow a file.")
+			}
+		},
+	}
+
+	cmd.Flags().StringVarP(&eParser, "parser", "p", parserJSON,
+		"How to parse each line: json, logfmt, nginx, apache or regex.")
+	cmd.Flags().StringVar(&eRegex, "regex", "",
+		"The regular expression used by the regex parser. Its named groups are the fields of the event.")
+	cmd.Flags().StringArrayVarP(&eFields, "field", "f", nil,
+		"A field added to every event as key=value. Can be given more than once.")
+	cmd.Flags().StringVar(&eTimeField, "time-field", "",
+		"A field holding the time of each event. It is removed from the event.")
+	cmd.Flags().StringVar(&eTimeFormat, "time-format", "",
+		"The layout of --time-field, as used by Go's time.Parse. Defaults to RFC3339 or seconds since the UNIX epoch.")
+	cmd.Flags().IntVar(&eSampleRate, "samplerate", 0,
+		"The sample rate of the events, if they were sampled.")
+	cmd.Flags().BoolVar(&eFromStart, "from-start", false,
+		"Read the file from its start, rather than only the lines written after it is opened.")
+	cmd.Flags().DurationVar(&ePollInterval, "poll-interval", 250*time.Millisecond,
+		"How often to check the file for new lines, rotation and truncation.")
+	cmd.Flags().IntVar(&eQueueSize, "queue-size", 10000,
+		"The most events to hold in memory while waiting to send them.")
+	cmd.Flags().DurationVar(&eFlushInterval, "flush-interval", time.Second,
+		"The longest to wait before sending a batch that is not full.")
+	eBatchLimits.register(cmd)
+
+	return cmd
+}
+
+// tailFile follows the file at path, calling line with each line written to it
+// until ctx is done. The file is reopened when it is replaced at path, as by log
+// rotation, once the rest of the old file has been read. It is read again from
+// the start when it is truncated.
+func tailFile(ctx context.Context, path string, fromStart bool, poll time.Duration, line func(string)) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer func() { f.Close() }()
+
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	var offset int64
+	if !fromStart {
+		if offset, err = f.Seek(0, io.SeekEnd); err != nil {
+			return err
+		}
+	}
+
+	r := bufio.NewReader(f)
+	var partial []byte
+	for {
+		n, err := readLines(r, &partial, line)
+		offset += n
+		if err != nil {
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(poll):
+		}
+
+		// The file may briefly not exist while it is being rotated.
+		current, err := os.Stat(path)
+		if err != nil {
+			continue
+		}
+
+		if !os.SameFile(info, current) {
+			if _, err := readLines(r, &partial, line); err != nil {
+				return err
+			}
+			if len(partial) > 0 {
+				line(string(partial))
+				partial = partial[:0]
+			}
+
+			next, err := os.Open(path)
+			if err != nil {
+				continue
+			}
+			if info, err = next.Stat(); err != nil {
+				next.Close()
+				return err
+			}
+			f.Close()
+			f, offset = next, 0
+			r.Reset(f)
+			fmt.Fprintf(os.Stderr, "%s was rotated, following the new file.\n", path)
+			continue
+		}
+
+		if current.Size() < offset {
+			if _, err := f.Seek(0, io.SeekStart); err != nil {
+				return err
+			}
+			offset, partial = 0, partial[:0]
+			r.Reset(f)
+			fmt.Fprintf(os.Stderr, "%s was truncated, reading it from the start.\n", path)
+		}
+	}
+}
+
+// readLines calls line with each complete line in r, until the end of what has
+// been written so far. A line without its newline yet is kept in partial for
+// the next call. It returns the number of bytes read.
+func readLines(r *bufio.Reader, partial *[]byte, line func(string)) (int64, error) {
+	var n int64
+	for {
+		chunk, err := r.ReadBytes('\n')
+		n += int64(len(chunk))
+		*partial = append(*partial, chunk...)
+		if err == io.EOF {
+			return n, nil
+		}
+		if err != nil {
+			return n, err
+		}
+		line(strings.TrimRight(string(*partial), "\r\n"))
+		*partial = (*partial)[:0]
+	}
+}
@@ -0,0 +1,339 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"unicode"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+// exportedResource is a resource written to a manifest by export.
+type exportedResource struct {
+	Kind    string `json:"kind"`
+	Dataset string `json:"dataset,omitempty"`
+	Name    string `json:"name"`
+	ID      string `json:"id"`
+	Path    string `json:"path"`
+}
+
+// collectedResource is an existing resource, as a manifest.
+type collectedResource struct {
+	manifest
+	ID string
+}
+
+// CUSTOM
+// Export every resource in the environment to a directory of manifests.
+func newExportCmd() *cobra.Command {
+	var eOut string
+
+	cmd := &cobra.Command{
+		Use:   "export",
+		Short: "Export every resource in the environment to manifests.",
+		Long: "Write every resource in the environment to a directory of manifests, one file\n" +
+			"per resource, which plan and apply can read. The resources are Recipients,\n" +
+			"Boards, and the Datasets with their Dataset Definitions, Columns, Derived\n" +
+			"Columns, Marker Settings, SLOs, Burn Alerts, Triggers and Query Annotations.\n" +
+			"\n" +
+			"Fields set by Honeycomb, such as IDs and timestamps, are left out. The Queries\n" +
+			"of Boards, Query Annotations and Triggers are written inline rather than by ID,\n" +
+			"Recipients are referred to by their type and target, and Burn Alerts refer to\n" +
+			"their SLO by name, so that the manifests can be applied to another environment.\n" +
+			"Board Queries leave out their Query Annotations, which belong to this one.\n" +
+			"Secrets are masked unless --show-secrets is given.\n" +
+			"\n" +
+			"Files are laid out as:\n" +
+			"\n" +
+			"  boards/<name>.yaml\n" +
+			"  recipients/<type>-<target>.yaml\n" +
+			"  datasets/<dataset>/dataset.yaml\n" +
+			"  datasets/<dataset>/dataset-definition.yaml\n" +
+			"  datasets/<dataset>/<kind>s/<name>.yaml\n" +
+			"\n" +
+			"Existing files are overwritten. With --dry-run the files are listed rather than\n" +
+			"written.",
+		Example:     "  honeybadger export --out manifests/",
+		Annotations: map[string]string{annotationLiveReads: ""},
+		Args:        cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			ctx := cmd.Context()
+			resources, err := collectResources(ctx, newRefResolver(nil), resourceKinds)
+			if err == nil {
+				var exported []exportedResource
+				exported, err = writeManifests(eOut, resources)
+				if err == nil {
+					printResponse(exported)
+					if !dryRun {
+						fmt.Fprintf(os.Stderr, "Exported %d resources to %s\n", len(exported), eOut)
+					}
+					return
+				}
+			}
+			fatal(log.Fields{
+				"_function": "newExportCmd",
+				"out":       eOut,
+			}, err, "Error received when attempting to export resources.")
+		},
+	}
+
+	cmd.Flags().StringVar(&eOut, "out", "", "The directory to write the manifests to.")
+	cmd.MarkFlagRequired("out")
+
+	return cmd
+}
+
+// collectResources lists the resources of the given kinds, in every dataset
+// for those that belong to one, as manifests with their references resolved
+// as manifests give them.
+func collectResources(ctx context.Context, r *refResolver, kinds []*resourceKind) ([]collectedResource, error) {
+	datasets, err := client.ListDatasets(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list datasets: %w", err)
+	}
+	slugs := make([]string, 0, len(datasets))
+	for _, d := range datasets {
+		slugs = append(slugs, d.Slug)
+	}
+	sort.Strings(slugs)
+
+	var out []collectedResource
+	for _, k := range kinds {
+		scopes := []string{""}
+		if k.datasetScoped {
+			scopes = slugs
+		}
+		for _, dataset := range scopes {
+			rs, err := k.list(ctx, r, dataset)
+			if err != nil {
+				return nil, fmt.Errorf("failed to list %s resources: %w", k.name, err)
+			}
+			for _, rr := range rs {
+				if err := exportSpec(ctx, r, k, dataset, rr.Spec); err != nil {
+					return nil, err
+				}
+				out = append(out, collectedResource{
+					manifest: manifest{
+						Kind:     k.name,
+						Metadata: manifestMetadata{Name: k.nameOf(dataset, rr.Spec), Dataset: dataset},
+						Spec:     rr.Spec,
+					},
+					ID: rr.ID,
+				})
+			}
+		}
+	}
+	return out, nil
+}
+
+// exportSpec rewrites the spec of a listed resource as a manifest gives it,
+// with its references resolved and without null values.
+func exportSpec(ctx context.Context, r *refResolver, k *resourceKind, dataset string, spec map[string]interface{}) error {
+	if k.expand != nil {
+		if err := k.expand(ctx, r, dataset, spec); err != nil {
+			return err
+		}
+		inlineQueries(spec)
+	}
+	if k.normalize != nil {
+		if err := k.normalize(ctx, r, spec); err != nil {
+			return err
+		}
+	}
+	dropNullValues(spec)
+	return nil
+}
+
+// inlineQueries drops the IDs of the Queries in a spec that have been added
+// inline, from a Query Annotation or the Queries of a Board. The Query
+// Annotations of Board Queries are dropped too, as their IDs belong to the
+// environment the spec was read from.
+func inlineQueries(spec map[string]interface{}) {
+	if spec["query"] != nil {
+		delete(spec, "query_id")
+	}
+	queries, _ := spec["queries"].([]interface{})
+	for _, item := range queries {
+		if q, ok := item.(map[string]interface{}); ok {
+			delete(q, "query_annotation_id")
+			if q["query"] != nil {
+				delete(q, "query_id")
+			}
+		}
+	}
+}
+
+// dropNullValues removes the fields of a spec, and of the objects within it,
+// that are null. Specs are encoded from the API types, so fields that are
+// omitted when empty are already absent, and the zero values left, such as
+// disabled: false, are those the API always returns, which are kept so that
+// changes to them are seen.
+func dropNullValues(spec map[string]interface{}) {
+	for k, v := range spec {
+		switch v := v.(type) {
+		case nil:
+			delete(spec, k)
+		case map[string]interface{}:
+			dropNullValues(v)
+		case []interface{}:
+			for _, item := range v {
+				if m, ok := item.(map[string]interface{}); ok {
+					dropNullValues(m)
+				}
+			}
+		}
+	}
+}
+
+// placedResource is a collected resource with the file it is written to.
+type placedResource struct {
+	collectedResource
+	Path string
+}
+
+// placeResources lays the resources out under dir, naming those whose natural
+// keys are not unique after their IDs.
+func placeResources(dir string, resources []collectedResource) []placedResource {
+	out := make([]placedResource, 0, len(resources))
+	refs := map[string]bool{}
+	paths := map[string]bool{}
+	for _, res := range resources {
+		if res.Metadata.Name == "" || refs[res.ref().String()] {
+			res.Metadata.Name = strings.TrimSpace(res.Metadata.Name + " " + res.ID)
+		}
+		refs[res.ref().String()] = true
+
+		path := manifestPath(dir, kindByName(res.Kind), res.manifest)
+		if paths[path] {
+			path = strings.TrimSuffix(path, ".yaml") + "-" + fileSlug(res.ID) + ".yaml"
+		}
+		paths[path] = true
+		out = append(out, placedResource{collectedResource: res, Path: path})
+	}
+	return out
+}
+
+// writeManifests writes a manifest for each resource under dir.
+func writeManifests(dir string, resources []collectedResource) ([]exportedResource, error) {
+	out := make([]exportedResource, 0, len(resources))
+	for _, res := range placeResources(dir, resources) {
+		m := res.manifest
+		k := kindByName(m.Kind)
+
+		// The name is only written when it differs from the one a manifest
+		// defaults to.
+		name := m.Metadata.Name
+		if m.Metadata.Name == k.nameOf(m.Metadata.Dataset, m.Spec) {
+			m.Metadata.Name = ""
+		}
+		data, err := marshalManifest(m)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode %s: %w", res.ref(), err)
+		}
+
+		if dryRun {
+			fmt.Fprintf(os.Stderr, "Would write %s\n", res.Path)
+		} else {
+			if err := os.MkdirAll(filepath.Dir(res.Path), 0o755); err != nil {
+				return nil, err
+			}
+			if err := writeFileAtomic(res.Path, data); err != nil {
+				return nil, err
+			}
+		}
+		out = append(out, exportedResource{
+			Kind:    res.Kind,
+			Dataset: res.Metadata.Dataset,
+			Name:    name,
+			ID:      res.ID,
+			Path:    res.Path,
+		})
+	}
+	return out, nil
+}
+
+// manifestPath returns where the manifest of a resource is written under dir.
+func manifestPath(dir string, k *resourceKind, m manifest) string {
+	if !k.datasetScoped {
+		return filepath.Join(dir, kebabCase(k.name)+"s", fileSlug(m.Metadata.Name)+".yaml")
+	}
+	base := filepath.Join(dir, "datasets", fileSlug(m.Metadata.Dataset))
+	if k.singleton {
+		return filepath.Join(base, kebabCase(k.name)+".yaml")
+	}
+	return filepath.Join(base, kebabCase(k.name)+"s", fileSlug(m.Metadata.Name)+".yaml")
+}
+
+// marshalManifest encodes a manifest as YAML, masking its secrets.
+func marshalManifest(m manifest) ([]byte, error) {
+	raw, err := json.Marshal(m)
+	if err != nil {
+		return nil, err
+	}
+	var data interface{}
+	dec := json.NewDecoder(bytes.NewReader(client.Redactor().JSON(raw)))
+	dec.UseNumber()
+	if err := dec.Decode(&data); err != nil {
+		return nil, err
+	}
+	if doc, ok := data.(map[string]interface{}); ok && isZeroSpecValue(doc["metadata"]) {
+		delete(doc, "metadata")
+	}
+
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(yamlValue(data)); err != nil {
+		return nil, err
+	}
+	if err := enc.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// kebabCase converts a kind such as DerivedColumn to derived-column.
+func kebabCase(s string) string {
+	var b strings.Builder
+	runes := []rune(s)
+	for i, r := range runes {
+		if i > 0 && unicode.IsUpper(r) && unicode.IsLower(runes[i-1]) {
+			b.WriteByte('-')
+		}
+		b.WriteRune(unicode.ToLower(r))
+	}
+	return b.String()
+}
+
+// fileSlug converts a name to a form safe for a file name, such as
+// api-availability for "API availability".
+func fileSlug(s string) string {
+	var b strings.Builder
+	dash := false
+	for _, r := range strings.ToLower(s) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) || r == '.' || r == '_' {
+			if dash && b.Len() > 0 {
+				b.WriteByte('-')
+			}
+			b.WriteRune(r)
+			dash = false
+			continue
+		}
+		dash = true
+	}
+	// Names of only dots would otherwise become hidden files, which
+	// loadManifests skips.
+	slug := strings.Trim(b.String(), ".")
+	if slug == "" {
+		return "unnamed"
+	}
+	return slug
+}
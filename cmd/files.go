@@ -0,0 +1,53 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+
+	"gopkg.in/yaml.v3"
+)
+
+// readResourceFile decodes a JSON or YAML file into v, using v's JSON field
+// names for both formats. A path of "-" reads from stdin.
+func readResourceFile(path string, v interface{}) error {
+	raw, err := readFileOrStdin(path)
+	if err != nil {
+		return err
+	}
+
+	// YAML is a superset of JSON, so decoding as YAML handles both. Going
+	// through a generic value means the json struct tags apply to YAML too.
+	var data interface{}
+	if err := yaml.Unmarshal(raw, &data); err != nil {
+		return fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	asJSON, err := json.Marshal(data)
+	if err != nil {
+		return fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	if err := json.Unmarshal(asJSON, v); err != nil {
+		return fmt.Errorf("failed to decode %s: %w", path, err)
+	}
+
+	return nil
+}
+
+// readFileOrStdin reads a file, or stdin if path is "-".
+func readFileOrStdin(path string) ([]byte, error) {
+	if path == "-" {
+		return io.ReadAll(os.Stdin)
+	}
+	return os.ReadFile(path)
+}
+
+// writeFileAtomic writes data to path through a temporary file, so that the
+// file is never left half written.
+func writeFileAtomic(path string, data []byte) error {
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
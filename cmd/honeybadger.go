@@ -1,14 +1,18 @@
 package cmd
 
 import (
+	"errors"
 	"fmt"
 	"os"
 	"strings"
+	"time"
 
 	"github.com/spf13/cobra"
 	"github.com/spf13/pflag"
 	"github.com/spf13/viper"
 
+	"github.com/adz-anz/honeybadger/honeycomb"
+
 	log "github.com/sirupsen/logrus"
 )
 
@@ -17,6 +21,24 @@ var (
 	apiHost       string
 	targetDataset string
 	dryRun        bool
+	retries       int
+	retryMaxWait  time.Duration
+	showSecrets   bool
+	redactHeaders []string
+	redactFields  []string
+
+	// client is created from the root flags before any command is run.
+	client *honeycomb.Client
+
+	// redactor masks secrets in logs and errors. It is nil if --show-secrets
+	// was given.
+	redactor *honeycomb.Redactor
+
+	// buildID is set by CI
+	buildID = "dev" // TODO: set this to the actual build ID
+
+	// userAgent is what gets included in all http requests to the api
+	userAgent string = fmt.Sprintf("%s/%s", appName, buildID)
 )
 
 const (
@@ -125,18 +147,54 @@ func NewHoneybadgerCmd() *cobra.Command {
 		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
 			// You can bind cobra and viper in a few locations, but
 			// PersistencePreRunE on the root command works well.
-			return initializeConfig(cmd)
+			if err := initializeConfig(cmd); err != nil {
+				return err
+			}
+			if configKey == "" && !skipsConfigKey(cmd) {
+				return errors.New(`required flag(s) "configkey" not set`)
+			}
+			if err := validateOutputFormat(); err != nil {
+				return err
+			}
+			if err := initializeClient(cmd); err != nil {
+				return err
+			}
+			initializeRedaction()
+			return nil
 		},
 	}
 	cmd.PersistentFlags().StringVarP(&configKey, "configkey", "k", "",
 		"Honeycomb configuration key from https://ui.honeycomb.io/<team>/environments/<environment>/api_keys")
-	cmd.MarkPersistentFlagRequired("configkey")
+	cmd.PersistentFlags().StringVar(&profileName, "profile", "",
+		"The named profile from the config file to use. Can also be set as HONEYBADGER_PROFILE.")
 	cmd.PersistentFlags().StringVar(&apiHost, "api_host",
-		"https://api.honeycomb.io/", "The host to query, don't change it unless it's a hosted Honeycomb environment.")
+		honeycomb.DefaultAPIHost, "The host to query, don't change it unless it's a hosted Honeycomb environment.")
 	cmd.PersistentFlags().MarkHidden("api_host")
-	cmd.PersistentFlags().BoolVar(&dryRun, "dry-run", false, "Print the request that would be sent without actually sending it.")
+	cmd.PersistentFlags().BoolVar(&dryRun, "dry-run", false, "Print the request that would be sent to stderr without actually sending it.")
+	cmd.PersistentFlags().IntVar(&retries, "retries", honeycomb.DefaultRetryPolicy.MaxAttempts-1,
+		"The number of times to retry a request that was rate limited or failed with a transient server error.")
+	cmd.PersistentFlags().DurationVar(&retryMaxWait, "retry-max-wait", honeycomb.DefaultRetryPolicy.MaxDelay,
+		"The maximum time to wait between retries, including waits requested by the server.")
+	cmd.PersistentFlags().StringVarP(&outputFormat, "output", "o", outputJSON,
+		"Output format. One of: json, json-compact, yaml, table, csv, jsonpath=<expr>, go-template=<template>.")
+	cmd.PersistentFlags().BoolVar(&outputNoHeaders, "no-headers", false,
+		"Omit the header row from table and csv output.")
+	cmd.PersistentFlags().StringSliceVar(&outputColumns, "columns", nil,
+		"The columns to show in table and csv output, as dot-separated JSON paths (e.g. id,name,links.board_url).")
+	cmd.PersistentFlags().BoolVar(&showSecrets, "show-secrets", false,
+		"Do not mask the configuration key and other secrets in dry run output, logs and errors.")
+	cmd.PersistentFlags().StringSliceVar(&redactHeaders, "redact-header", nil,
+		"Additional request headers to mask, on top of X-Honeycomb-Team, X-Amz-Firehose-Access-Key, Authorization and Cookie.")
+	cmd.PersistentFlags().StringSliceVar(&redactFields, "redact-field", nil,
+		"Additional JSON body fields to mask, on top of recipient secrets and integration keys.")
 
 	setCommandGroups(cmd, []commandGroup{
+		{
+			Name: "Configuration Commands",
+			Commands: []*cobra.Command{
+				newConfigCmd(),
+			},
+		},
 		{
 			Name: "Authorization Commands",
 			Commands: []*cobra.Command{
@@ -149,19 +207,19 @@ func NewHoneybadgerCmd() *cobra.Command {
 				newBoardsCmd(),
 			},
 		},
-		// {
-		// 	Name: "Burn Alert Commands",
-		// 	Commands: []*cobra.Command{
-		// 		newBurnAlertsCmd(),
-		// 	},
-		// },
-		// {
-		// 	Name: "Column Commands",
-		// 	Commands: []*cobra.Command{
-		// 		newColumnsCmd(),
-		// 		newDerivedColumnsCmd(),
-		// 	},
-		// },
+		{
+			Name: "Burn Alert Commands",
+			Commands: []*cobra.Command{
+				newBurnAlertsCmd(),
+			},
+		},
+		{
+			Name: "Column Commands",
+			Commands: []*cobra.Command{
+				newColumnsCmd(),
+				newDerivedColumnsCmd(),
+			},
+		},
 		{
 			Name: "Dataset Commands",
 			Commands: []*cobra.Command{
@@ -169,13 +227,12 @@ func NewHoneybadgerCmd() *cobra.Command {
 				newDatasetDefinitionsCmd(),
 			},
 		},
-		// {
-		// 	Name: "Event Commands",
-		// 	Commands: []*cobra.Command{
-		// 		newEventCmd(),
-		// 		newKinesisEventCmd(),
-		// 	},
-		// },
+		{
+			Name: "Event Commands",
+			Commands: []*cobra.Command{
+				newEventsCmd(),
+			},
+		},
 		{
 			Name: "Marker Commands",
 			Commands: []*cobra.Command{
@@ -183,37 +240,56 @@ func NewHoneybadgerCmd() *cobra.Command {
 				newMarkerSettingsCmd(),
 			},
 		},
-		// {
-		// 	Name: "Query Commands",
-		// 	Commands: []*cobra.Command{
-		// 		newQueriesCmd(),
-		// 		newQueryAnnotationsCmd(),
-		// 		newQueryDataCmd(),
-		// 	},
-		// },
-		// {
-		// 	Name: "Recipient Commands",
-		// 	Commands: []*cobra.Command{
-		// 		newRecipientsCmd(),
-		// 	},
-		// },
-		// {
-		// 	Name: "SLO Commands",
-		// 	Commands: []*cobra.Command{
-		// 		newSLOsCmd(),
-		// 	},
-		// },
-		// {
-		// 	Name: "Trigger Commands",
-		// 	Commands: []*cobra.Command{
-		// 		newTriggersCmd(),
-		// 	},
-		// },
+		{
+			Name: "Query Commands",
+			Commands: []*cobra.Command{
+				newQueriesCmd(),
+				newQueryAnnotationsCmd(),
+			},
+		},
+		{
+			Name: "Recipient Commands",
+			Commands: []*cobra.Command{
+				newRecipientsCmd(),
+			},
+		},
+		{
+			Name: "SLO Commands",
+			Commands: []*cobra.Command{
+				newSLOsCmd(),
+			},
+		},
+		{
+			Name: "Trigger Commands",
+			Commands: []*cobra.Command{
+				newTriggersCmd(),
+			},
+		},
+		{
+			Name: "Manifest Commands",
+			Commands: []*cobra.Command{
+				newPlanCmd(),
+				newApplyCmd(),
+				newExportCmd(),
+				newDriftCmd(),
+				newPromoteCmd(),
+				newBackupCmd(),
+				newRestoreCmd(),
+			},
+		},
 	})
 
 	return cmd
 }
 
+// Execute runs the root command. Errors from individual commands exit with their
+// own codes; any other failure, such as an unknown flag, exits with exitUsage.
+func Execute() {
+	if err := NewHoneybadgerCmd().Execute(); err != nil {
+		os.Exit(exitUsage)
+	}
+}
+
 func initializeConfig(cmd *cobra.Command) error {
 	v := viper.New()
 
@@ -221,8 +297,12 @@ func initializeConfig(cmd *cobra.Command) error {
 	v.SetConfigName(viperDefaultConfigFilename)
 
 	// Set as many paths as you like where viper should look for the
-	// config file. We are only looking in the current working directory.
+	// config file. The current working directory takes precedence over the
+	// user config directory.
 	v.AddConfigPath(".")
+	if dir, err := userConfigDir(); err == nil {
+		v.AddConfigPath(dir)
+	}
 
 	// Attempt to read the config file, gracefully ignoring errors
 	// caused by a config file not being found. Return an error
@@ -233,6 +313,7 @@ func initializeConfig(cmd *cobra.Command) error {
 			return err
 		}
 	}
+	configFileUsed = v.ConfigFileUsed()
 
 	// When we bind flags to environment variables expect that the environment
 	// variables are prefixed, e.g. a flag like --number binds to an environment
@@ -249,12 +330,58 @@ func initializeConfig(cmd *cobra.Command) error {
 	// like --favorite-color which we fix in the bindFlags function
 	v.AutomaticEnv()
 
+	// Settings from the selected profile override those at the top level of
+	// the config file.
+	if err := resolveProfile(cmd, v); err != nil {
+		return err
+	}
+
 	// Bind the current command's flags to viper
 	bindFlags(cmd, v)
 
 	return nil
 }
 
+// initializeClient creates the Honeycomb client shared by all commands from the
+// root flags.
+func initializeClient(cmd *cobra.Command) error {
+	var err error
+	client, err = newClient(cmd, apiHost, configKey)
+	return err
+}
+
+// newClient creates a Client for the given API host and configuration key,
+// with the options set by the global flags.
+func newClient(cmd *cobra.Command, host, key string) (*honeycomb.Client, error) {
+	opts := []honeycomb.Option{
+		honeycomb.WithAPIHost(host),
+		honeycomb.WithConfigKey(key),
+		honeycomb.WithUserAgent(userAgent),
+		honeycomb.WithRetryPolicy(honeycomb.RetryPolicy{
+			MaxAttempts: retries + 1,
+			BaseDelay:   honeycomb.DefaultRetryPolicy.BaseDelay,
+			MaxDelay:    retryMaxWait,
+			Jitter:      honeycomb.DefaultRetryPolicy.Jitter,
+		}),
+	}
+	if dryRun {
+		opts = append(opts, honeycomb.WithDryRun(os.Stderr))
+		if _, ok := cmd.Annotations[annotationLiveReads]; ok {
+			opts = append(opts, honeycomb.WithLiveReads())
+		}
+	}
+	if showSecrets {
+		opts = append(opts, honeycomb.WithRedactor(nil))
+	} else {
+		r := honeycomb.NewRedactor()
+		r.AddHeaders(redactHeaders...)
+		r.AddFields(redactFields...)
+		opts = append(opts, honeycomb.WithRedactor(r))
+	}
+
+	return honeycomb.NewClient(opts...)
+}
+
 // Bind each cobra flag to its associated viper configuration (config file and environment variable)
 func bindFlags(cmd *cobra.Command, v *viper.Viper) {
 	cmd.Flags().VisitAll(func(f *pflag.Flag) {
@@ -269,6 +396,14 @@ func bindFlags(cmd *cobra.Command, v *viper.Viper) {
 
 		// Apply the viper config value to the flag when the flag is not set and viper has a value
 		if !f.Changed && v.IsSet(configName) {
+			// Slices are set element by element, as formatting them with %v
+			// would produce a single "[a b]" value.
+			if f.Value.Type() == "stringSlice" {
+				for _, val := range v.GetStringSlice(configName) {
+					cmd.Flags().Set(f.Name, val)
+				}
+				return
+			}
 			val := v.Get(configName)
 			cmd.Flags().Set(f.Name, fmt.Sprintf("%v", val))
 		}
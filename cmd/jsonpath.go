@@ -0,0 +1,112 @@
+package cmd
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// evalJSONPath evaluates a small subset of JSONPath against data decoded from
+// JSON: field access (.name), array indexing ([0]) and wildcards ([*] or .*).
+// The expression may optionally be wrapped in braces, as kubectl expects, and
+// may start with $.
+//
+//	{.id}
+//	$[*].links.board_url
+//	.queries[0].query_id
+func evalJSONPath(expr string, data interface{}) ([]interface{}, error) {
+	path := strings.TrimSpace(expr)
+	path = strings.TrimSuffix(strings.TrimPrefix(path, "{"), "}")
+	path = strings.TrimPrefix(path, "$")
+
+	current := []interface{}{data}
+	for pos := 0; pos < len(path); {
+		var next []interface{}
+
+		switch path[pos] {
+		case '.':
+			end := pos + 1
+			for end < len(path) && path[end] != '.' && path[end] != '[' {
+				end++
+			}
+			field := path[pos+1 : end]
+			if field == "" {
+				return nil, fmt.Errorf("invalid jsonpath %q: empty field name at position %d", expr, pos)
+			}
+			for _, c := range current {
+				if field == "*" {
+					next = append(next, children(c)...)
+					continue
+				}
+				if m, ok := c.(map[string]interface{}); ok {
+					if v, ok := m[field]; ok {
+						next = append(next, v)
+					}
+				}
+			}
+			pos = end
+
+		case '[':
+			end := strings.IndexByte(path[pos:], ']')
+			if end < 0 {
+				return nil, fmt.Errorf("invalid jsonpath %q: unclosed [ at position %d", expr, pos)
+			}
+			end += pos
+			index := strings.Trim(path[pos+1:end], `'"`)
+			for _, c := range current {
+				if index == "*" {
+					next = append(next, children(c)...)
+					continue
+				}
+				switch val := c.(type) {
+				case []interface{}:
+					i, err := strconv.Atoi(index)
+					if err != nil {
+						return nil, fmt.Errorf("invalid jsonpath %q: bad index %q", expr, index)
+					}
+					if i < 0 {
+						i += len(val)
+					}
+					if i >= 0 && i < len(val) {
+						next = append(next, val[i])
+					}
+				case map[string]interface{}:
+					if v, ok := val[index]; ok {
+						next = append(next, v)
+					}
+				}
+			}
+			pos = end + 1
+
+		default:
+			return nil, fmt.Errorf("invalid jsonpath %q: unexpected %q at position %d", expr, path[pos], pos)
+		}
+
+		current = next
+	}
+
+	return current, nil
+}
+
+// children returns the elements of an array, or the values of an object in
+// key order.
+func children(v interface{}) []interface{} {
+	switch val := v.(type) {
+	case []interface{}:
+		return val
+	case map[string]interface{}:
+		keys := make([]string, 0, len(val))
+		for k := range val {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		out := make([]interface{}, 0, len(keys))
+		for _, k := range keys {
+			out = append(out, val[k])
+		}
+		return out
+	default:
+		return nil
+	}
+}
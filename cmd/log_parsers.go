@@ -0,0 +1,213 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Parsers accepted by --parser.
+const (
+	parserJSON   = "json"
+	parserLogfmt = "logfmt"
+	parserNginx  = "nginx"
+	parserApache = "apache"
+	parserRegex  = "regex"
+)
+
+var logParsers = []string{parserJSON, parserLogfmt, parserNginx, parserApache, parserRegex}
+
+// logParser parses a line of a log into the fields of an event, and the time
+// of the event if the format of the line has one.
+type logParser func(line string) (map[string]interface{}, *time.Time, error)
+
+// newLogParser returns the parser with the given name. pattern is the regular
+// expression used by the regex parser, and must have at least one named group.
+func newLogParser(name, pattern string) (logParser, error) {
+	switch name {
+	case parserJSON:
+		return parseJSONLine, nil
+	case parserLogfmt:
+		return parseLogfmtLine, nil
+	case parserNginx, parserApache:
+		return parseCombinedLine, nil
+	case parserRegex:
+		return newRegexParser(pattern)
+	default:
+		return nil, fmt.Errorf("--parser must be one of %s", strings.Join(logParsers, ", "))
+	}
+}
+
+// parseJSONLine parses a line holding a JSON object.
+func parseJSONLine(line string) (map[string]interface{}, *time.Time, error) {
+	var fields map[string]interface{}
+	dec := json.NewDecoder(strings.NewReader(line))
+	dec.UseNumber()
+	if err := dec.Decode(&fields); err != nil {
+		return nil, nil, fmt.Errorf("the line must be a JSON object: %w", err)
+	}
+	if fields == nil {
+		return nil, nil, errors.New("the line must be a JSON object")
+	}
+	return fields, nil, nil
+}
+
+// parseLogfmtLine parses a line of space-separated key=value pairs, as written
+// by logfmt. Values may be wrapped in double quotes, with the escapes of a Go
+// string, and a key without a value is true. The types of unquoted values are
+// inferred.
+func parseLogfmtLine(line string) (map[string]interface{}, *time.Time, error) {
+	fields := map[string]interface{}{}
+	rest := strings.TrimSpace(line)
+	for rest != "" {
+		end := strings.IndexAny(rest, "= ")
+		if end == -1 {
+			end = len(rest)
+		}
+		key := rest[:end]
+		if key == "" || strings.ContainsAny(key, `"{`) {
+			return nil, nil, fmt.Errorf("expected a key at %q", rest)
+		}
+		rest = rest[end:]
+
+		if !strings.HasPrefix(rest, "=") {
+			fields[key] = true
+			rest = strings.TrimLeft(rest, " ")
+			continue
+		}
+		rest = rest[1:]
+
+		if strings.HasPrefix(rest, `"`) {
+			quoted, err := strconv.QuotedPrefix(rest)
+			if err != nil {
+				return nil, nil, fmt.Errorf("unterminated value for key %s", key)
+			}
+			value, err := strconv.Unquote(quoted)
+			if err != nil {
+				return nil, nil, fmt.Errorf("invalid value for key %s: %w", key, err)
+			}
+			fields[key] = value
+			rest = rest[len(quoted):]
+		} else {
+			end := strings.IndexByte(rest, ' ')
+			if end == -1 {
+				end = len(rest)
+			}
+			fields[key] = inferValue(rest[:end])
+			rest = rest[end:]
+		}
+		rest = strings.TrimLeft(rest, " ")
+	}
+	if len(fields) == 0 {
+		return nil, nil, errors.New("the line has no fields")
+	}
+	return fields, nil, nil
+}
+
+// combinedLog matches the combined log format written by default by nginx, and
+// by Apache with the "combined" LogFormat. The referer and user agent are
+// optional, so that the common log format is matched too.
+var combinedLog = regexp.MustCompile(`^(\S+) (\S+) (\S+) \[([^\]]+)\] "((?:[^"\\]|\\.)*)" (\d{3}) (\d+|-)(?: "((?:[^"\\]|\\.)*)" "((?:[^"\\]|\\.)*)")?`)
+
+// combinedTimeLayout is the layout of times in the combined log format.
+const combinedTimeLayout = "02/Jan/2006:15:04:05 -0700"
+
+// parseCombinedLine parses a line in the combined log format. The fields are
+// named after the nginx variables that make up the format, and the request is
+// also split into its method, path and protocol.
+func parseCombinedLine(line string) (map[string]interface{}, *time.Time, error) {
+	m := combinedLog.FindStringSubmatch(line)
+	if m == nil {
+		return nil, nil, errors.New("the line is not in the combined log format")
+	}
+
+	t, err := time.Parse(combinedTimeLayout, m[4])
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid time %q", m[4])
+	}
+
+	fields := map[string]interface{}{
+		"remote_addr": m[1],
+		"request":     unescapeLogValue(m[5]),
+	}
+	if m[3] != "-" {
+		fields["remote_user"] = m[3]
+	}
+	if method, rest, ok := strings.Cut(fields["request"].(string), " "); ok {
+		fields["request_method"] = method
+		path, protocol, _ := strings.Cut(rest, " ")
+		fields["request_path"] = path
+		if protocol != "" {
+			fields["request_protocol"] = protocol
+		}
+	}
+	fields["status"], _ = strconv.ParseInt(m[6], 10, 64)
+	if m[7] != "-" {
+		fields["body_bytes_sent"], _ = strconv.ParseInt(m[7], 10, 64)
+	}
+	if m[8] != "" && m[8] != "-" {
+		fields["http_referer"] = unescapeLogValue(m[8])
+	}
+	if m[9] != "" && m[9] != "-" {
+		fields["http_user_agent"] = unescapeLogValue(m[9])
+	}
+
+	return fields, &t, nil
+}
+
+// unescapeLogValue removes the backslashes that nginx and Apache put before
+// quotes and backslashes in quoted values.
+func unescapeLogValue(s string) string {
+	if !strings.Contains(s, `\`) {
+		return s
+	}
+	var b bytes.Buffer
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\\' && i+1 < len(s) && (s[i+1] == '"' || s[i+1] == '\\') {
+			i++
+		}
+		b.WriteByte(s[i])
+	}
+	return b.String()
+}
+
+// newRegexParser returns a parser taking the fields of an event from the named
+// groups of pattern. The types of the values are inferred, and groups that do
+// not match are left out.
+func newRegexParser(pattern string) (logParser, error) {
+	if pattern == "" {
+		return nil, errors.New("--regex is required by the regex parser")
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("invalid --regex: %w", err)
+	}
+	names := re.SubexpNames()
+	named := false
+	for _, n := range names {
+		named = named || n != ""
+	}
+	if !named {
+		return nil, errors.New("--regex must have at least one named group, such as (?P<status>\\d+)")
+	}
+
+	return func(line string) (map[string]interface{}, *time.Time, error) {
+		idx := re.FindStringSubmatchIndex(line)
+		if idx == nil {
+			return nil, nil, errors.New("the line does not match --regex")
+		}
+		fields := map[string]interface{}{}
+		for i, n := range names {
+			if n == "" || idx[2*i] < 0 {
+				continue
+			}
+			fields[n] = inferValue(line[idx[2*i]:idx[2*i+1]])
+		}
+		return fields, nil, nil
+	}, nil
+}
@@ -0,0 +1,303 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/adz-anz/honeybadger/honeycomb"
+)
+
+// defaultStateFile is the name of the file recording the resources owned by
+// apply, kept beside the manifests unless --state is given.
+const defaultStateFile = ".honeybadger-state.json"
+
+// manifest is a resource declared in a manifest file:
+//
+//	kind: SLO
+//	metadata:
+//	  dataset: api
+//	spec:
+//	  name: API availability
+//	  ...
+type manifest struct {
+	Kind     string                 `json:"kind"`
+	Metadata manifestMetadata       `json:"metadata"`
+	Spec     map[string]interface{} `json:"spec"`
+
+	// Where the manifest was read from, for error messages.
+	source string
+}
+
+type manifestMetadata struct {
+	// Identifies the resource among those of its kind in its dataset.
+	// Defaults to the natural key of the resource, such as the alias of a
+	// Derived Column or the name of an SLO.
+	Name string `json:"name,omitempty"`
+
+	// The dataset the resource belongs to, for kinds that belong to one.
+	Dataset string `json:"dataset,omitempty"`
+}
+
+// ref returns the reference identifying the declared resource.
+func (m manifest) ref() resourceRef {
+	return resourceRef{Kind: m.Kind, Dataset: m.Metadata.Dataset, Name: m.Metadata.Name}
+}
+
+// resourceRef identifies a resource managed through manifests.
+type resourceRef struct {
+	Kind    string `json:"kind"`
+	Dataset string `json:"dataset,omitempty"`
+	Name    string `json:"name"`
+}
+
+func (r resourceRef) String() string {
+	if r.Dataset == "" {
+		return r.Kind + "/" + r.Name
+	}
+	return r.Kind + "/" + r.Dataset + "/" + r.Name
+}
+
+// loadManifests reads the manifests in each path, which is either a file or a
+// directory searched for .yaml, .yml and .json files. Files and directories
+// whose names start with a dot are skipped. A file may hold several YAML
+// documents, or a list of manifests.
+func loadManifests(paths []string) ([]manifest, error) {
+	var files []string
+	for _, p := range paths {
+		info, err := os.Stat(p)
+		if err != nil {
+			return nil, err
+		}
+		if !info.IsDir() {
+			files = append(files, p)
+			continue
+		}
+		err = filepath.WalkDir(p, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if path != p && strings.HasPrefix(d.Name(), ".") {
+				if d.IsDir() {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			switch strings.ToLower(filepath.Ext(path)) {
+			case ".yaml", ".yml", ".json":
+				if !d.IsDir() {
+					files = append(files, path)
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	sort.Strings(files)
+
+	var out []manifest
+	for _, f := range files {
+		ms, err := readManifestFile(f)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, ms...)
+	}
+	return out, nil
+}
+
+// readManifestFile reads the manifests in a single file.
+func readManifestFile(path string) ([]manifest, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var out []manifest
+	dec := yaml.NewDecoder(bytes.NewReader(raw))
+	for doc := 1; ; doc++ {
+		var data interface{}
+		err := dec.Decode(&data)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+		}
+
+		items, ok := data.([]interface{})
+		if !ok {
+			items = []interface{}{data}
+		}
+		for i, item := range items {
+			if item == nil {
+				continue
+			}
+			source := fmt.Sprintf("%s (document %d)", path, doc)
+			if len(items) > 1 {
+				source = fmt.Sprintf("%s (document %d, item %d)", path, doc, i+1)
+			}
+
+			var m manifest
+			if err := decodeStrict(item, &m); err != nil {
+				return nil, fmt.Errorf("failed to decode %s: %w", source, err)
+			}
+			m.source = source
+			out = append(out, m)
+		}
+	}
+	return out, nil
+}
+
+// decodeStrict decodes a generic value, as decoded from YAML or JSON, into v
+// through JSON, failing on fields that v does not have. Numbers are kept as
+// json.Number in maps.
+func decodeStrict(data interface{}, v interface{}) error {
+	asJSON, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	dec := json.NewDecoder(bytes.NewReader(asJSON))
+	dec.UseNumber()
+	dec.DisallowUnknownFields()
+	return dec.Decode(v)
+}
+
+// toSpec converts a resource to a generic spec.
+func toSpec(v interface{}) (map[string]interface{}, error) {
+	data, err := toGeneric(v)
+	if err != nil {
+		return nil, err
+	}
+	spec, ok := data.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("expected an object, not %T", data)
+	}
+	return spec, nil
+}
+
+// validateManifests checks the manifests before any request is sent, and names
+// each that does not give its own name after its resource.
+func validateManifests(ms []manifest) error {
+	var problems []string
+	seen := map[string]string{}
+	for i := range ms {
+		m := &ms[i]
+		kind := kindByName(m.Kind)
+		if kind == nil {
+			problems = append(problems, fmt.Sprintf("%s: kind %q must be one of %s", m.source, m.Kind, strings.Join(kindNames(), ", ")))
+			continue
+		}
+		if m.Spec == nil {
+			problems = append(problems, fmt.Sprintf("%s: spec is required", m.source))
+			continue
+		}
+		if kind.datasetScoped && m.Metadata.Dataset == "" {
+			problems = append(problems, fmt.Sprintf("%s: metadata.dataset is required for %s", m.source, kind.name))
+		}
+		if !kind.datasetScoped && m.Metadata.Dataset != "" {
+			problems = append(problems, fmt.Sprintf("%s: metadata.dataset cannot be set for %s", m.source, kind.name))
+		}
+
+		if m.Metadata.Name == "" {
+			m.Metadata.Name = kind.nameOf(m.Metadata.Dataset, m.Spec)
+		}
+		if m.Metadata.Name == "" {
+			problems = append(problems, fmt.Sprintf("%s: metadata.name or the %s of the %s is required", m.source, kind.keyName, kind.name))
+			continue
+		}
+
+		ref := m.ref().String()
+		if other, ok := seen[ref]; ok {
+			problems = append(problems, fmt.Sprintf("%s: %s is also declared in %s", m.source, ref, other))
+		}
+		seen[ref] = m.source
+	}
+
+	if len(problems) > 0 {
+		return &honeycomb.ValidationError{Resource: "manifests", Problems: problems}
+	}
+	return nil
+}
+
+// ownedResource is a resource created or adopted by apply.
+type ownedResource struct {
+	resourceRef
+	ID string `json:"id"`
+}
+
+// applyState records the resources owned by apply in each environment. Owned
+// resources are found by ID even once renamed outside of the manifests, and
+// are the only resources --prune deletes; anything else is left alone.
+type applyState struct {
+	Environments map[string]map[string]ownedResource `json:"environments"`
+
+	path string
+}
+
+// defaultStatePath returns where the state is kept for manifests read from
+// paths: in the first directory given, or beside the first file.
+func defaultStatePath(paths []string) string {
+	if len(paths) == 0 {
+		return defaultStateFile
+	}
+	if info, err := os.Stat(paths[0]); err == nil && info.IsDir() {
+		return filepath.Join(paths[0], defaultStateFile)
+	}
+	return filepath.Join(filepath.Dir(paths[0]), defaultStateFile)
+}
+
+// readApplyState reads the state at path, which is empty if the file does not
+// exist yet.
+func readApplyState(path string) (*applyState, error) {
+	s := &applyState{Environments: map[string]map[string]ownedResource{}, path: path}
+	raw, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(raw, s); err != nil {
+		return nil, fmt.Errorf("failed to decode state %s: %w", path, err)
+	}
+	if s.Environments == nil {
+		s.Environments = map[string]map[string]ownedResource{}
+	}
+	return s, nil
+}
+
+// owned returns the resources owned in an environment.
+func (s *applyState) owned(env string) map[string]ownedResource {
+	if s.Environments[env] == nil {
+		s.Environments[env] = map[string]ownedResource{}
+	}
+	return s.Environments[env]
+}
+
+// save writes the state. Nothing is written on a dry run, or for a state that
+// is only kept in memory, without a path.
+func (s *applyState) save() error {
+	if dryRun || s.path == "" {
+		return nil
+	}
+	raw, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := writeFileAtomic(s.path, append(raw, '\n')); err != nil {
+		return fmt.Errorf("failed to write state: %w", err)
+	}
+	return nil
+}
@@ -1,34 +1,13 @@
 package cmd
 
 import (
-	"encoding/json"
-	"net/http"
-	"time"
-
 	"github.com/spf13/cobra"
 
+	"github.com/adz-anz/honeybadger/honeycomb"
+
 	log "github.com/sirupsen/logrus"
 )
 
-type markerSettings struct {
-	// Groups similar Markers. For example, 'deploys'. All Markers of the same
-	// type appear with the same color on the graph.
-	Type string `json:"type,omitempty"`
-
-	// Color to use for display of this marker type. Specified as hexadecimal
-	// RGB. For example, "#F96E11".
-	Color string `json:"color,omitempty"`
-
-	// The unique identifier (ID) of a Marker Setting.
-	ID string `json:"id,omitempty"`
-
-	// The ISO8601-formatted time when the Marker Setting was created.
-	CreatedAt *time.Time `json:"created_at,omitempty"`
-
-	// The ISO8601-formatted time when the Marker Setting was updated.
-	UpdatedAt *time.Time `json:"updated_at,omitempty"`
-}
-
 func newMarkerSettingsCmd() *cobra.Command {
 	cmd := &cobra.Command{
 		Use:     "marker_settings",
@@ -40,7 +19,7 @@ func newMarkerSettingsCmd() *cobra.Command {
 			"These commands allow you to list, create, update, and delete Marker Settings.",
 	}
 
-	cmd.PersistentFlags().StringVarP(&targetDataset, "dataset", "d", "__all__",
+	cmd.PersistentFlags().StringVarP(&targetDataset, "dataset", "d", honeycomb.EnvironmentWide,
 		"The dataset slug or use __all__ (or omit) for endpoints that support environment-wide operations.")
 
 	cmd.AddCommand(
@@ -67,34 +46,21 @@ func newMarkersSettingsCreateCmd() *cobra.Command {
 		Short:   "Create a Marker Setting in the specified dataset.",
 		Long:    "TODO: Update this with the actual description.",
 		Run: func(cmd *cobra.Command, args []string) {
-			var ms = markerSettings{
+			var ms = honeycomb.MarkerSetting{
 				Type:  msType,
 				Color: msColor,
 			}
 
-			var bodyMarshal, err = json.Marshal(ms)
+			created, err := client.CreateMarkerSetting(cmd.Context(), targetDataset, &ms)
 			if err != nil {
-				log.WithFields(log.Fields{
+				fatal(log.Fields{
 					"_function":      "newMarkersSettingsCreateCmd",
-					"err":            err,
+					"dataset":        targetDataset,
 					"marker_setting": ms,
-				}).Fatal("Error received when attempting to marshal a marker setting.")
-			}
-			var p = payload{
-				Method:   http.MethodPost,
-				Path:     "/1/marker_settings/" + targetDataset,
-				Body:     bodyMarshal,
-				Response: &markerSettings{},
+				}, err, "Error received when attempting to create a new marker setting.")
 			}
 
-			err = p.GetResponse(true)
-			if err != nil {
-				log.WithFields(log.Fields{
-					"_function": "newMarkersSettingsCreateCmd",
-					"err":       err,
-					"payload":   p,
-				}).Fatal("Error received when attempting to create a new marker setting.")
-			}
+			printResponse(created)
 		},
 	}
 
@@ -118,20 +84,15 @@ func newMarkersSettingsGetCmd() *cobra.Command {
 		Long:    `TODO: Update this with the actual description.`,
 		Example: `Example`,
 		Run: func(cmd *cobra.Command, args []string) {
-			var p = payload{
-				Method:   http.MethodGet,
-				Path:     "/1/marker_settings/" + targetDataset,
-				Response: &[]markerSettings{},
-			}
-
-			var err = p.GetResponse(true)
+			settings, err := client.ListMarkerSettings(cmd.Context(), targetDataset)
 			if err != nil {
-				log.WithFields(log.Fields{
+				fatal(log.Fields{
 					"_function": "newMarkersSettingsGetCmd",
-					"err":       err,
-					"payload":   p,
-				}).Fatal("Error received when attempting to list all marker settings.")
+					"dataset":   targetDataset,
+				}, err, "Error received when attempting to list all marker settings.")
 			}
+
+			printResponse(settings)
 		},
 	}
 
@@ -154,35 +115,22 @@ func newMarkersSettingsUpdateCmd() *cobra.Command {
 		Long:    `TODO: Update this with the actual description.`,
 		Example: `Example`,
 		Run: func(cmd *cobra.Command, args []string) {
-			var ms = markerSettings{
+			var ms = honeycomb.MarkerSetting{
 				ID:    msID,
 				Type:  msType,
 				Color: msColor,
 			}
 
-			var bodyMarshal, err = json.Marshal(ms)
+			updated, err := client.UpdateMarkerSetting(cmd.Context(), targetDataset, msID, &ms)
 			if err != nil {
-				log.WithFields(log.Fields{
+				fatal(log.Fields{
 					"_function":      "newMarkersSettingsUpdateCmd",
-					"err":            err,
+					"dataset":        targetDataset,
 					"marker_setting": ms,
-				}).Fatal("Error received when attempting to marshal a marker setting.")
-			}
-			var p = payload{
-				Method:   http.MethodPut,
-				Path:     "/1/markers/" + targetDataset + "/" + ms.ID,
-				Body:     bodyMarshal,
-				Response: &markerSettings{},
+				}, err, "Error received when attempting to update a marker setting.")
 			}
 
-			err = p.GetResponse(true)
-			if err != nil {
-				log.WithFields(log.Fields{
-					"_function": "newMarkersSettingsUpdateCmd",
-					"err":       err,
-					"payload":   p,
-				}).Fatal("Error received when attempting to update a marker setting.")
-			}
+			printResponse(updated)
 		},
 	}
 
@@ -211,22 +159,13 @@ func newMarkersSettingsDeleteCmd() *cobra.Command {
 		Short:   "Delete a Marker Setting in the specified dataset.",
 		Long:    `TODO: Update this with the actual description.`,
 		Run: func(cmd *cobra.Command, args []string) {
-			var ms = markerSettings{
-				ID: msID,
-			}
-			var p = payload{
-				Method:   http.MethodDelete,
-				Path:     "/1/marker_settings/" + targetDataset + "/" + ms.ID,
-				Response: nil,
-			}
-
-			var err = p.GetResponse(true)
+			err := client.DeleteMarkerSetting(cmd.Context(), targetDataset, msID)
 			if err != nil {
-				log.WithFields(log.Fields{
-					"_function": "newMarkersSettingsDeleteCmd",
-					"err":       err,
-					"payload":   p,
-				}).Fatal("Error received when attempting to delete a marker setting.")
+				fatal(log.Fields{
+					"_function":         "newMarkersSettingsDeleteCmd",
+					"dataset":           targetDataset,
+					"marker_setting_id": msID,
+				}, err, "Error received when attempting to delete a marker setting.")
 			}
 		},
 	}
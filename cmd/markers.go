@@ -1,49 +1,13 @@
 package cmd
 
 import (
-	"encoding/json"
-	"net/http"
-	"time"
-
 	"github.com/spf13/cobra"
 
+	"github.com/adz-anz/honeybadger/honeycomb"
+
 	log "github.com/sirupsen/logrus"
 )
 
-type marker struct {
-	// Indicates the time the Marker should be placed. If missing, defaults to
-	// the time the request arrives. Expressed in Unix Time.
-	StartTime int64 `json:"start_time,omitempty"`
-
-	// Specifies end time, and allows a Marker to be recorded as representing a
-	// time range, such as a 5 minute deploy. Expressed in Unix Time.
-	EndTime int64 `json:"end_time,omitempty"`
-
-	// A message to describe this specific Marker.
-	Message string `json:"message,omitempty"`
-
-	// Groups similar Markers. For example, 'deploys'. All Markers of the same
-	// type appear with the same color on the graph.
-	Type string `json:"type,omitempty"`
-
-	// A target for the marker. Clicking the marker text will take you to this
-	// URL.
-	URL string `json:"url,omitempty"`
-
-	// The unique identifier (ID) of a Marker.
-	ID string `json:"id,omitempty"`
-
-	// Color can be assigned to Markers using the Marker Settings endpoint. This
-	// field will be populated when List All Markers is called.
-	Color string `json:"color,omitempty"`
-
-	// The ISO8601-formatted time when the Marker was created.
-	CreatedAt *time.Time `json:"created_at,omitempty"`
-
-	// The ISO8601-formatted time when the Marker was updated.
-	UpdatedAt *time.Time `json:"updated_at,omitempty"`
-}
-
 func newMarkersCmd() *cobra.Command {
 	cmd := &cobra.Command{
 		Use:     "markers",
@@ -55,7 +19,7 @@ func newMarkersCmd() *cobra.Command {
 			"These commands allow you to list, create, update, and delete Markers.",
 	}
 
-	cmd.PersistentFlags().StringVarP(&targetDataset, "dataset", "d", "__all__",
+	cmd.PersistentFlags().StringVarP(&targetDataset, "dataset", "d", honeycomb.EnvironmentWide,
 		"The dataset slug or use __all__ (or omit) for endpoints that support environment-wide operations.")
 
 	cmd.AddCommand(
@@ -86,36 +50,24 @@ func newMarkersCreateCmd() *cobra.Command {
 			"use the __all__ dataset (or omit the dataset) and an API key associated with\n" +
 			"the desired environment.",
 		Run: func(cmd *cobra.Command, args []string) {
-			var m = marker{
+			var m = honeycomb.Marker{
 				StartTime: mStartTime,
 				EndTime:   mEndTime,
 				Message:   mMsg,
 				Type:      mType,
 				URL:       mURL,
 			}
-			var bodyMarshal, err = json.Marshal(m)
+
+			created, err := client.CreateMarker(cmd.Context(), targetDataset, &m)
 			if err != nil {
-				log.WithFields(log.Fields{
+				fatal(log.Fields{
 					"_function": "newMarkersCreateCmd",
-					"err":       err,
+					"dataset":   targetDataset,
 					"marker":    m,
-				}).Fatal("Error received when attempting to marshal a marker.")
-			}
-			var p = payload{
-				Method:   http.MethodPost,
-				Path:     "/1/markers/" + targetDataset,
-				Body:     bodyMarshal,
-				Response: &marker{},
+				}, err, "Error received when attempting to create a new marker.")
 			}
 
-			err = p.GetResponse(true)
-			if err != nil {
-				log.WithFields(log.Fields{
-					"_function": "newMarkersCreateCmd",
-					"err":       err,
-					"payload":   p,
-				}).Fatal("Error received when attempting to create a new marker.")
-			}
+			printResponse(created)
 		},
 	}
 
@@ -142,20 +94,15 @@ func newMarkersListCmd() *cobra.Command {
 		Long: "Lists all Markers for a dataset. To list environment markers, use the __all__\n" +
 			"dataset (or omit the dataset) and an API key associated with the desired environment.",
 		Run: func(cmd *cobra.Command, args []string) {
-			var p = payload{
-				Method:   http.MethodGet,
-				Path:     "/1/markers/" + targetDataset,
-				Response: &[]marker{},
-			}
-
-			var err = p.GetResponse(true)
+			markers, err := client.ListMarkers(cmd.Context(), targetDataset)
 			if err != nil {
-				log.WithFields(log.Fields{
+				fatal(log.Fields{
 					"_function": "newMarkersListCmd",
-					"err":       err,
-					"payload":   p,
-				}).Fatal("Error received when attempting to list all markers.")
+					"dataset":   targetDataset,
+				}, err, "Error received when attempting to list all markers.")
 			}
+
+			printResponse(markers)
 		},
 	}
 
@@ -180,7 +127,7 @@ func newMarkersUpdateCmd() *cobra.Command {
 		Long: "Update a Marker in the specified dataset. To update an environment marker, use the\n" +
 			"_all__ dataset (or omit the dataset) and an API key associated with the desired environment.",
 		Run: func(cmd *cobra.Command, args []string) {
-			var m = marker{
+			var m = honeycomb.Marker{
 				ID:        mID,
 				StartTime: mStartTime,
 				EndTime:   mEndTime,
@@ -188,29 +135,17 @@ func newMarkersUpdateCmd() *cobra.Command {
 				Type:      mType,
 				URL:       mURL,
 			}
-			var bodyMarshal, err = json.Marshal(m)
+
+			updated, err := client.UpdateMarker(cmd.Context(), targetDataset, mID, &m)
 			if err != nil {
-				log.WithFields(log.Fields{
+				fatal(log.Fields{
 					"_function": "newMarkersUpdateCmd",
-					"err":       err,
+					"dataset":   targetDataset,
 					"marker":    m,
-				}).Fatal("Error received when attempting to marshal a marker.")
-			}
-			var p = payload{
-				Method:   http.MethodPut,
-				Path:     "/1/markers/" + targetDataset + "/" + m.ID,
-				Body:     bodyMarshal,
-				Response: &marker{},
+				}, err, "Error received when attempting to update an existing marker.")
 			}
 
-			err = p.GetResponse(true)
-			if err != nil {
-				log.WithFields(log.Fields{
-					"_function": "newMarkersUpdateCmd",
-					"err":       err,
-					"payload":   p,
-				}).Fatal("Error received when attempting to update an existing marker.")
-			}
+			printResponse(updated)
 		},
 	}
 
@@ -243,22 +178,13 @@ func newMarkersDeleteCmd() *cobra.Command {
 		Long: "Delete a Marker in the specified dataset. To delete an environment marker, use the __all__\n" +
 			"dataset (or omit the dataset) and an API key associated with the desired environment.",
 		Run: func(cmd *cobra.Command, args []string) {
-			var m = marker{
-				ID: mID,
-			}
-			var p = payload{
-				Method:   http.MethodDelete,
-				Path:     "/1/markers/" + targetDataset + "/" + m.ID,
-				Response: &marker{},
-			}
-
-			var err = p.GetResponse(true)
+			err := client.DeleteMarker(cmd.Context(), targetDataset, mID)
 			if err != nil {
-				log.WithFields(log.Fields{
+				fatal(log.Fields{
 					"_function": "newMarkersDeleteCmd",
-					"err":       err,
-					"payload":   p,
-				}).Fatal("Error received when attempting to delete an existing marker.")
+					"dataset":   targetDataset,
+					"marker_id": mID,
+				}, err, "Error received when attempting to delete an existing marker.")
 			}
 		},
 	}
@@ -0,0 +1,522 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"text/template"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/adz-anz/honeybadger/honeycomb"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// Output formats accepted by --output. The jsonpath and go-template formats
+// take their expression after an equals sign, e.g. jsonpath={.id}.
+const (
+	outputJSON        = "json"
+	outputJSONCompact = "json-compact"
+	outputYAML        = "yaml"
+	outputTable       = "table"
+	outputCSV         = "csv"
+	outputJSONPath    = "jsonpath"
+	outputGoTemplate  = "go-template"
+)
+
+var (
+	outputFormat    string
+	outputNoHeaders bool
+	outputColumns   []string
+)
+
+// outputColumn is a single column of table or CSV output.
+type outputColumn struct {
+	// The column heading.
+	Header string
+
+	// The dot-separated path to the value within the JSON representation of
+	// the resource, e.g. links.board_url.
+	Path string
+}
+
+// defaultColumns are the columns shown for each resource in table and CSV
+// output when --columns is not given.
+var defaultColumns = map[reflect.Type][]outputColumn{
+	reflect.TypeOf(honeycomb.Auth{}): {
+		{"TEAM", "team.slug"},
+		{"ENVIRONMENT", "environment.slug"},
+	},
+	reflect.TypeOf(backupEntry{}): {
+		{"KIND", "kind"},
+		{"DATASET", "dataset"},
+		{"NAME", "name"},
+		{"ID", "id"},
+		{"PATH", "path"},
+	},
+	reflect.TypeOf(honeycomb.Board{}): {
+		{"ID", "id"},
+		{"NAME", "name"},
+		{"COLUMN LAYOUT", "column_layout"},
+		{"URL", "links.board_url"},
+	},
+	reflect.TypeOf(honeycomb.BurnAlert{}): {
+		{"ID", "id"},
+		{"SLO", "slo.id"},
+		{"ALERT TYPE", "alert_type"},
+		{"EXHAUSTION MINUTES", "exhaustion_minutes"},
+		{"WINDOW MINUTES", "budget_rate_window_minutes"},
+		{"DECREASE PER MILLION", "budget_rate_decrease_threshold_per_million"},
+	},
+	reflect.TypeOf(honeycomb.Column{}): {
+		{"ID", "id"},
+		{"KEY NAME", "key_name"},
+		{"TYPE", "type"},
+		{"HIDDEN", "hidden"},
+		{"LAST WRITTEN", "last_written"},
+	},
+	reflect.TypeOf(csvImportSummary{}): {
+		{"SOURCE", "source"},
+		{"ROWS", "rows"},
+		{"SKIPPED", "skipped"},
+		{"FILTERED", "filtered"},
+		{"SAMPLED OUT", "sampled_out"},
+		{"ACCEPTED", "accepted"},
+		{"REJECTED", "rejected"},
+	},
+	reflect.TypeOf(honeycomb.Dataset{}): {
+		{"SLUG", "slug"},
+		{"NAME", "name"},
+		{"COLUMNS", "regular_columns_count"},
+		{"LAST WRITTEN", "last_written_at"},
+	},
+	reflect.TypeOf(honeycomb.DatasetDefinition{}): {
+		{"TRACE ID", "trace_id.name"},
+		{"SPAN ID", "span_id.name"},
+		{"PARENT ID", "parent_id.name"},
+		{"NAME", "name.name"},
+		{"SERVICE NAME", "service_name.name"},
+		{"DURATION", "duration_ms.name"},
+	},
+	reflect.TypeOf(honeycomb.DerivedColumn{}): {
+		{"ID", "id"},
+		{"ALIAS", "alias"},
+		{"EXPRESSION", "expression"},
+	},
+	reflect.TypeOf(eventStatus{}): {
+		{"SOURCE", "source"},
+		{"INDEX", "index"},
+		{"STATUS", "status"},
+		{"ERROR", "error"},
+	},
+	reflect.TypeOf(exportedResource{}): {
+		{"KIND", "kind"},
+		{"DATASET", "dataset"},
+		{"NAME", "name"},
+		{"ID", "id"},
+		{"PATH", "path"},
+	},
+	reflect.TypeOf(honeycomb.KinesisResponse{}): {
+		{"REQUEST ID", "requestId"},
+		{"TIMESTAMP", "timestamp"},
+		{"ERROR", "errorMessage"},
+	},
+	reflect.TypeOf(kinesisRecordView{}): {
+		{"INDEX", "index"},
+		{"GZIPPED", "gzipped"},
+		{"BYTES", "bytes"},
+		{"DATA", "data"},
+	},
+	reflect.TypeOf(honeycomb.Marker{}): {
+		{"ID", "id"},
+		{"TYPE", "type"},
+		{"MESSAGE", "message"},
+		{"START", "start_time"},
+		{"END", "end_time"},
+		{"URL", "url"},
+	},
+	reflect.TypeOf(honeycomb.MarkerSetting{}): {
+		{"ID", "id"},
+		{"TYPE", "type"},
+		{"COLOR", "color"},
+	},
+	reflect.TypeOf(profileSummary{}): {
+		{"CURRENT", "current"},
+		{"NAME", "name"},
+		{"API HOST", "api_host"},
+		{"DATASET", "dataset"},
+		{"OUTPUT", "output"},
+	},
+	reflect.TypeOf(promotedResource{}): {
+		{"KIND", "kind"},
+		{"DATASET", "dataset"},
+		{"NAME", "name"},
+		{"ACTION", "action"},
+		{"SOURCE ID", "source_id"},
+		{"ID", "id"},
+	},
+	reflect.TypeOf(honeycomb.Query{}): {
+		{"ID", "id"},
+		{"TIME RANGE", "time_range"},
+		{"BREAKDOWNS", "breakdowns"},
+		{"CALCULATIONS", "calculations"},
+	},
+	reflect.TypeOf(honeycomb.QueryAnnotation{}): {
+		{"ID", "id"},
+		{"NAME", "name"},
+		{"QUERY ID", "query_id"},
+		{"SOURCE", "source"},
+	},
+	reflect.TypeOf(honeycomb.QueryResult{}): {
+		{"ID", "id"},
+		{"COMPLETE", "complete"},
+		{"URL", "links.query_url"},
+	},
+	reflect.TypeOf(honeycomb.Recipient{}): {
+		{"ID", "id"},
+		{"TYPE", "type"},
+		{"EMAIL", "details.email_address"},
+		{"SLACK CHANNEL", "details.slack_channel"},
+		{"PAGERDUTY", "details.pagerduty_integration_name"},
+		{"WEBHOOK", "details.webhook_name"},
+	},
+	reflect.TypeOf(recipientUsage{}): {
+		{"KIND", "kind"},
+		{"DATASET", "dataset"},
+		{"ID", "id"},
+		{"NAME", "name"},
+		{"SLO", "slo_id"},
+	},
+	reflect.TypeOf(restoredResource{}): {
+		{"KIND", "kind"},
+		{"DATASET", "dataset"},
+		{"NAME", "name"},
+		{"ACTION", "action"},
+		{"BACKUP ID", "backup_id"},
+		{"ID", "id"},
+	},
+	reflect.TypeOf(honeycomb.SLO{}): {
+		{"ID", "id"},
+		{"NAME", "name"},
+		{"SLI", "sli.alias"},
+		{"DAYS", "time_period_days"},
+		{"TARGET PER MILLION", "target_per_million"},
+	},
+	reflect.TypeOf(honeycomb.Trigger{}): {
+		{"ID", "id"},
+		{"NAME", "name"},
+		{"ALERT TYPE", "alert_type"},
+		{"FREQUENCY", "frequency"},
+		{"OP", "threshold.op"},
+		{"THRESHOLD", "threshold.value"},
+		{"TRIGGERED", "triggered"},
+		{"DISABLED", "disabled"},
+	},
+}
+
+// validateOutputFormat ensures --output names a known format, so that a typo
+// is reported before any request is sent.
+func validateOutputFormat() error {
+	name, expr, _ := strings.Cut(outputFormat, "=")
+	switch name {
+	case outputJSON, outputJSONCompact, outputYAML, outputTable, outputCSV:
+		return nil
+	case outputJSONPath, outputGoTemplate:
+		if expr == "" {
+			return fmt.Errorf("--output %s requires an expression, e.g. %s=...", name, name)
+		}
+		return nil
+	default:
+		return fmt.Errorf("unknown output format %q", outputFormat)
+	}
+}
+
+// printResponse writes a response to stdout in the format selected by
+// --output. Nothing is printed on a dry run, as no request was actually sent.
+func printResponse(v interface{}) {
+	if dryRun {
+		return
+	}
+
+	if err := writeOutput(os.Stdout, v); err != nil {
+		fatal(log.Fields{
+			"_function": "printResponse",
+			"output":    outputFormat,
+		}, err, "Error received when attempting to format a response.")
+	}
+}
+
+// writeOutput writes v to w in the format selected by --output.
+func writeOutput(w io.Writer, v interface{}) error {
+	name, expr, _ := strings.Cut(outputFormat, "=")
+
+	switch name {
+	case outputJSONCompact:
+		return writeJSON(w, v, "")
+
+	case outputYAML:
+		data, err := toGeneric(v)
+		if err != nil {
+			return err
+		}
+		enc := yaml.NewEncoder(w)
+		enc.SetIndent(2)
+		if err := enc.Encode(yamlValue(data)); err != nil {
+			return err
+		}
+		return enc.Close()
+
+	case outputTable, outputCSV:
+		rows, err := toRows(v)
+		if err != nil {
+			return err
+		}
+		cols := columnsFor(v, rows)
+		if name == outputCSV {
+			return writeCSV(w, cols, rows)
+		}
+		return writeTable(w, cols, rows)
+
+	case outputJSONPath:
+		data, err := toGeneric(v)
+		if err != nil {
+			return err
+		}
+		results, err := evalJSONPath(expr, data)
+		if err != nil {
+			return err
+		}
+		for _, r := range results {
+			if _, err := fmt.Fprintln(w, formatValue(r)); err != nil {
+				return err
+			}
+		}
+		return nil
+
+	case outputGoTemplate:
+		data, err := toGeneric(v)
+		if err != nil {
+			return err
+		}
+		tmpl, err := template.New("output").Option("missingkey=zero").Parse(expr)
+		if err != nil {
+			return fmt.Errorf("failed to parse template: %w", err)
+		}
+		var buf bytes.Buffer
+		if err := tmpl.Execute(&buf, data); err != nil {
+			return fmt.Errorf("failed to execute template: %w", err)
+		}
+		if !bytes.HasSuffix(buf.Bytes(), []byte("\n")) {
+			buf.WriteByte('\n')
+		}
+		_, err = w.Write(buf.Bytes())
+		return err
+
+	default:
+		return writeJSON(w, v, "  ")
+	}
+}
+
+// writeJSON writes v to w as JSON, indented by indent if it is not empty.
+// HTML characters are not escaped, so that query ops such as >= read as
+// written.
+func writeJSON(w io.Writer, v interface{}, indent string) error {
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", indent)
+	return enc.Encode(v)
+}
+
+// toGeneric converts v to its JSON representation as maps, slices and scalars,
+// so that every format sees the same field names as the JSON output.
+func toGeneric(v interface{}) (interface{}, error) {
+	out, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	var data interface{}
+	dec := json.NewDecoder(bytes.NewReader(out))
+	dec.UseNumber()
+	if err := dec.Decode(&data); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// toRows converts v to a list of rows, treating a single resource as a list of
+// one.
+func toRows(v interface{}) ([]interface{}, error) {
+	data, err := toGeneric(v)
+	if err != nil {
+		return nil, err
+	}
+	if rows, ok := data.([]interface{}); ok {
+		return rows, nil
+	}
+	if data == nil {
+		return nil, nil
+	}
+	return []interface{}{data}, nil
+}
+
+// columnsFor returns the columns to show for v: those given with --columns,
+// else the defaults for its type, else every top-level field of the rows.
+func columnsFor(v interface{}, rows []interface{}) []outputColumn {
+	if len(outputColumns) > 0 {
+		cols := make([]outputColumn, 0, len(outputColumns))
+		for _, c := range outputColumns {
+			header := strings.ToUpper(strings.NewReplacer(".", "_", "-", "_").Replace(c))
+			cols = append(cols, outputColumn{Header: header, Path: c})
+		}
+		return cols
+	}
+
+	t := reflect.TypeOf(v)
+	for t != nil && (t.Kind() == reflect.Pointer || t.Kind() == reflect.Slice) {
+		t = t.Elem()
+	}
+	if cols, ok := defaultColumns[t]; ok {
+		return cols
+	}
+
+	seen := map[string]bool{}
+	for _, r := range rows {
+		if m, ok := r.(map[string]interface{}); ok {
+			for k := range m {
+				seen[k] = true
+			}
+		}
+	}
+	keys := make([]string, 0, len(seen))
+	for k := range seen {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	cols := make([]outputColumn, 0, len(keys))
+	for _, k := range keys {
+		cols = append(cols, outputColumn{Header: strings.ToUpper(k), Path: k})
+	}
+	return cols
+}
+
+// lookupPath returns the value at a dot-separated path, or nil if any part of
+// the path is missing.
+func lookupPath(data interface{}, path string) interface{} {
+	for _, part := range strings.Split(path, ".") {
+		m, ok := data.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		data = m[part]
+	}
+	return data
+}
+
+// yamlValue converts the json.Numbers in a generic value to int64 or float64,
+// which YAML would otherwise quote as strings.
+func yamlValue(v interface{}) interface{} {
+	switch val := v.(type) {
+	case json.Number:
+		if n, err := val.Int64(); err == nil {
+			return n
+		}
+		if f, err := val.Float64(); err == nil {
+			return f
+		}
+		return val.String()
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(val))
+		for k, e := range val {
+			out[k] = yamlValue(e)
+		}
+		return out
+	case []interface{}:
+		out := make([]interface{}, len(val))
+		for i, e := range val {
+			out[i] = yamlValue(e)
+		}
+		return out
+	}
+	return v
+}
+
+// formatValue renders a single value for table, CSV or jsonpath output.
+// Scalars are printed as-is, and objects and arrays as compact JSON.
+func formatValue(v interface{}) string {
+	switch val := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return val
+	case json.Number:
+		return val.String()
+	case bool:
+		return fmt.Sprintf("%t", val)
+	default:
+		out, err := json.Marshal(val)
+		if err != nil {
+			return fmt.Sprintf("%v", val)
+		}
+		return string(out)
+	}
+}
+
+func writeTable(w io.Writer, cols []outputColumn, rows []interface{}) error {
+	tw := tabwriter.NewWriter(w, 0, 4, 3, ' ', 0)
+
+	if !outputNoHeaders {
+		headers := make([]string, len(cols))
+		for i, c := range cols {
+			headers[i] = c.Header
+		}
+		fmt.Fprintln(tw, strings.Join(headers, "\t"))
+	}
+
+	for _, r := range rows {
+		cells := make([]string, len(cols))
+		for i, c := range cols {
+			// Tabs and newlines would break the alignment of the table.
+			cells[i] = strings.NewReplacer("\t", " ", "\n", " ").Replace(formatValue(lookupPath(r, c.Path)))
+		}
+		fmt.Fprintln(tw, strings.Join(cells, "\t"))
+	}
+
+	return tw.Flush()
+}
+
+func writeCSV(w io.Writer, cols []outputColumn, rows []interface{}) error {
+	cw := csv.NewWriter(w)
+
+	if !outputNoHeaders {
+		headers := make([]string, len(cols))
+		for i, c := range cols {
+			headers[i] = c.Path
+		}
+		if err := cw.Write(headers); err != nil {
+			return err
+		}
+	}
+
+	for _, r := range rows {
+		cells := make([]string, len(cols))
+		for i, c := range cols {
+			cells[i] = formatValue(lookupPath(r, c.Path))
+		}
+		if err := cw.Write(cells); err != nil {
+			return err
+		}
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
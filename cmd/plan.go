@@ -0,0 +1,644 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+
+	"github.com/adz-anz/honeybadger/honeycomb"
+)
+
+// The actions of a plan.
+const (
+	planCreate = "create"
+	planUpdate = "update"
+	planAdopt  = "adopt"
+	planDelete = "delete"
+)
+
+// specChange is a difference between a declared spec and the resource as it
+// exists, at a dot-separated path into the spec.
+type specChange struct {
+	Path string      `json:"path"`
+	From interface{} `json:"from"`
+	To   interface{} `json:"to"`
+}
+
+// planAction is a change to make to a single resource.
+type planAction struct {
+	Action  string                 `json:"action"`
+	Kind    string                 `json:"kind"`
+	Dataset string                 `json:"dataset,omitempty"`
+	Name    string                 `json:"name"`
+	ID      string                 `json:"id,omitempty"`
+	Changes []specChange           `json:"changes,omitempty"`
+	Spec    map[string]interface{} `json:"spec,omitempty"`
+
+	kind     *resourceKind
+	declared map[string]interface{}
+	source   string
+}
+
+func (a *planAction) ref() resourceRef {
+	return resourceRef{Kind: a.Kind, Dataset: a.Dataset, Name: a.Name}
+}
+
+// resourcePlan is the set of changes that make an environment match the
+// manifests.
+type resourcePlan struct {
+	// The environment planned for, as <team>/<environment>.
+	Environment string `json:"environment"`
+
+	// The actions to take, in the order they are taken.
+	Actions []planAction `json:"actions"`
+
+	// The number of declared resources that are already as declared.
+	Unchanged int `json:"unchanged"`
+
+	// Resources owned by apply that are no longer declared, and are not
+	// being pruned.
+	Orphaned []ownedResource `json:"orphaned,omitempty"`
+
+	// Owned resources that no longer exist, to be dropped from the state.
+	forget []string
+
+	// The declared resources that are already as declared.
+	inSync []planAction
+}
+
+// CUSTOM
+// Show the changes that applying the manifests would make.
+func newPlanCmd() *cobra.Command {
+	var (
+		pFiles     []string
+		pStateFile string
+		pPrune     bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "plan",
+		Short: "Show the changes that applying manifests would make.",
+		Long: "Compare the resources declared in manifests with those in the environment, and\n" +
+			"show what apply would create, update and delete, without changing anything.\n" +
+			"\n" +
+			manifestsHelp,
+		Example: "  honeybadger plan -f manifests/\n" +
+			"  honeybadger plan -f manifests/ --prune -o json",
+		Annotations: map[string]string{annotationLiveReads: ""},
+		Args:        cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			ctx := cmd.Context()
+			plan, _, _ := planManifests(ctx, "newPlanCmd", pFiles, pStateFile, pPrune)
+			if cmd.Flags().Changed("output") {
+				printResponse(plan)
+				return
+			}
+			writePlan(os.Stdout, plan, pPrune)
+		},
+	}
+
+	registerManifestFlags(cmd, &pFiles, &pStateFile)
+	cmd.Flags().BoolVar(&pPrune, "prune", false,
+		"Plan to delete the resources owned by apply that are no longer declared.")
+
+	return cmd
+}
+
+// CUSTOM
+// Make the environment match the manifests.
+func newApplyCmd() *cobra.Command {
+	var (
+		aFiles     []string
+		aStateFile string
+		aPrune     bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "apply",
+		Short: "Create, update and delete resources to match manifests.",
+		Long: "Compare the resources declared in manifests with those in the environment, and\n" +
+			"create and update them to match. The plan is shown on stderr as it is applied.\n" +
+			"With --dry-run the current resources are still read, and the requests that\n" +
+			"would change them are printed instead of sent.\n" +
+			"\n" +
+			manifestsHelp,
+		Example: "  honeybadger apply -f manifests/\n" +
+			"  honeybadger apply -f manifests/ --prune --dry-run",
+		Annotations: map[string]string{annotationLiveReads: ""},
+		Args:        cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			ctx := cmd.Context()
+			plan, state, r := planManifests(ctx, "newApplyCmd", aFiles, aStateFile, aPrune)
+			writePlan(os.Stderr, plan, aPrune)
+
+			if err := applyPlan(ctx, plan, state, r); err != nil {
+				fatal(log.Fields{
+					"_function": "newApplyCmd",
+					"state":     state.path,
+				}, err, "Error received when attempting to apply manifests.")
+			}
+			printResponse(plan)
+		},
+	}
+
+	registerManifestFlags(cmd, &aFiles, &aStateFile)
+	cmd.Flags().BoolVar(&aPrune, "prune", false,
+		"Delete the resources owned by apply that are no longer declared. Resources apply did not create or adopt are never deleted.")
+
+	return cmd
+}
+
+// manifestsHelp describes manifests, for the help of the commands that read
+// them.
+const manifestsHelp = "Manifests are YAML or JSON files, read from the files and directories given by\n" +
+	"-f, each declaring one or more resources:\n" +
+	"\n" +
+	"  kind: SLO\n" +
+	"  metadata:\n" +
+	"    dataset: api\n" +
+	"  spec:\n" +
+	"    name: API availability\n" +
+	"    sli:\n" +
+	"      alias: sli.availability\n" +
+	"    time_period_days: 30\n" +
+	"    target_per_million: 999000\n" +
+	"\n" +
+	"The kinds are Recipient, Dataset, DatasetDefinition, Column, DerivedColumn,\n" +
+	"MarkerSetting, SLO, Trigger, BurnAlert, QueryAnnotation and Board, and the spec\n" +
+	"of each is as sent to the API. Burn Alerts refer to their SLO as\n" +
+	"slo: {name: ...}, Triggers and Burn Alerts may refer to Recipients by their type\n" +
+	"and target rather than ID, and Boards and Query Annotations may give their\n" +
+	"Queries inline as query: {...}. Only the fields a spec declares are compared.\n" +
+	"\n" +
+	"Resources are matched to existing ones by their name, alias, key name or type.\n" +
+	"The resources apply creates or adopts are recorded in a state file, kept beside\n" +
+	"the manifests, and only they are deleted by --prune. export writes manifests\n" +
+	"for the resources that exist."
+
+func registerManifestFlags(cmd *cobra.Command, files *[]string, stateFile *string) {
+	cmd.Flags().StringArrayVarP(files, "filename", "f", nil,
+		"A manifest file, or a directory of them. Can be given more than once.")
+	cmd.Flags().StringVar(stateFile, "state", "",
+		"The file recording the resources owned by apply. Defaults to "+defaultStateFile+" in the first directory given.")
+	cmd.MarkFlagRequired("filename")
+}
+
+// planManifests loads the manifests and state, and plans the changes to make,
+// exiting on error.
+func planManifests(ctx context.Context, function string, files []string, stateFile string, prune bool) (*resourcePlan, *applyState, *refResolver) {
+	ms, err := loadManifests(files)
+	if err == nil {
+		err = validateManifests(ms)
+	}
+	if err != nil {
+		fatal(log.Fields{
+			"_function": function,
+			"filename":  files,
+		}, err, "Error received when attempting to read manifests.")
+	}
+
+	if stateFile == "" {
+		stateFile = defaultStatePath(files)
+	}
+	state, err := readApplyState(stateFile)
+	if err != nil {
+		fatal(log.Fields{
+			"_function": function,
+			"state":     stateFile,
+		}, err, "Error received when attempting to read state.")
+	}
+
+	r := newRefResolver(ms)
+	plan, err := buildPlan(ctx, ms, state, r, prune)
+	if err != nil {
+		fatal(log.Fields{
+			"_function": function,
+			"filename":  files,
+		}, err, "Error received when attempting to plan changes.")
+	}
+	return plan, state, r
+}
+
+// environmentKey identifies the environment of the configuration key, under
+// which its owned resources are recorded.
+func environmentKey(ctx context.Context) (string, error) {
+	auth, err := client.GetAuth(ctx)
+	if err != nil {
+		return "", err
+	}
+	return auth.Team.Slug + "/" + auth.Environment.Slug, nil
+}
+
+// buildPlan compares the manifests with the resources that exist, and returns
+// the actions that make them match.
+func buildPlan(ctx context.Context, ms []manifest, state *applyState, r *refResolver, prune bool) (*resourcePlan, error) {
+	env, err := environmentKey(ctx)
+	if err != nil {
+		return nil, err
+	}
+	plan := &resourcePlan{Environment: env, Actions: []planAction{}}
+	owned := state.owned(env)
+
+	// List the resources in each dataset something is declared or owned in.
+	remote := map[string][]remoteResource{}
+	scopes := map[string]bool{}
+	for _, m := range ms {
+		scopes[m.Kind+"\x00"+m.Metadata.Dataset] = true
+	}
+	for _, o := range owned {
+		scopes[o.Kind+"\x00"+o.Dataset] = true
+	}
+	for _, k := range resourceKinds {
+		for _, ds := range sortedKeys(scopes) {
+			kind, dataset, _ := strings.Cut(ds, "\x00")
+			if kind != k.name || kindByName(kind) == nil {
+				continue
+			}
+			rs, err := k.list(ctx, r, dataset)
+			if err != nil {
+				return nil, fmt.Errorf("failed to list %s resources: %w", k.name, err)
+			}
+			remote[ds] = rs
+		}
+	}
+
+	sort.SliceStable(ms, func(i, j int) bool {
+		ki, kj := kindByName(ms[i].Kind), kindByName(ms[j].Kind)
+		if ki.rank != kj.rank {
+			return ki.rank < kj.rank
+		}
+		return ms[i].ref().String() < ms[j].ref().String()
+	})
+
+	var problems []string
+	claimed := map[string]bool{}
+	for _, m := range ms {
+		k := kindByName(m.Kind)
+		ref := m.ref()
+		scope := m.Kind + "\x00" + m.Metadata.Dataset
+		action := planAction{Kind: m.Kind, Dataset: m.Metadata.Dataset, Name: m.Metadata.Name, kind: k, declared: m.Spec, source: m.source}
+
+		if _, err := k.prepare(ctx, r, m.Metadata.Dataset, m.Spec, false); err != nil {
+			var apiErr *honeycomb.APIError
+			if errors.As(err, &apiErr) {
+				return nil, err
+			}
+			problems = append(problems, fmt.Sprintf("%s: %s: %v", m.source, ref, err))
+			continue
+		}
+
+		found := findRemote(k, remote[scope], owned[ref.String()].ID, m, func(id string) bool {
+			return claimed[scope+"\x00"+id]
+		})
+		if found == nil {
+			action.Action = planCreate
+			action.Spec = m.Spec
+			plan.Actions = append(plan.Actions, action)
+			continue
+		}
+		if claimed[scope+"\x00"+found.ID] {
+			problems = append(problems, fmt.Sprintf("%s: %s: matches %s %s, which is already declared", m.source, ref, k.name, found.ID))
+			continue
+		}
+		claimed[scope+"\x00"+found.ID] = true
+
+		if k.expand != nil {
+			if err := k.expand(ctx, r, m.Metadata.Dataset, found.Spec); err != nil {
+				return nil, err
+			}
+		}
+		declaredSpec := m.Spec
+		if k.normalize != nil {
+			declaredSpec = cloneSpec(m.Spec)
+			if err := k.normalize(ctx, r, declaredSpec); err != nil {
+				return nil, err
+			}
+			if err := k.normalize(ctx, r, found.Spec); err != nil {
+				return nil, err
+			}
+		}
+		action.ID = found.ID
+		action.Changes = diffSpec("", declaredSpec, found.Spec)
+		switch {
+		case owned[ref.String()].ID != found.ID:
+			action.Action = planAdopt
+		case len(action.Changes) > 0:
+			action.Action = planUpdate
+		default:
+			plan.Unchanged++
+			plan.inSync = append(plan.inSync, action)
+			continue
+		}
+		plan.Actions = append(plan.Actions, action)
+	}
+	if len(problems) > 0 {
+		return nil, &honeycomb.ValidationError{Resource: "manifests", Problems: problems}
+	}
+
+	// Owned resources that are no longer declared are deleted with --prune,
+	// unless they were renamed and are now declared under another name.
+	declared := map[string]bool{}
+	for _, m := range ms {
+		declared[m.ref().String()] = true
+	}
+	var deletes []planAction
+	for _, key := range sortedKeys(owned) {
+		o := owned[key]
+		if declared[key] {
+			continue
+		}
+		k := kindByName(o.Kind)
+		scope := o.Kind + "\x00" + o.Dataset
+		exists := false
+		for _, rr := range remote[scope] {
+			if rr.ID == o.ID {
+				exists = true
+			}
+		}
+		switch {
+		case k == nil || !exists:
+			plan.forget = append(plan.forget, key)
+		case claimed[scope+"\x00"+o.ID]:
+			plan.forget = append(plan.forget, key)
+		case prune && k.deletable:
+			deletes = append(deletes, planAction{
+				Action:  planDelete,
+				Kind:    o.Kind,
+				Dataset: o.Dataset,
+				Name:    o.Name,
+				ID:      o.ID,
+				kind:    k,
+			})
+		default:
+			plan.Orphaned = append(plan.Orphaned, o)
+		}
+	}
+	sort.SliceStable(deletes, func(i, j int) bool { return deletes[i].kind.rank > deletes[j].kind.rank })
+	plan.Actions = append(plan.Actions, deletes...)
+
+	return plan, nil
+}
+
+// findRemote returns the existing resource a manifest declares: the one it
+// owns if it still exists, or else the first with the same natural key that
+// is not already claimed by another manifest.
+func findRemote(k *resourceKind, rs []remoteResource, ownedID string, m manifest, claimed func(id string) bool) *remoteResource {
+	if ownedID != "" {
+		for i := range rs {
+			if rs[i].ID == ownedID {
+				return &rs[i]
+			}
+		}
+	}
+	if k.singleton {
+		if len(rs) > 0 {
+			return &rs[0]
+		}
+		return nil
+	}
+	key := k.key(m.Spec)
+	for i := range rs {
+		if k.key(rs[i].Spec) == key && !claimed(rs[i].ID) {
+			return &rs[i]
+		}
+	}
+	return nil
+}
+
+// applyPlan takes the actions of the plan in order, recording the resources
+// owned in the state after each.
+func applyPlan(ctx context.Context, plan *resourcePlan, state *applyState, r *refResolver) error {
+	owned := state.owned(plan.Environment)
+	for _, key := range plan.forget {
+		delete(owned, key)
+	}
+
+	for i := range plan.Actions {
+		a := &plan.Actions[i]
+		ref := a.ref()
+
+		switch a.Action {
+		case planDelete:
+			if err := a.kind.delete(ctx, a.Dataset, a.ID); err != nil {
+				return fmt.Errorf("failed to delete %s: %w", ref, err)
+			}
+			delete(owned, ref.String())
+
+		case planCreate, planUpdate, planAdopt:
+			if a.Action != planAdopt || len(a.Changes) > 0 {
+				v, err := a.kind.prepare(ctx, r, a.Dataset, a.declared, true)
+				if err != nil {
+					return fmt.Errorf("failed to prepare %s: %w", ref, err)
+				}
+				id, err := a.kind.send(ctx, a.Dataset, a.ID, v)
+				if err != nil {
+					return fmt.Errorf("failed to %s %s: %w", a.Action, ref, err)
+				}
+				if id == "" {
+					id = pendingID
+				}
+				a.ID = id
+				r.forget(a.kind, a.Dataset)
+			}
+			owned[ref.String()] = ownedResource{resourceRef: ref, ID: a.ID}
+		}
+
+		if err := state.save(); err != nil {
+			return err
+		}
+		if !dryRun {
+			fmt.Fprintf(os.Stderr, "%s %s (%s)\n", pastTense(a.Action), ref, a.ID)
+		}
+	}
+	return state.save()
+}
+
+func pastTense(action string) string {
+	switch action {
+	case planAdopt:
+		return "Adopted"
+	case planCreate:
+		return "Created"
+	case planUpdate:
+		return "Updated"
+	case planDelete:
+		return "Deleted"
+	}
+	return action
+}
+
+// writePlan writes a plan in a readable form.
+func writePlan(w io.Writer, plan *resourcePlan, prune bool) {
+	var counts = map[string]int{}
+	for _, a := range plan.Actions {
+		counts[a.Action]++
+		id := ""
+		if a.ID != "" {
+			id = " (" + a.ID + ")"
+		}
+		switch a.Action {
+		case planCreate:
+			fmt.Fprintf(w, "+ %s will be created\n", a.ref())
+			writeIndentedYAML(w, a.Spec)
+		case planUpdate:
+			fmt.Fprintf(w, "~ %s%s will be updated\n", a.ref(), id)
+		case planAdopt:
+			fmt.Fprintf(w, "= %s%s will be adopted\n", a.ref(), id)
+		case planDelete:
+			fmt.Fprintf(w, "- %s%s will be deleted\n", a.ref(), id)
+		}
+		for _, c := range a.Changes {
+			fmt.Fprintf(w, "    %s: %s → %s\n", c.Path, formatSpecValue(c.From), formatSpecValue(c.To))
+		}
+	}
+
+	if len(plan.Actions) > 0 {
+		fmt.Fprintln(w)
+	}
+	for _, o := range plan.Orphaned {
+		fmt.Fprintf(w, "! %s (%s) is no longer declared\n", o.resourceRef, o.ID)
+	}
+	if len(plan.Orphaned) > 0 && !prune {
+		fmt.Fprintln(w, "  Use --prune to delete the resources that are no longer declared.")
+		fmt.Fprintln(w)
+	}
+
+	if len(plan.Actions) == 0 {
+		fmt.Fprintf(w, "No changes in %s. %d resources are as declared.\n", plan.Environment, plan.Unchanged)
+		return
+	}
+	fmt.Fprintf(w, "Plan for %s: %d to create, %d to update, %d to adopt, %d to delete, %d unchanged.\n",
+		plan.Environment, counts[planCreate], counts[planUpdate], counts[planAdopt], counts[planDelete], plan.Unchanged)
+}
+
+func writeIndentedYAML(w io.Writer, v interface{}) {
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(yamlValue(v)); err != nil {
+		return
+	}
+	for _, line := range strings.Split(strings.TrimRight(buf.String(), "\n"), "\n") {
+		fmt.Fprintf(w, "    %s\n", line)
+	}
+}
+
+// formatSpecValue formats a value of a spec for a diff.
+func formatSpecValue(v interface{}) string {
+	switch v := v.(type) {
+	case nil:
+		return "(unset)"
+	case string:
+		return fmt.Sprintf("%q", v)
+	case json.Number:
+		return v.String()
+	case bool:
+		return fmt.Sprint(v)
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(b)
+}
+
+// diffSpec returns how current differs from declared, comparing only what is
+// declared. Absent values are taken to be equal to zero values, as the API
+// leaves those out, and secrets masked by export are not compared.
+func diffSpec(path string, declared, current interface{}) []specChange {
+	switch d := declared.(type) {
+	case string:
+		if d == honeycomb.RedactedValue {
+			return nil
+		}
+	case map[string]interface{}:
+		c, ok := current.(map[string]interface{})
+		if !ok && current != nil {
+			return []specChange{{Path: path, From: current, To: declared}}
+		}
+		var changes []specChange
+		keys := make([]string, 0, len(d))
+		for k := range d {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			p := k
+			if path != "" {
+				p = path + "." + k
+			}
+			changes = append(changes, diffSpec(p, d[k], c[k])...)
+		}
+		return changes
+
+	case []interface{}:
+		c, ok := current.([]interface{})
+		if (ok || current == nil) && len(c) == len(d) {
+			var changes []specChange
+			for i := range d {
+				changes = append(changes, diffSpec(fmt.Sprintf("%s[%d]", path, i), d[i], c[i])...)
+			}
+			return changes
+		}
+		return []specChange{{Path: path, From: current, To: declared}}
+	}
+
+	if specValuesEqual(declared, current) {
+		return nil
+	}
+	return []specChange{{Path: path, From: current, To: declared}}
+}
+
+// specValuesEqual reports whether two scalar values of specs are equal.
+func specValuesEqual(a, b interface{}) bool {
+	if isZeroSpecValue(a) && isZeroSpecValue(b) {
+		return true
+	}
+	an, aok := a.(json.Number)
+	bn, bok := b.(json.Number)
+	if aok && bok {
+		af, aerr := an.Float64()
+		bf, berr := bn.Float64()
+		return aerr == nil && berr == nil && af == bf
+	}
+	return reflect.DeepEqual(a, b)
+}
+
+func isZeroSpecValue(v interface{}) bool {
+	switch v := v.(type) {
+	case nil:
+		return true
+	case string:
+		return v == ""
+	case bool:
+		return !v
+	case json.Number:
+		f, err := v.Float64()
+		return err == nil && f == 0
+	case map[string]interface{}:
+		return len(v) == 0
+	case []interface{}:
+		return len(v) == 0
+	}
+	return false
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,493 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/adz-anz/honeybadger/honeycomb"
+)
+
+// fakeAPI serves canned JSON responses, keyed by method and path, in place of
+// the Honeycomb API, and counts the requests it receives. Anything else is
+// not found.
+type fakeAPI struct {
+	mu        sync.Mutex
+	responses map[string]string
+	requests  map[string]int
+}
+
+// newFakeAPI starts a fakeAPI and points the client at it for the rest of the
+// test. The environment is acme/prod, and holds no Recipients, Derived
+// Columns or SLOs unless responses says otherwise.
+func newFakeAPI(t *testing.T, responses map[string]string) *fakeAPI {
+	t.Helper()
+	api := &fakeAPI{
+		responses: map[string]string{
+			"GET /1/auth":                `{"team": {"slug": "acme"}, "environment": {"slug": "prod"}}`,
+			"GET /1/recipients":          `[]`,
+			"GET /1/derived_columns/api": `[]`,
+			"GET /1/slos/api":            `[]`,
+		},
+		requests: map[string]int{},
+	}
+	for k, v := range responses {
+		api.responses[k] = v
+	}
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := r.Method + " " + r.URL.Path
+		api.mu.Lock()
+		api.requests[key]++
+		body, ok := api.responses[key]
+		api.mu.Unlock()
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"error": "not found"}`))
+			return
+		}
+		w.Write([]byte(body))
+	}))
+	t.Cleanup(srv.Close)
+
+	c, err := honeycomb.NewClient(
+		honeycomb.WithAPIHost(srv.URL),
+		honeycomb.WithConfigKey("test-key"),
+		honeycomb.WithRetryPolicy(honeycomb.NoRetries),
+	)
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+	previous := client
+	client = c
+	t.Cleanup(func() { client = previous })
+	return api
+}
+
+// count returns the number of requests received for a method and path.
+func (api *fakeAPI) count(key string) int {
+	api.mu.Lock()
+	defer api.mu.Unlock()
+	return api.requests[key]
+}
+
+// testManifest returns a manifest of a kind, as read from a file.
+func testManifest(kind, dataset, name, spec string) manifest {
+	m := manifest{
+		Kind:     kind,
+		Metadata: manifestMetadata{Name: name, Dataset: dataset},
+		source:   "test.yaml",
+	}
+	dec := json.NewDecoder(strings.NewReader(spec))
+	dec.UseNumber()
+	if err := dec.Decode(&m.Spec); err != nil {
+		panic(err)
+	}
+	return m
+}
+
+// specValue decodes JSON as a spec value, with numbers as json.Number.
+func specValue(t *testing.T, s string) interface{} {
+	t.Helper()
+	var v interface{}
+	dec := json.NewDecoder(strings.NewReader(s))
+	dec.UseNumber()
+	if err := dec.Decode(&v); err != nil {
+		t.Fatalf("failed to decode %s: %v", s, err)
+	}
+	return v
+}
+
+func TestDiffSpec(t *testing.T) {
+	tests := []struct {
+		name     string
+		declared string
+		current  string
+		want     []string
+	}{
+		{
+			name:     "equal",
+			declared: `{"name": "a", "limit": 10, "disabled": true}`,
+			current:  `{"name": "a", "limit": 10, "disabled": true}`,
+		},
+		{
+			name:     "numbers compared by value",
+			declared: `{"limit": 1000, "value": 1.5}`,
+			current:  `{"limit": 1e3, "value": 1.50}`,
+		},
+		{
+			name:     "undeclared fields ignored",
+			declared: `{"name": "a"}`,
+			current:  `{"name": "a", "description": "set in the UI"}`,
+		},
+		{
+			name:     "absent equals zero",
+			declared: `{"disabled": false, "description": "", "limit": 0, "tags": [], "details": {}}`,
+			current:  `{}`,
+		},
+		{
+			name:     "null equals zero",
+			declared: `{"disabled": false, "limit": 0}`,
+			current:  `{"disabled": null, "limit": null}`,
+		},
+		{
+			name:     "declared zero differs from current value",
+			declared: `{"disabled": false, "limit": 0}`,
+			current:  `{"disabled": true, "limit": 5}`,
+			want:     []string{"disabled: true -> false", "limit: 5 -> 0"},
+		},
+		{
+			name:     "declared value differs from absent",
+			declared: `{"description": "new"}`,
+			current:  `{}`,
+			want:     []string{`description: (unset) -> "new"`},
+		},
+		{
+			name:     "nested fields",
+			declared: `{"threshold": {"op": ">", "value": 1}}`,
+			current:  `{"threshold": {"op": ">=", "value": 1}}`,
+			want:     []string{`threshold.op: ">=" -> ">"`},
+		},
+		{
+			name:     "array elements",
+			declared: `{"recipients": [{"id": "a"}, {"id": "b"}]}`,
+			current:  `{"recipients": [{"id": "a", "type": "email"}, {"id": "c"}]}`,
+			want:     []string{`recipients[1].id: "c" -> "b"`},
+		},
+		{
+			name:     "array lengths",
+			declared: `{"breakdowns": ["a", "b"]}`,
+			current:  `{"breakdowns": ["a"]}`,
+			want:     []string{`breakdowns: ["a"] -> ["a","b"]`},
+		},
+		{
+			name:     "object replaces a scalar",
+			declared: `{"query": {"time_range": 7200}}`,
+			current:  `{"query": "abc123"}`,
+			want:     []string{`query: "abc123" -> {"time_range":7200}`},
+		},
+		{
+			name:     "types compared",
+			declared: `{"value": "1"}`,
+			current:  `{"value": 1}`,
+			want:     []string{`value: 1 -> "1"`},
+		},
+		{
+			name:     "redacted values ignored",
+			declared: `{"details": {"webhook_url": "https://example.com", "webhook_secret": "[REDACTED]"}}`,
+			current:  `{"details": {"webhook_url": "https://example.com", "webhook_secret": "s3cret"}}`,
+		},
+		{
+			name:     "redacted values ignored when absent",
+			declared: `{"details": {"webhook_secret": "[REDACTED]"}}`,
+			current:  `{"details": {}}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, c := range diffSpec("", specValue(t, tt.declared), specValue(t, tt.current)) {
+				got = append(got, fmt.Sprintf("%s: %s -> %s", c.Path, formatSpecValue(c.From), formatSpecValue(c.To)))
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("diffSpec()\n got %q\nwant %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSpecValuesEqual(t *testing.T) {
+	tests := []struct {
+		a, b interface{}
+		want bool
+	}{
+		{nil, nil, true},
+		{"", nil, true},
+		{false, nil, true},
+		{json.Number("0"), nil, true},
+		{json.Number("0"), false, true},
+		{[]interface{}{}, nil, true},
+		{map[string]interface{}{}, nil, true},
+		{json.Number("1000"), json.Number("1e3"), true},
+		{json.Number("1"), json.Number("2"), false},
+		{json.Number("1"), "1", false},
+		{"a", "b", false},
+		{true, false, false},
+		{true, nil, false},
+	}
+
+	for _, tt := range tests {
+		if got := specValuesEqual(tt.a, tt.b); got != tt.want {
+			t.Errorf("specValuesEqual(%#v, %#v) = %t, want %t", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestFindRemote(t *testing.T) {
+	rs := []remoteResource{
+		{ID: "d1", Spec: map[string]interface{}{"alias": "ok"}},
+		{ID: "d2", Spec: map[string]interface{}{"alias": "ok"}},
+		{ID: "d3", Spec: map[string]interface{}{"alias": "renamed"}},
+	}
+	m := testManifest("DerivedColumn", "api", "ok", `{"alias": "ok"}`)
+
+	tests := []struct {
+		name    string
+		kind    *resourceKind
+		rs      []remoteResource
+		ownedID string
+		claimed []string
+		want    string
+	}{
+		{name: "by natural key", kind: derivedColumnKind, rs: rs, want: "d1"},
+		{name: "owned, though renamed", kind: derivedColumnKind, rs: rs, ownedID: "d3", want: "d3"},
+		{name: "owned, though deleted", kind: derivedColumnKind, rs: rs, ownedID: "d9", want: "d1"},
+		{name: "owned, though claimed", kind: derivedColumnKind, rs: rs, ownedID: "d1", claimed: []string{"d1"}, want: "d1"},
+		{name: "skips those claimed", kind: derivedColumnKind, rs: rs, claimed: []string{"d1"}, want: "d2"},
+		{name: "all claimed", kind: derivedColumnKind, rs: rs, claimed: []string{"d1", "d2"}},
+		{name: "no match", kind: derivedColumnKind, rs: rs[2:]},
+		{name: "singleton", kind: datasetKind, rs: []remoteResource{{ID: "api"}}, want: "api"},
+		{name: "singleton missing", kind: datasetKind},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			found := findRemote(tt.kind, tt.rs, tt.ownedID, m, func(id string) bool {
+				for _, c := range tt.claimed {
+					if c == id {
+						return true
+					}
+				}
+				return false
+			})
+			var got string
+			if found != nil {
+				got = found.ID
+			}
+			if got != tt.want {
+				t.Errorf("findRemote() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBuildPlan(t *testing.T) {
+	const (
+		okColumn      = `{"alias": "ok", "expression": "EQUALS($status_code, 200)"}`
+		okColumnList  = `[{"id": "d1", "alias": "ok", "expression": "EQUALS($status_code, 200)"}]`
+		emailList     = `[{"id": "r1", "type": "email", "details": {"email_address": "oncall@example.com"}}]`
+		webhookList   = `[{"id": "r2", "type": "webhook", "details": {"webhook_name": "deploys", "webhook_url": "https://example.com", "webhook_secret": "s3cret"}}]`
+		availableSLOs = `[{"id": "s1", "name": "Availability", "sli": {"alias": "ok"}, "target_per_million": 999000, "time_period_days": 30}]`
+	)
+
+	tests := []struct {
+		name      string
+		responses map[string]string
+		manifests []manifest
+		owned     []ownedResource
+		prune     bool
+
+		wantActions   []string
+		wantUnchanged int
+		wantOrphaned  []string
+		wantForget    []string
+		wantProblem   string
+	}{
+		{
+			name:        "create",
+			manifests:   []manifest{testManifest("DerivedColumn", "api", "ok", okColumn)},
+			wantActions: []string{"create DerivedColumn/api/ok"},
+		},
+		{
+			name:        "adopt",
+			responses:   map[string]string{"GET /1/derived_columns/api": okColumnList},
+			manifests:   []manifest{testManifest("DerivedColumn", "api", "ok", okColumn)},
+			wantActions: []string{"adopt DerivedColumn/api/ok d1"},
+		},
+		{
+			name:        "adopt with changes",
+			responses:   map[string]string{"GET /1/derived_columns/api": okColumnList},
+			manifests:   []manifest{testManifest("DerivedColumn", "api", "ok", `{"alias": "ok", "expression": "EQUALS($status_code, 204)"}`)},
+			wantActions: []string{"adopt DerivedColumn/api/ok d1 [expression]"},
+		},
+		{
+			name:          "unchanged",
+			responses:     map[string]string{"GET /1/derived_columns/api": okColumnList},
+			manifests:     []manifest{testManifest("DerivedColumn", "api", "ok", okColumn)},
+			owned:         []ownedResource{{resourceRef{"DerivedColumn", "api", "ok"}, "d1"}},
+			wantUnchanged: 1,
+		},
+		{
+			name:          "absent fields equal to zero",
+			responses:     map[string]string{"GET /1/derived_columns/api": okColumnList},
+			manifests:     []manifest{testManifest("DerivedColumn", "api", "ok", `{"alias": "ok", "expression": "EQUALS($status_code, 200)", "description": ""}`)},
+			owned:         []ownedResource{{resourceRef{"DerivedColumn", "api", "ok"}, "d1"}},
+			wantUnchanged: 1,
+		},
+		{
+			name:          "redacted secrets not compared",
+			responses:     map[string]string{"GET /1/recipients": webhookList},
+			manifests:     []manifest{testManifest("Recipient", "", "deploys", `{"type": "webhook", "details": {"webhook_name": "deploys", "webhook_url": "https://example.com", "webhook_secret": "[REDACTED]"}}`)},
+			owned:         []ownedResource{{resourceRef{"Recipient", "", "deploys"}, "r2"}},
+			wantUnchanged: 1,
+		},
+		{
+			name:        "update",
+			responses:   map[string]string{"GET /1/derived_columns/api": okColumnList},
+			manifests:   []manifest{testManifest("DerivedColumn", "api", "ok", `{"alias": "ok", "expression": "EQUALS($status_code, 204)", "description": "Successful requests"}`)},
+			owned:       []ownedResource{{resourceRef{"DerivedColumn", "api", "ok"}, "d1"}},
+			wantActions: []string{"update DerivedColumn/api/ok d1 [description expression]"},
+		},
+		{
+			name:        "owned resource renamed outside the manifests",
+			responses:   map[string]string{"GET /1/derived_columns/api": `[{"id": "d1", "alias": "renamed", "expression": "EQUALS($status_code, 200)"}]`},
+			manifests:   []manifest{testManifest("DerivedColumn", "api", "ok", okColumn)},
+			owned:       []ownedResource{{resourceRef{"DerivedColumn", "api", "ok"}, "d1"}},
+			wantActions: []string{"update DerivedColumn/api/ok d1 [alias]"},
+		},
+		{
+			name:      "natural key already claimed",
+			responses: map[string]string{"GET /1/derived_columns/api": okColumnList},
+			manifests: []manifest{
+				testManifest("DerivedColumn", "api", "a", okColumn),
+				testManifest("DerivedColumn", "api", "b", okColumn),
+			},
+			wantActions: []string{"adopt DerivedColumn/api/a d1", "create DerivedColumn/api/b"},
+		},
+		{
+			name:      "owned resource already claimed",
+			responses: map[string]string{"GET /1/derived_columns/api": okColumnList},
+			manifests: []manifest{
+				testManifest("DerivedColumn", "api", "a", okColumn),
+				testManifest("DerivedColumn", "api", "b", okColumn),
+			},
+			owned: []ownedResource{
+				{resourceRef{"DerivedColumn", "api", "a"}, "d1"},
+				{resourceRef{"DerivedColumn", "api", "b"}, "d1"},
+			},
+			wantProblem: "test.yaml: DerivedColumn/api/b: matches DerivedColumn d1, which is already declared",
+		},
+		{
+			name:        "invalid manifest",
+			manifests:   []manifest{testManifest("DerivedColumn", "api", "ok", `{"alias": "ok"}`)},
+			wantProblem: "test.yaml: DerivedColumn/api/ok: invalid derived column",
+		},
+		{
+			name:         "orphaned without prune",
+			responses:    map[string]string{"GET /1/derived_columns/api": okColumnList},
+			owned:        []ownedResource{{resourceRef{"DerivedColumn", "api", "ok"}, "d1"}},
+			wantOrphaned: []string{"DerivedColumn/api/ok"},
+		},
+		{
+			name:        "pruned",
+			responses:   map[string]string{"GET /1/derived_columns/api": okColumnList},
+			owned:       []ownedResource{{resourceRef{"DerivedColumn", "api", "ok"}, "d1"}},
+			prune:       true,
+			wantActions: []string{"delete DerivedColumn/api/ok d1"},
+		},
+		{
+			name:       "forgotten once deleted",
+			owned:      []ownedResource{{resourceRef{"DerivedColumn", "api", "ok"}, "d1"}},
+			prune:      true,
+			wantForget: []string{"DerivedColumn/api/ok"},
+		},
+		{
+			name:        "forgotten once declared under another name",
+			responses:   map[string]string{"GET /1/derived_columns/api": okColumnList},
+			manifests:   []manifest{testManifest("DerivedColumn", "api", "success", okColumn)},
+			owned:       []ownedResource{{resourceRef{"DerivedColumn", "api", "ok"}, "d1"}},
+			prune:       true,
+			wantActions: []string{"adopt DerivedColumn/api/success d1"},
+			wantForget:  []string{"DerivedColumn/api/ok"},
+		},
+		{
+			name: "created in rank order and deleted in reverse",
+			responses: map[string]string{
+				"GET /1/recipients":          emailList,
+				"GET /1/derived_columns/api": `[{"id": "d2", "alias": "old", "expression": "1"}]`,
+				"GET /1/slos/api":            availableSLOs,
+			},
+			manifests: []manifest{
+				testManifest("DerivedColumn", "api", "ok", okColumn),
+				testManifest("Recipient", "", "deploys", `{"type": "slack", "details": {"slack_channel": "#deploys"}}`),
+			},
+			owned: []ownedResource{
+				{resourceRef{"Recipient", "", "oncall"}, "r1"},
+				{resourceRef{"DerivedColumn", "api", "old"}, "d2"},
+				{resourceRef{"SLO", "api", "Availability"}, "s1"},
+			},
+			prune: true,
+			wantActions: []string{
+				"create Recipient/deploys",
+				"create DerivedColumn/api/ok",
+				"delete SLO/api/Availability s1",
+				"delete DerivedColumn/api/old d2",
+				"delete Recipient/oncall r1",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			newFakeAPI(t, tt.responses)
+			state := &applyState{Environments: map[string]map[string]ownedResource{}}
+			owned := state.owned("acme/prod")
+			for _, o := range tt.owned {
+				owned[o.resourceRef.String()] = o
+			}
+
+			plan, err := buildPlan(context.Background(), tt.manifests, state, newRefResolver(tt.manifests), tt.prune)
+			if tt.wantProblem != "" {
+				var validationErr *honeycomb.ValidationError
+				if !errors.As(err, &validationErr) || len(validationErr.Problems) != 1 ||
+					!strings.HasPrefix(validationErr.Problems[0], tt.wantProblem) {
+					t.Fatalf("buildPlan() = %v, want the problem %q", err, tt.wantProblem)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("buildPlan(): %v", err)
+			}
+
+			if plan.Environment != "acme/prod" {
+				t.Errorf("environment = %q, want acme/prod", plan.Environment)
+			}
+			var actions []string
+			for _, a := range plan.Actions {
+				s := strings.TrimSpace(a.Action + " " + a.ref().String() + " " + a.ID)
+				if len(a.Changes) > 0 {
+					var paths []string
+					for _, c := range a.Changes {
+						paths = append(paths, c.Path)
+					}
+					s += " [" + strings.Join(paths, " ") + "]"
+				}
+				actions = append(actions, s)
+			}
+			if !reflect.DeepEqual(actions, tt.wantActions) {
+				t.Errorf("actions\n got %q\nwant %q", actions, tt.wantActions)
+			}
+			if plan.Unchanged != tt.wantUnchanged || len(plan.inSync) != tt.wantUnchanged {
+				t.Errorf("unchanged = %d with %d in sync, want %d", plan.Unchanged, len(plan.inSync), tt.wantUnchanged)
+			}
+			var orphaned []string
+			for _, o := range plan.Orphaned {
+				orphaned = append(orphaned, o.resourceRef.String())
+			}
+			if !reflect.DeepEqual(orphaned, tt.wantOrphaned) {
+				t.Errorf("orphaned = %q, want %q", orphaned, tt.wantOrphaned)
+			}
+			if !reflect.DeepEqual(plan.forget, tt.wantForget) {
+				t.Errorf("forget = %q, want %q", plan.forget, tt.wantForget)
+			}
+		})
+	}
+}
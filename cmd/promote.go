@@ -0,0 +1,314 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+
+	"github.com/adz-anz/honeybadger/honeycomb"
+)
+
+// promoteSource is a resource named on the command line of promote, as
+// <kind>/<dataset>/<id>, or <kind>/<id> for those that do not belong to a
+// dataset.
+type promoteSource struct {
+	kind    *resourceKind
+	dataset string
+	id      string
+}
+
+// promotedResource is a resource created or updated by promote.
+type promotedResource struct {
+	Kind     string `json:"kind"`
+	Dataset  string `json:"dataset,omitempty"`
+	Name     string `json:"name"`
+	Action   string `json:"action"`
+	SourceID string `json:"source_id"`
+	ID       string `json:"id"`
+}
+
+// CUSTOM
+// Copy resources from the environment of one profile to that of another.
+func newPromoteCmd() *cobra.Command {
+	var (
+		pFromProfile string
+		pToProfile   string
+		pDatasetMap  string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "promote <kind>/<id>...",
+		Short: "Copy resources from one environment to another.",
+		Long: "Read resources with the configuration key of one profile, and create or update\n" +
+			"them with that of another, such as to promote Boards and Triggers built in a\n" +
+			"staging environment to production.\n" +
+			"\n" +
+			"Resources are named as <kind>/<dataset>/<id>, or <kind>/<id> for Boards and\n" +
+			"Recipients, and <kind>/<dataset> for Datasets and Dataset Definitions. The kinds\n" +
+			"are those of manifests, in any case, such as board, trigger, slo or burnalert.\n" +
+			"\n" +
+			"References that differ between environments are rewritten:\n" +
+			"\n" +
+			"  - Datasets are renamed as given by --dataset-map, a YAML or JSON file\n" +
+			"    mapping the slugs of the source environment to those of the target.\n" +
+			"  - The Queries of Boards, Query Annotations and Triggers are created\n" +
+			"    again, rather than referred to by ID.\n" +
+			"  - Recipients are found by their type and target, and must exist.\n" +
+			"  - Burn Alerts are attached to the SLO with the same name.\n" +
+			"\n" +
+			"A resource with the same name in the target environment is updated, and any\n" +
+			"other is created. The changes are shown on stderr before they are made. With\n" +
+			"--dry-run they are previewed, and the requests printed instead of sent.",
+		Example: "  honeybadger promote --from-profile staging --to-profile prod board/2Gg7Cw8cH4d trigger/api/Hx3cTZkPfqC --dry-run\n" +
+			"  honeybadger promote --from-profile staging --to-profile prod --dataset-map datasets.yaml slo/api-staging/5uQPwv8NpBD",
+		Annotations: map[string]string{
+			annotationNoConfigKey: "",
+			annotationLiveReads:   "",
+		},
+		Args: func(cmd *cobra.Command, args []string) error {
+			if len(args) == 0 {
+				return fmt.Errorf("requires at least 1 resource, as <kind>/<id>")
+			}
+			for _, arg := range args {
+				if _, err := parsePromoteSource(arg); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			if pFromProfile == pToProfile {
+				return fmt.Errorf("--from-profile and --to-profile must be different profiles")
+			}
+			return nil
+		},
+		Run: func(cmd *cobra.Command, args []string) {
+			ctx := cmd.Context()
+			fields := log.Fields{
+				"_function":    "newPromoteCmd",
+				"from_profile": pFromProfile,
+				"to_profile":   pToProfile,
+			}
+
+			datasetMap := map[string]string{}
+			if pDatasetMap != "" {
+				if err := readResourceFile(pDatasetMap, &datasetMap); err != nil {
+					fatal(fields, err, "Error received when attempting to read the dataset mapping.")
+				}
+			}
+
+			from, err := profileClient(cmd, pFromProfile)
+			if err != nil {
+				fatal(fields, err, "Error received when attempting to read the source profile.")
+			}
+			to, err := profileClient(cmd, pToProfile)
+			if err != nil {
+				fatal(fields, err, "Error received when attempting to read the target profile.")
+			}
+
+			// Resources are read from one environment and then written to
+			// the other, through the client the resource kinds use.
+			client = from
+			ms, sourceIDs, err := readPromoteSources(ctx, args, datasetMap)
+			if err != nil {
+				fatal(fields, err, "Error received when attempting to read resources to promote.")
+			}
+
+			client = to
+			state := &applyState{Environments: map[string]map[string]ownedResource{}}
+			r := newRefResolver(ms)
+			plan, err := buildPlan(ctx, ms, state, r, false)
+			if err == nil {
+				err = checkPromoteRecipients(ctx, r, ms)
+			}
+			if err != nil {
+				fatal(fields, err, "Error received when attempting to plan the promotion.")
+			}
+			updateExisting(plan)
+			writePlan(os.Stderr, plan, false)
+
+			if err := applyPlan(ctx, plan, state, r); err != nil {
+				fatal(fields, err, "Error received when attempting to promote resources.")
+			}
+
+			promoted := []promotedResource{}
+			for _, a := range append(plan.Actions, plan.inSync...) {
+				action := a.Action
+				if action == "" {
+					action = "unchanged"
+				}
+				promoted = append(promoted, promotedResource{
+					Kind:     a.Kind,
+					Dataset:  a.Dataset,
+					Name:     a.Name,
+					Action:   action,
+					SourceID: sourceIDs[a.ref().String()],
+					ID:       a.ID,
+				})
+			}
+			printResponse(promoted)
+		},
+	}
+
+	cmd.Flags().StringVar(&pFromProfile, "from-profile", "", "The profile to read the resources with.")
+	cmd.Flags().StringVar(&pToProfile, "to-profile", "", "The profile to create or update the resources with.")
+	cmd.Flags().StringVar(&pDatasetMap, "dataset-map", "",
+		"A YAML or JSON file mapping the dataset slugs of the source environment to those of the target.")
+	cmd.MarkFlagRequired("from-profile")
+	cmd.MarkFlagRequired("to-profile")
+
+	return cmd
+}
+
+// parsePromoteSource parses a resource named on the command line of promote.
+func parsePromoteSource(arg string) (promoteSource, error) {
+	parts := strings.Split(arg, "/")
+	k := kindByLooseName(parts[0])
+	if k == nil {
+		return promoteSource{}, fmt.Errorf("unknown kind in %q, must be one of %s", arg, strings.Join(kindNames(), ", "))
+	}
+
+	switch {
+	case k.singleton && len(parts) == 2 && parts[1] != "":
+		return promoteSource{kind: k, dataset: parts[1], id: parts[1]}, nil
+	case k.singleton:
+		return promoteSource{}, fmt.Errorf("%q must be given as %s/<dataset>", arg, parts[0])
+	case k.datasetScoped && len(parts) == 3 && parts[1] != "" && parts[2] != "":
+		return promoteSource{kind: k, dataset: parts[1], id: parts[2]}, nil
+	case k.datasetScoped:
+		return promoteSource{}, fmt.Errorf("%q must be given as %s/<dataset>/<id>", arg, parts[0])
+	case len(parts) == 2 && parts[1] != "":
+		return promoteSource{kind: k, id: parts[1]}, nil
+	}
+	return promoteSource{}, fmt.Errorf("%q must be given as %s/<id>", arg, parts[0])
+}
+
+// kindByLooseName returns the kind with the given name, ignoring case, dashes
+// and underscores, and a plural s.
+func kindByLooseName(name string) *resourceKind {
+	loose := strings.NewReplacer("-", "", "_", "").Replace(strings.ToLower(name))
+	for _, candidate := range []string{loose, strings.TrimSuffix(loose, "s")} {
+		for _, k := range resourceKinds {
+			if strings.ToLower(k.name) == candidate {
+				return k
+			}
+		}
+	}
+	return nil
+}
+
+// readPromoteSources reads the named resources as manifests for the target
+// environment, renaming their datasets as given by datasetMap. The IDs of the
+// resources are returned by the references of their manifests.
+func readPromoteSources(ctx context.Context, args []string, datasetMap map[string]string) ([]manifest, map[string]string, error) {
+	r := newRefResolver(nil)
+	mapDataset := func(ds string) string {
+		if to, ok := datasetMap[ds]; ok {
+			return to
+		}
+		return ds
+	}
+
+	var ms []manifest
+	for _, arg := range args {
+		src, _ := parsePromoteSource(arg)
+		rs, err := src.kind.list(ctx, r, src.dataset)
+		if err != nil {
+			return nil, nil, err
+		}
+		var found *remoteResource
+		for i := range rs {
+			if rs[i].ID == src.id {
+				found = &rs[i]
+			}
+		}
+		if found == nil {
+			return nil, nil, fmt.Errorf("%s: %w", arg, honeycomb.ErrNotFound)
+		}
+		if err := exportSpec(ctx, r, src.kind, src.dataset, found.Spec); err != nil {
+			return nil, nil, err
+		}
+
+		// Board Queries are created in the mapped dataset.
+		queries, _ := found.Spec["queries"].([]interface{})
+		for _, item := range queries {
+			if q, ok := item.(map[string]interface{}); ok {
+				if ds, ok := q["dataset"].(string); ok {
+					q["dataset"] = mapDataset(ds)
+				}
+			}
+		}
+
+		m := manifest{
+			Kind:   src.kind.name,
+			Spec:   found.Spec,
+			source: arg,
+		}
+		if src.kind.datasetScoped {
+			m.Metadata.Dataset = mapDataset(src.dataset)
+		}
+		if src.kind == datasetKind {
+			// The name of a Dataset is its slug in the source environment.
+			delete(m.Spec, "name")
+		}
+		ms = append(ms, m)
+	}
+
+	if err := validateManifests(ms); err != nil {
+		return nil, nil, err
+	}
+	sourceIDs := map[string]string{}
+	for i, arg := range args {
+		src, _ := parsePromoteSource(arg)
+		sourceIDs[ms[i].ref().String()] = src.id
+	}
+	return ms, sourceIDs, nil
+}
+
+// updateExisting turns the adoptions in a plan built without state into
+// updates, for commands that own nothing in the environment but update what
+// they find there. Those without changes are left alone.
+func updateExisting(plan *resourcePlan) {
+	actions := plan.Actions[:0]
+	for _, a := range plan.Actions {
+		switch {
+		case a.Action != planAdopt:
+		case len(a.Changes) > 0:
+			a.Action = planUpdate
+		default:
+			a.Action = ""
+			plan.Unchanged++
+			plan.inSync = append(plan.inSync, a)
+			continue
+		}
+		actions = append(actions, a)
+	}
+	plan.Actions = actions
+}
+
+// checkPromoteRecipients ensures that the Recipients the promoted resources
+// notify exist in the target environment.
+func checkPromoteRecipients(ctx context.Context, r *refResolver, ms []manifest) error {
+	var problems []string
+	for _, m := range ms {
+		spec := cloneSpec(m.Spec)
+		if err := r.resolveRecipients(ctx, spec); err != nil {
+			return err
+		}
+		items, _ := spec["recipients"].([]interface{})
+		for _, item := range items {
+			nr, _ := item.(map[string]interface{})
+			if nr["id"] == nil {
+				problems = append(problems, fmt.Sprintf("%s: recipient %v %v does not exist in the target environment", m.source, nr["type"], nr["target"]))
+			}
+		}
+	}
+	if len(problems) > 0 {
+		return &honeycomb.ValidationError{Resource: "recipients", Problems: problems}
+	}
+	return nil
+}
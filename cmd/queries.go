@@ -1,183 +1,314 @@
 package cmd
 
 import (
-	"encoding/json"
-	"net/http"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
 
 	"github.com/spf13/cobra"
 
+	"github.com/adz-anz/honeybadger/honeycomb"
+
 	log "github.com/sirupsen/logrus"
 )
 
-// ----- QUERY STRUCTS -----
+// readQuery reads a Query Specification from a JSON or YAML file, exiting if
+// it cannot be read or is not valid.
+func readQuery(function, path string) *honeycomb.Query {
+	var q honeycomb.Query
+	err := readResourceFile(path, &q)
+	if err == nil {
+		err = q.Validate()
+	}
+	if err != nil {
+		fatal(log.Fields{
+			"_function": function,
+			"from_file": path,
+		}, err, "Error received when attempting to read a query.")
+	}
+	return &q
+}
 
-type queryCalculation struct {
-	// Enum:
-	//		"COUNT" "CONCURRENCY" "SUM" "AVG" "COUNT_DISTINCT" "HEATMAP" "MAX"
-	//		"MIN" "P001" "P01" "P05" "P10" "P25" "P50" "P75" "P90" "P95" "P99"
-	//		"P999" "RATE_AVG" "RATE_SUM" "RATE_MAX"
-	Op string `json:"op,omitempty"`
+// parseQuery parses a Query written in the query language, exiting if it is
+// not valid.
+func parseQuery(function string, args []string) *honeycomb.Query {
+	text := strings.Join(args, " ")
+	q, err := honeycomb.ParseQuery(text)
+	if err != nil {
+		fatal(log.Fields{
+			"_function": function,
+			"query":     text,
+		}, err, "Error received when attempting to parse a query.")
+	}
+	return q
+}
 
-	// The name of the column.
-	Column string `json:"column,omitempty"`
+// queryTextOrFlags returns a cobra.PositionalArgs requiring that a Query is
+// given either as query language arguments or by exactly one of flags.
+func queryTextOrFlags(flags ...string) cobra.PositionalArgs {
+	return func(cmd *cobra.Command, args []string) error {
+		var changed []string
+		for _, f := range flags {
+			if cmd.Flags().Changed(f) {
+				changed = append(changed, "--"+f)
+			}
+		}
+
+		switch {
+		case len(args) > 0 && len(changed) > 0:
+			return fmt.Errorf("a query cannot be given both as arguments and with %s", strings.Join(changed, " or "))
+		case len(args) == 0 && len(changed) == 0:
+			return fmt.Errorf("a query must be given as arguments or with --%s", strings.Join(flags, " or --"))
+		default:
+			return nil
+		}
+	}
 }
 
-type queryFilter struct {
-	// Enum:
-	//		"=" "!=" ">" ">=" "<" "<=" "starts-with" "does-not-start-with"
-	//		"exists" "does-not-exist" "contains" "does-not-contain" "in"
-	//		"not-in"
-	Op string `json:"op,omitempty"`
+// Queries
+// https://docs.honeycomb.io/api/tag/Queries
+func newQueriesCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "queries",
+		Aliases: []string{"q"},
+		Short:   "Manage and run Queries",
+		Long: "Queries describe the events to consider and the calculations to perform over them.\n" +
+			"A Query is created once and can then be run any number of times, each run creating\n" +
+			"a Query Result that is processed asynchronously.\n" +
+			"\n" +
+			"These commands allow you to create and get Queries, and to run them, either in a\n" +
+			"single step with run, or one step at a time with create-query-result and\n" +
+			"get-query-result.\n" +
+			"\n" +
+			"Queries can be given as JSON or YAML Query Specifications, or written in a compact\n" +
+			"query language such as:\n" +
+			"\n" +
+			"  P99(duration_ms), COUNT WHERE status_code >= 500 GROUP BY route LAST 2h\n" +
+			"\n" +
+			"See explain for the full syntax.",
+	}
+
+	cmd.PersistentFlags().StringVarP(&targetDataset, "dataset", "d", honeycomb.EnvironmentWide,
+		"The dataset slug or use __all__ (or omit) for endpoints that support environment-wide operations.")
 
-	// The name of the column.
-	Column string `json:"column,omitempty"`
+	cmd.AddCommand(
+		newQueriesCreateCmd(),
+		newQueriesGetCmd(),
+		newQueriesRunCmd(),
+		newQueriesExplainCmd(),
+		newQueryResultCreateCmd(),
+		newQueryResultGetCmd(),
+	)
 
-	// The value to compare against.
-	Value interface{} `json:"value,omitempty"`
+	return cmd
 }
 
-type queryOrder struct {
-	// The name of the column.
-	Column string `json:"column,omitempty"`
-
-	// Enum:
-	//		"COUNT" "CONCURRENCY" "SUM" "AVG" "COUNT_DISTINCT" "HEATMAP" "MAX"
-	//		"MIN" "P001" "P01" "P05" "P10" "P25" "P50" "P75" "P90" "P95" "P99"
-	//		"P999" "RATE_AVG" "RATE_SUM" "RATE_MAX"
-	Op string `json:"op,omitempty"`
+// Create a Query
+// https://docs.honeycomb.io/api/tag/Queries#operation/createQuery
+func newQueriesCreateCmd() *cobra.Command {
+	var (
+		qFromFile string
+	)
 
-	Order string `json:"order,omitempty"`
-}
+	cmd := &cobra.Command{
+		Use:     "create [query]",
+		Aliases: []string{"add", "new"},
+		Short:   "Create a Query in the specified dataset.",
+		Long: "Create a Query in the specified dataset from a Query Specification or the query\n" +
+			"language, and print it with its ID. Queries cannot be updated or deleted once\n" +
+			"created.",
+		Example: "  honeybadger queries create -d my-service -f slow-requests.yaml\n" +
+			"  honeybadger queries create -d my-service 'P99(duration_ms) GROUP BY route LAST 1d'",
+		Args: queryTextOrFlags("from-file"),
+		Run: func(cmd *cobra.Command, args []string) {
+			var q *honeycomb.Query
+			if qFromFile != "" {
+				q = readQuery("newQueriesCreateCmd", qFromFile)
+			} else {
+				q = parseQuery("newQueriesCreateCmd", args)
+			}
 
-type queryHaving struct {
-	// Enum:
-	//		"COUNT" "CONCURRENCY" "SUM" "AVG" "COUNT_DISTINCT" "HEATMAP" "MAX"
-	//		"MIN" "P001" "P01" "P05" "P10" "P25" "P50" "P75" "P90" "P95" "P99"
-	//		"P999" "RATE_AVG" "RATE_SUM" "RATE_MAX"
-	CalculateOp string `json:"calculate_op,omitempty"`
+			created, err := client.CreateQuery(cmd.Context(), targetDataset, q)
+			if err != nil {
+				fatal(log.Fields{
+					"_function": "newQueriesCreateCmd",
+					"dataset":   targetDataset,
+					"query":     q,
+				}, err, "Error received when attempting to create a new query.")
+			}
 
-	// The name of the column to filter against.
-	Column string `json:"column,omitempty"`
+			printResponse(created)
+		},
+	}
 
-	// Enum:
-	//		 "=" "!=" ">" ">=" "<" "<="
-	Op string `json:"op,omitempty"`
+	cmd.Flags().StringVarP(&qFromFile, "from-file", "f", "",
+		"A JSON or YAML file holding the Query Specification, or - for stdin.")
 
-	Value int `json:"value,omitempty"`
+	return cmd
 }
 
-type query struct {
-	// The ID of a query returned from the Queries endpoint.
-	ID string `json:"id,omitempty"`
-
-	// The columns by which to break events down into groups.
-	Breakdowns []string `json:"breakdowns,omitempty"`
-
-	// The calculations to return as a time series and summary table.
-	Calculations []queryCalculation `json:"calculations,omitempty"`
-
-	// The filters with which to restrict the considered events.
-	Filters []queryFilter `json:"filters,omitempty"`
-
-	// set to "OR" to match ANY filter in the filter list.
-	FilterCombination string `json:"filter_combination,omitempty"`
-
-	// The time resolution of the query's graph, in seconds. Given a query time
-	// range T, valid values (T/1000...T/10).
-	Granularity int `json:"granularity,omitempty"`
-
-	// The terms on which to order the query results. Each term must appear in
-	// either the breakdowns field or the calculations field.
-	Orders []queryOrder `json:"orders,omitempty"`
-
-	// The maximum number of unique groups returned in 'results'. Aggregating
-	// many unique groups across a large time range is computationally
-	// expensive, and too high a limit with too many unique groups may cause
-	// queries to fail completey. Limiting the results to only the needed
-	// values can significantly speed up queries. The normal allowed maximum
-	// value when creating a query is 1_000. When running 'disable_series'
-	// queries, this can be overridden to be up to 10_000, so the maximum value
-	// returned from the API when fetching a query may be up to 10_000.
-	Limit int `json:"limit,omitempty"`
-
-	// Absolute start time of query, in seconds since UNIX epoch.
-	// Must be <= end_time.
-	StartTime int `json:"start_time,omitempty"`
-
-	// Absolute end time of query, in seconds since UNIX epoch.
-	EndTime int `json:"end_time,omitempty"`
-
-	// Time range of query in seconds. Can be used with either start_time
-	// (seconds after start_time), end_time (seconds before end_time), or
-	// without either (seconds before now).
-	TimeRange int `json:"time_range,omitempty"`
-
-	// The Having clause allows you to filter on the results table. This
-	// operation is distinct from the Where clause, which filters the
-	// underlying events. Order By allows you to order the results, and Having
-	// filters them.
-	Havings []queryHaving `json:"havings,omitempty"`
-}
+// Get a Query
+// https://docs.honeycomb.io/api/tag/Queries#operation/getQuery
+func newQueriesGetCmd() *cobra.Command {
+	var (
+		qID string
+	)
 
-// ----- QUERY RESULT STRUCTS -----
+	cmd := &cobra.Command{
+		Use:     "get",
+		Aliases: []string{},
+		Short:   "Get a single Query by ID.",
+		Long:    "Get a single Query by ID.",
+		Run: func(cmd *cobra.Command, args []string) {
+			q, err := client.GetQuery(cmd.Context(), targetDataset, qID)
+			if err != nil {
+				fatal(log.Fields{
+					"_function": "newQueriesGetCmd",
+					"dataset":   targetDataset,
+					"query_id":  qID,
+				}, err, "Error received when attempting to get a query.")
+			}
 
-type queryResultCreateRequest struct {
-	// The ID of a query returned from the Queries endpoint.
-	QueryID string `json:"query_id,omitempty"`
+			printResponse(q)
+		},
+	}
 
-	// If true, will disable calculation and return of the full time-series
-	// data, usually included in the 'series' response field, instead only
-	// returning the summarized 'results'.
-	DisableSeries bool `json:"disable_series,omitempty"`
+	cmd.Flags().StringVarP(&qID, "id", "i", "", "The unique identifier (ID) of a Query.")
+	cmd.MarkFlagRequired("id")
 
-	// If 'disable_series' is true, then a limit may optionally be given, which
-	// will override the limit normally associated with the query. Unlike normal
-	// query results which are limited to 1_000 results, 'disable_series'
-	// results may have a limit of up to 10_000. If 'disable_series' is false,
-	// then this field will be ignored.
-	Limit int `json:"limit,omitempty"`
+	return cmd
 }
 
-type queryResultLinks struct {
-	QueryURL string `json:"query_url,omitempty"`
+// CUSTOM
+// Create a Query, create a Query Result for it, and wait for it to complete
+func newQueriesRunCmd() *cobra.Command {
+	var (
+		qFromFile      string
+		qID            string
+		qDisableSeries bool
+		qLimit         int
+		qTimeout       time.Duration
+		qView          queryResultViewFlags
+		qExport        queryResultExportFlags
+	)
 
-	GraphImageURL string `json:"graph_image_url,omitempty"`
-}
+	cmd := &cobra.Command{
+		Use:   "run [query]",
+		Short: "Run a Query and wait for its results.",
+		Long: "Run a Query and wait for its results.\n" +
+			"\n" +
+			"The Query is created from the query language arguments or --from-file, or an\n" +
+			"existing Query is given with --query-id. A Query Result is then created for it\n" +
+			"and polled, with backoff, until it is complete or --timeout passes.\n" +
+			"\n" +
+			"With --view, the data of the result is printed as a table of the results, a chart\n" +
+			"of the time series of each group, or a sparkline for each group. With --export,\n" +
+			"the results or series are also written to a CSV, TSV or NDJSON file.\n" +
+			"\n" +
+			"Only the last 7 days of data can be queried.",
+		Example: "  honeybadger queries run -d my-service -f slow-requests.yaml\n" +
+			"  honeybadger queries run -d my-service -q abc123 --timeout 2m\n" +
+			"  honeybadger queries run -d my-service 'COUNT WHERE status_code >= 500 GROUP BY route LAST 2h'",
+		Args: queryTextOrFlags("from-file", "query-id"),
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			if err := qView.validate(); err != nil {
+				return err
+			}
+			return qExport.validate()
+		},
+		Run: func(cmd *cobra.Command, args []string) {
+			q := &honeycomb.Query{ID: qID}
+			switch {
+			case qFromFile != "":
+				q = readQuery("newQueriesRunCmd", qFromFile)
+				q.ID = ""
+			case len(args) > 0:
+				q = parseQuery("newQueriesRunCmd", args)
+			}
 
-type queryResultSeries struct {
-	Time string `json:"time,omitempty"`
+			ctx, cancel := context.WithTimeout(cmd.Context(), qTimeout)
+			defer cancel()
 
-	Data map[string]interface{} `json:"data,omitempty"`
-}
+			qr, err := client.RunQuery(ctx, targetDataset, q, &honeycomb.QueryResultCreateRequest{
+				DisableSeries: qDisableSeries,
+				Limit:         qLimit,
+			})
+			if errors.Is(err, context.DeadlineExceeded) {
+				err = fmt.Errorf("the query did not complete within %s", qTimeout)
+			}
+			if err != nil {
+				fatal(log.Fields{
+					"_function": "newQueriesRunCmd",
+					"dataset":   targetDataset,
+					"query":     q,
+				}, err, "Error received when attempting to run a query.")
+			}
 
-type queryResultResults struct {
-	Data map[string]interface{} `json:"data,omitempty"`
-}
+			qExport.write("newQueriesRunCmd", qr)
+			if !qExport.toStdout() {
+				qView.print("newQueriesRunCmd", qr)
+			}
+		},
+	}
 
-type queryResultData struct {
-	Series []queryResultSeries `json:"series,omitempty"`
+	cmd.Flags().StringVarP(&qFromFile, "from-file", "f", "",
+		"A JSON or YAML file holding the Query Specification, or - for stdin.")
+	cmd.Flags().StringVarP(&qID, "query-id", "q", "",
+		"The ID of an existing Query to run.")
+	cmd.MarkFlagsMutuallyExclusive("from-file", "query-id")
+	cmd.Flags().BoolVar(&qDisableSeries, "disable-series", false,
+		"Only return the summary results, not the time series.")
+	cmd.Flags().IntVar(&qLimit, "limit", 0,
+		"With --disable-series, override the limit of the Query, up to 10000.")
+	cmd.Flags().DurationVar(&qTimeout, "timeout", time.Minute,
+		"How long to wait for the results before giving up.")
+	qView.register(cmd)
+	qExport.register(cmd)
 
-	Results []queryResultResults `json:"results,omitempty"`
+	return cmd
 }
 
-type queryResult struct {
-	// The query that this result is for.
-	Query query `json:"query,omitempty"`
-
-	// The unique identifier (ID) of a Query Result
-	ID string `json:"id,omitempty"`
-
-	// Indicates if the query results are available yet or not. For example, is
-	// the query still being processed or complete?
-	Complete bool `json:"complete"`
-
-	// Data
-	Data queryResultData `json:"data,omitempty"`
+// CUSTOM
+// Parse a Query written in the query language and print its Query Specification
+func newQueriesExplainCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "explain <query>",
+		Short: "Print the Query Specification for a query written in the query language.",
+		Long: "Print the Query Specification for a query written in the query language, without\n" +
+			"sending it. The query may be given as one argument or several.\n" +
+			"\n" +
+			"A query starts with its calculations, followed by any of these clauses, in any\n" +
+			"order:\n" +
+			"\n" +
+			"  WHERE <column> <op> [value] [AND|OR ...]  filter the events\n" +
+			"  GROUP BY <column>, ...                    break the events down\n" +
+			"  ORDER BY <column|calculation> [ASC|DESC]  order the results\n" +
+			"  LIMIT <n>                                 limit the number of results\n" +
+			"  HAVING <calculation> <op> <number>, ...   filter the results\n" +
+			"  LAST <duration>                           e.g. 30m, 2h, 7d\n" +
+			"  BETWEEN <start> AND <end>                 RFC3339 times or epoch seconds\n" +
+			"  GRANULARITY <duration>                    the resolution of the time series\n" +
+			"\n" +
+			"Calculations are written as COUNT, CONCURRENCY or OP(column), e.g. P99(duration_ms).\n" +
+			"Filters combine with either AND or OR, not both. The in and not-in ops take a list\n" +
+			"of values in parentheses, and exists and does-not-exist take none. Values that\n" +
+			"contain spaces or punctuation can be quoted, as can columns, with backticks.",
+		Example: "  honeybadger queries explain 'P99(duration_ms), COUNT WHERE service.name = api AND status_code >= 500\n" +
+			"    GROUP BY route ORDER BY P99(duration_ms) DESC LIMIT 20 HAVING COUNT > 10 LAST 2h'",
+		Args:        cobra.MinimumNArgs(1),
+		Annotations: map[string]string{annotationNoConfigKey: ""},
+		Run: func(cmd *cobra.Command, args []string) {
+			printResponse(parseQuery("newQueriesExplainCmd", args))
+		},
+	}
 
-	// Links
-	Links queryResultLinks `json:"links,omitempty"`
+	return cmd
 }
 
+// Create a Query Result
+// https://docs.honeycomb.io/api/tag/Query-Data#operation/createQueryResult
 func newQueryResultCreateCmd() *cobra.Command {
 	var (
 		queryID string
@@ -193,34 +324,19 @@ func newQueryResultCreateCmd() *cobra.Command {
 			"\n" +
 			"Only the last 7 days of data can be queried. Any queries with a `start_time`, `end_time`, or `time_range` older than last 7 days will result in a `400` error response.",
 		Run: func(cmd *cobra.Command, args []string) {
-			var qr = queryResultCreateRequest{
+			var qr = honeycomb.QueryResultCreateRequest{
 				QueryID: queryID,
 			}
 
-			var bodyMarshal, err = json.Marshal(qr)
+			resp, err := client.CreateQueryResult(cmd.Context(), targetDataset, &qr)
 			if err != nil {
-				log.WithFields(log.Fields{
+				fatal(log.Fields{
 					"_function": "newQueryResultCreateCmd",
-					"err":       err,
 					"query":     qr,
-				}).Fatal("Error received when attempting to marshal a query result create request.")
+				}, err, "Error received when attempting to create a query result.")
 			}
 
-			var p = payload{
-				Method:   http.MethodPost,
-				Path:     "/1/query_results/" + targetDataset,
-				Body:     bodyMarshal,
-				Response: &queryResult{},
-			}
-
-			err = p.GetResponse(true)
-			if err != nil {
-				log.WithFields(log.Fields{
-					"_function": "newQueryResultCreateCmd",
-					"err":       err,
-					"payload":   p,
-				}).Fatal("Error received when attempting to create a query result.")
-			}
+			printResponse(resp)
 		},
 	}
 
@@ -231,9 +347,13 @@ func newQueryResultCreateCmd() *cobra.Command {
 	return cmd
 }
 
+// Get a Query Result
+// https://docs.honeycomb.io/api/tag/Query-Data#operation/getQueryResult
 func newQueryResultGetCmd() *cobra.Command {
 	var (
 		queryResultID string
+		qView         queryResultViewFlags
+		qExport       queryResultExportFlags
 	)
 
 	cmd := &cobra.Command{
@@ -243,20 +363,24 @@ func newQueryResultGetCmd() *cobra.Command {
 		Long: "Get the Query Result details for a specific Query Result ID.\n" +
 			"\n" +
 			"This endpoint is used to fetch the results of a query that had previously been created. It is recommended to follow the Location header included in the Create Query Result output, but the URL can also be constructed manually with the <query-result-id>.",
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			if err := qView.validate(); err != nil {
+				return err
+			}
+			return qExport.validate()
+		},
 		Run: func(cmd *cobra.Command, args []string) {
-			var p = payload{
-				Method:   http.MethodGet,
-				Path:     "/1/query_results/" + targetDataset + "/" + queryResultID,
-				Response: &queryResult{},
+			resp, err := client.GetQueryResult(cmd.Context(), targetDataset, queryResultID)
+			if err != nil {
+				fatal(log.Fields{
+					"_function":       "newQueryResultGetCmd",
+					"query_result_id": queryResultID,
+				}, err, "Error received when attempting to get a query result.")
 			}
 
-			err := p.GetResponse(true)
-			if err != nil {
-				log.WithFields(log.Fields{
-					"_function": "newQueryResultGetCmd",
-					"err":       err,
-					"payload":   p,
-				}).Fatal("Error received when attempting to get a query result.")
+			qExport.write("newQueryResultGetCmd", resp)
+			if !qExport.toStdout() {
+				qView.print("newQueryResultGetCmd", resp)
 			}
 		},
 	}
@@ -264,6 +388,8 @@ func newQueryResultGetCmd() *cobra.Command {
 	cmd.Flags().StringVarP(&queryResultID, "query-result-id", "q", "",
 		"The unique identifier (ID) of the query result.")
 	cmd.MarkFlagRequired("query-result-id")
+	qView.register(cmd)
+	qExport.register(cmd)
 
 	return cmd
 }
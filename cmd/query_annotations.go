@@ -0,0 +1,259 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/adz-anz/honeybadger/honeycomb"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// Query Annotations
+// https://docs.honeycomb.io/api/tag/Query-Annotations
+func newQueryAnnotationsCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "query_annotations",
+		Aliases: []string{"qa"},
+		Short:   "Manage Query Annotations",
+		Long: "Query Annotations give a Query a name and description, which are shown wherever\n" +
+			"the Query is displayed, such as on a Board.\n" +
+			"\n" +
+			"These commands allow you to list, create, update, and delete Query Annotations.",
+	}
+
+	cmd.PersistentFlags().StringVarP(&targetDataset, "dataset", "d", honeycomb.EnvironmentWide,
+		"The dataset slug or use __all__ (or omit) for endpoints that support environment-wide operations.")
+
+	cmd.AddCommand(
+		newQueryAnnotationsCreateCmd(),
+		newQueryAnnotationsListCmd(),
+		newQueryAnnotationsGetCmd(),
+		newQueryAnnotationsUpdateCmd(),
+		newQueryAnnotationsDeleteCmd(),
+	)
+
+	return cmd
+}
+
+// Create a Query Annotation
+// https://docs.honeycomb.io/api/tag/Query-Annotations#operation/createQueryAnnotation
+func newQueryAnnotationsCreateCmd() *cobra.Command {
+	var (
+		qaName        string
+		qaDescription string
+		qaQueryID     string
+	)
+
+	cmd := &cobra.Command{
+		Use:     "create",
+		Aliases: []string{"add", "new"},
+		Short:   "Create a Query Annotation in the specified dataset.",
+		Long:    "Create a Query Annotation for a Query in the specified dataset.",
+		Example: "  honeybadger query_annotations create -d my-service -q abc123 -n 'Slow requests'",
+		Run: func(cmd *cobra.Command, args []string) {
+			var qa = honeycomb.QueryAnnotation{
+				Name:        qaName,
+				Description: qaDescription,
+				QueryID:     qaQueryID,
+			}
+
+			if err := qa.Validate(); err != nil {
+				fatal(log.Fields{
+					"_function":        "newQueryAnnotationsCreateCmd",
+					"query_annotation": qa,
+				}, err, "Error received when attempting to validate a query annotation.")
+			}
+
+			created, err := client.CreateQueryAnnotation(cmd.Context(), targetDataset, &qa)
+			if err != nil {
+				fatal(log.Fields{
+					"_function":        "newQueryAnnotationsCreateCmd",
+					"dataset":          targetDataset,
+					"query_annotation": qa,
+				}, err, "Error received when attempting to create a new query annotation.")
+			}
+
+			printResponse(created)
+		},
+	}
+
+	cmd.Flags().StringVarP(&qaName, "name", "n", "",
+		"The name to display for the Query.")
+	cmd.MarkFlagRequired("name")
+	cmd.Flags().StringVar(&qaDescription, "description", "",
+		"The description to display for the Query.")
+	cmd.Flags().StringVarP(&qaQueryID, "query-id", "q", "",
+		"The ID of the Query to annotate.")
+	cmd.MarkFlagRequired("query-id")
+
+	return cmd
+}
+
+// List All Query Annotations
+// https://docs.honeycomb.io/api/tag/Query-Annotations#operation/listQueryAnnotations
+func newQueryAnnotationsListCmd() *cobra.Command {
+	var (
+		qaIncludeBoard bool
+	)
+
+	cmd := &cobra.Command{
+		Use:     "list",
+		Aliases: []string{"ls"},
+		Short:   "List all Query Annotations in the specified dataset.",
+		Long: "List all Query Annotations in the specified dataset. Query Annotations created for\n" +
+			"Boards are only listed with --include-board-annotations.",
+		Run: func(cmd *cobra.Command, args []string) {
+			annotations, err := client.ListQueryAnnotations(cmd.Context(), targetDataset, qaIncludeBoard)
+			if err != nil {
+				fatal(log.Fields{
+					"_function": "newQueryAnnotationsListCmd",
+					"dataset":   targetDataset,
+				}, err, "Error received when attempting to list all query annotations.")
+			}
+
+			printResponse(annotations)
+		},
+	}
+
+	cmd.Flags().BoolVar(&qaIncludeBoard, "include-board-annotations", false,
+		"Also list the Query Annotations created for Boards.")
+
+	return cmd
+}
+
+// Get a Query Annotation
+// https://docs.honeycomb.io/api/tag/Query-Annotations#operation/getQueryAnnotation
+func newQueryAnnotationsGetCmd() *cobra.Command {
+	var (
+		qaID string
+	)
+
+	cmd := &cobra.Command{
+		Use:     "get",
+		Aliases: []string{},
+		Short:   "Get a single Query Annotation by ID.",
+		Long:    "Get a single Query Annotation by ID.",
+		Run: func(cmd *cobra.Command, args []string) {
+			qa, err := client.GetQueryAnnotation(cmd.Context(), targetDataset, qaID)
+			if err != nil {
+				fatal(log.Fields{
+					"_function":           "newQueryAnnotationsGetCmd",
+					"dataset":             targetDataset,
+					"query_annotation_id": qaID,
+				}, err, "Error received when attempting to get a query annotation.")
+			}
+
+			printResponse(qa)
+		},
+	}
+
+	cmd.Flags().StringVarP(&qaID, "id", "i", "", "The unique identifier (ID) of a Query Annotation.")
+	cmd.MarkFlagRequired("id")
+
+	return cmd
+}
+
+// Update a Query Annotation
+// https://docs.honeycomb.io/api/tag/Query-Annotations#operation/updateQueryAnnotation
+func newQueryAnnotationsUpdateCmd() *cobra.Command {
+	var (
+		qaID          string
+		qaName        string
+		qaDescription string
+		qaQueryID     string
+	)
+
+	cmd := &cobra.Command{
+		Use:     "update",
+		Aliases: []string{"up", "edit", "modify", "change", "set"},
+		Short:   "Update a Query Annotation by ID.",
+		Long: "Update a Query Annotation by ID.\n" +
+			"\n" +
+			"The existing Query Annotation is fetched and only the values given as flags are\n" +
+			"changed.",
+		Annotations: map[string]string{annotationLiveReads: ""},
+		Run: func(cmd *cobra.Command, args []string) {
+			qa, err := client.GetQueryAnnotation(cmd.Context(), targetDataset, qaID)
+			if err != nil {
+				fatal(log.Fields{
+					"_function":           "newQueryAnnotationsUpdateCmd",
+					"dataset":             targetDataset,
+					"query_annotation_id": qaID,
+				}, err, "Error received when attempting to get the query annotation to update.")
+			}
+
+			if cmd.Flags().Changed("name") {
+				qa.Name = qaName
+			}
+			if cmd.Flags().Changed("description") {
+				qa.Description = qaDescription
+			}
+			if cmd.Flags().Changed("query-id") {
+				qa.QueryID = qaQueryID
+			}
+
+			// Read only fields are not accepted in an update.
+			qa.ID, qa.Source, qa.CreatedAt, qa.UpdatedAt = "", "", nil, nil
+
+			if err := qa.Validate(); err != nil {
+				fatal(log.Fields{
+					"_function":        "newQueryAnnotationsUpdateCmd",
+					"query_annotation": qa,
+				}, err, "Error received when attempting to validate a query annotation.")
+			}
+
+			updated, err := client.UpdateQueryAnnotation(cmd.Context(), targetDataset, qaID, qa)
+			if err != nil {
+				fatal(log.Fields{
+					"_function":           "newQueryAnnotationsUpdateCmd",
+					"dataset":             targetDataset,
+					"query_annotation_id": qaID,
+					"query_annotation":    qa,
+				}, err, "Error received when attempting to update an existing query annotation.")
+			}
+
+			printResponse(updated)
+		},
+	}
+
+	cmd.Flags().StringVarP(&qaID, "id", "i", "", "The unique identifier (ID) of a Query Annotation.")
+	cmd.MarkFlagRequired("id")
+	cmd.Flags().StringVarP(&qaName, "name", "n", "",
+		"The name to display for the Query.")
+	cmd.Flags().StringVar(&qaDescription, "description", "",
+		"The description to display for the Query.")
+	cmd.Flags().StringVarP(&qaQueryID, "query-id", "q", "",
+		"The ID of the Query to annotate.")
+
+	return cmd
+}
+
+// Delete a Query Annotation
+// https://docs.honeycomb.io/api/tag/Query-Annotations#operation/deleteQueryAnnotation
+func newQueryAnnotationsDeleteCmd() *cobra.Command {
+	var (
+		qaID string
+	)
+
+	cmd := &cobra.Command{
+		Use:     "delete",
+		Aliases: []string{"rm", "remove", "del"},
+		Short:   "Delete a Query Annotation by ID.",
+		Long:    "Delete a Query Annotation by ID.",
+		Run: func(cmd *cobra.Command, args []string) {
+			err := client.DeleteQueryAnnotation(cmd.Context(), targetDataset, qaID)
+			if err != nil {
+				fatal(log.Fields{
+					"_function":           "newQueryAnnotationsDeleteCmd",
+					"dataset":             targetDataset,
+					"query_annotation_id": qaID,
+				}, err, "Error received when attempting to delete an existing query annotation.")
+			}
+		},
+	}
+
+	cmd.Flags().StringVarP(&qaID, "id", "i", "", "The unique identifier (ID) of a Query Annotation.")
+	cmd.MarkFlagRequired("id")
+
+	return cmd
+}
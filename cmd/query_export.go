@@ -0,0 +1,320 @@
+package cmd
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"golang.org/x/exp/slices"
+
+	"github.com/adz-anz/honeybadger/honeycomb"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// Formats accepted by --export-format.
+const (
+	exportCSV    = "csv"
+	exportTSV    = "tsv"
+	exportNDJSON = "ndjson"
+)
+
+var exportFormats = []string{exportCSV, exportTSV, exportNDJSON}
+
+// exportExtensions map file extensions to the format used when
+// --export-format is not given.
+var exportExtensions = map[string]string{
+	".csv":    exportCSV,
+	".tsv":    exportTSV,
+	".tab":    exportTSV,
+	".ndjson": exportNDJSON,
+	".jsonl":  exportNDJSON,
+}
+
+// Data accepted by --export-data.
+const (
+	exportResults = "results"
+	exportSeries  = "series"
+)
+
+// Time formats accepted by --export-time-format.
+const (
+	exportTimeISO8601 = "iso8601"
+	exportTimeEpoch   = "epoch"
+	exportTimeEpochMS = "epoch-ms"
+)
+
+var exportTimeFormats = []string{exportTimeISO8601, exportTimeEpoch, exportTimeEpochMS}
+
+// queryResultExportFlags are the flags for writing the data of a Query Result
+// to a file.
+type queryResultExportFlags struct {
+	file       string
+	format     string
+	data       string
+	timeFormat string
+}
+
+func (f *queryResultExportFlags) register(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&f.file, "export", "",
+		"Also write the data of the Query Result to this file, or - for stdout in place of the normal output.")
+	cmd.Flags().StringVar(&f.format, "export-format", "",
+		"The format of --export: csv, tsv or ndjson. Defaults to the file extension, else csv.")
+	cmd.Flags().StringVar(&f.data, "export-data", exportResults,
+		"The data to export: results, a row per group, or series, a row per time and group.")
+	cmd.Flags().StringVar(&f.timeFormat, "export-time-format", exportTimeISO8601,
+		"How to write the times of exported series: iso8601, epoch (seconds) or epoch-ms.")
+}
+
+// validate checks the flags before any request is sent.
+func (f *queryResultExportFlags) validate() error {
+	if f.format == "" {
+		f.format = exportExtensions[strings.ToLower(filepath.Ext(f.file))]
+		if f.format == "" {
+			f.format = exportCSV
+		}
+	}
+	if !slices.Contains(exportFormats, f.format) {
+		return fmt.Errorf("--export-format must be one of %s", strings.Join(exportFormats, ", "))
+	}
+	if f.data != exportResults && f.data != exportSeries {
+		return fmt.Errorf("--export-data must be %s or %s", exportResults, exportSeries)
+	}
+	if !slices.Contains(exportTimeFormats, f.timeFormat) {
+		return fmt.Errorf("--export-time-format must be one of %s", strings.Join(exportTimeFormats, ", "))
+	}
+	return nil
+}
+
+// toStdout reports whether the data is exported to stdout, in which case
+// nothing else may be written there.
+func (f *queryResultExportFlags) toStdout() bool {
+	return f.file == "-" && !dryRun
+}
+
+// write exports the data of qr if --export was given. Nothing is written on a
+// dry run, as there is no data.
+func (f *queryResultExportFlags) write(function string, qr *honeycomb.QueryResult) {
+	if f.file == "" || dryRun {
+		return
+	}
+
+	err := func() error {
+		cols, rows, err := f.table(qr)
+		if err != nil {
+			return err
+		}
+
+		if f.file == "-" {
+			return writeExport(os.Stdout, f.format, cols, rows)
+		}
+		file, err := os.Create(f.file)
+		if err != nil {
+			return err
+		}
+		if err := writeExport(file, f.format, cols, rows); err != nil {
+			file.Close()
+			return err
+		}
+		return file.Close()
+	}()
+	if err != nil {
+		fatal(log.Fields{
+			"_function": function,
+			"export":    f.file,
+		}, err, "Error received when attempting to export a query result.")
+	}
+}
+
+// table flattens the results or series of qr into rows. The columns are the
+// time (for series), then the breakdowns and calculations in the order of the
+// Query, then any other columns in alphabetical order, so that exports of the
+// same Query can be compared and appended to.
+func (f *queryResultExportFlags) table(qr *honeycomb.QueryResult) ([]string, []map[string]interface{}, error) {
+	var rows []map[string]interface{}
+	if f.data == exportResults {
+		for _, r := range qr.Data.Results {
+			rows = append(rows, flattenData(r.Data))
+		}
+	} else {
+		// Order the series by time, then by the group's place in the results.
+		groups := map[string]int{}
+		for i, r := range qr.Data.Results {
+			groups[strings.Join(breakdownValues(qr.Query.Breakdowns, r.Data), "\x00")] = i
+		}
+		groupOf := func(data map[string]interface{}) int {
+			if i, ok := groups[strings.Join(breakdownValues(qr.Query.Breakdowns, data), "\x00")]; ok {
+				return i
+			}
+			return len(groups)
+		}
+
+		series := slices.Clone(qr.Data.Series)
+		times := make(map[string]time.Time, len(series))
+		for _, s := range series {
+			t, err := time.Parse(time.RFC3339, s.Time)
+			if err != nil {
+				return nil, nil, fmt.Errorf("failed to parse the time %q of the series: %w", s.Time, err)
+			}
+			times[s.Time] = t
+		}
+		sort.SliceStable(series, func(i, j int) bool {
+			ti, tj := times[series[i].Time], times[series[j].Time]
+			if !ti.Equal(tj) {
+				return ti.Before(tj)
+			}
+			return groupOf(series[i].Data) < groupOf(series[j].Data)
+		})
+
+		for _, s := range series {
+			row := flattenData(s.Data)
+			row["time"] = f.formatTime(times[s.Time])
+			rows = append(rows, row)
+		}
+	}
+
+	var cols []string
+	if f.data == exportSeries {
+		cols = append(cols, "time")
+	}
+	cols = append(cols, qr.Query.Breakdowns...)
+	for _, c := range qr.Query.Calculations {
+		cols = append(cols, c.String())
+	}
+	if len(qr.Query.Calculations) == 0 {
+		cols = append(cols, "COUNT")
+	}
+
+	var extra []string
+	for _, r := range rows {
+		for k := range r {
+			if !slices.Contains(cols, k) && !slices.Contains(extra, k) {
+				extra = append(extra, k)
+			}
+		}
+	}
+	sort.Strings(extra)
+
+	return append(cols, extra...), rows, nil
+}
+
+func (f *queryResultExportFlags) formatTime(t time.Time) interface{} {
+	switch f.timeFormat {
+	case exportTimeEpoch:
+		return t.Unix()
+	case exportTimeEpochMS:
+		return t.UnixMilli()
+	default:
+		return t.UTC().Format(time.RFC3339)
+	}
+}
+
+// flattenData flattens nested objects in a row of Query Result data, such as
+// the buckets of a HEATMAP, into columns named by their dot-separated path.
+func flattenData(data map[string]interface{}) map[string]interface{} {
+	out := map[string]interface{}{}
+	var flatten func(prefix string, v interface{})
+	flatten = func(prefix string, v interface{}) {
+		m, ok := v.(map[string]interface{})
+		if !ok {
+			out[prefix] = v
+			return
+		}
+		for k, mv := range m {
+			flatten(prefix+"."+k, mv)
+		}
+	}
+	for k, v := range data {
+		flatten(k, v)
+	}
+	return out
+}
+
+// writeExport writes rows to w in format, with the given columns in order.
+// Missing values are left empty in CSV and TSV, and are null in NDJSON.
+func writeExport(w io.Writer, format string, cols []string, rows []map[string]interface{}) error {
+	bw := bufio.NewWriter(w)
+
+	if format == exportNDJSON {
+		for _, r := range rows {
+			line, err := orderedJSON(cols, r)
+			if err != nil {
+				return err
+			}
+			bw.Write(line)
+			bw.WriteByte('\n')
+		}
+		return bw.Flush()
+	}
+
+	cw := csv.NewWriter(bw)
+	if format == exportTSV {
+		cw.Comma = '\t'
+	}
+	if err := cw.Write(cols); err != nil {
+		return err
+	}
+	for _, r := range rows {
+		cells := make([]string, len(cols))
+		for i, c := range cols {
+			cells[i] = formatExportValue(r[c])
+		}
+		if err := cw.Write(cells); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	if err := cw.Error(); err != nil {
+		return err
+	}
+	return bw.Flush()
+}
+
+// formatExportValue renders a value for CSV or TSV. Numbers are written in
+// full, never with an exponent, so that spreadsheets read them as numbers.
+func formatExportValue(v interface{}) string {
+	switch val := v.(type) {
+	case float64:
+		return strconv.FormatFloat(val, 'f', -1, 64)
+	case int64:
+		return strconv.FormatInt(val, 10)
+	default:
+		return formatValue(val)
+	}
+}
+
+// orderedJSON encodes row as a JSON object with its keys in the order of cols,
+// rather than sorted as encoding/json would.
+func orderedJSON(cols []string, row map[string]interface{}) ([]byte, error) {
+	var b bytes.Buffer
+	enc := json.NewEncoder(&b)
+	enc.SetEscapeHTML(false)
+
+	b.WriteByte('{')
+	for i, c := range cols {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		if err := enc.Encode(c); err != nil {
+			return nil, err
+		}
+		b.Truncate(b.Len() - 1) // Encode ends each value with a newline.
+		b.WriteByte(':')
+		if err := enc.Encode(row[c]); err != nil {
+			return nil, err
+		}
+		b.Truncate(b.Len() - 1)
+	}
+	b.WriteByte('}')
+	return b.Bytes(), nil
+}
@@ -0,0 +1,598 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/spf13/cobra"
+	"golang.org/x/exp/slices"
+
+	"github.com/adz-anz/honeybadger/honeycomb"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// Views of a Query Result accepted by --view.
+const (
+	viewTable     = "table"
+	viewChart     = "chart"
+	viewSparkline = "sparkline"
+)
+
+var queryResultViews = []string{viewTable, viewChart, viewSparkline}
+
+// defaultTerminalWidth is the width charts are drawn to when the width of the
+// terminal cannot be found.
+const defaultTerminalWidth = 80
+
+// chartGlyphs are the characters a chart or sparkline is drawn with.
+type chartGlyphs struct {
+	// The marker for each group, in the order of the results table. Only as
+	// many groups are charted as there are markers.
+	markers []rune
+
+	// The bars of a sparkline, from lowest to highest.
+	bars []rune
+
+	axis, tick, base, corner rune
+}
+
+var (
+	unicodeGlyphs = chartGlyphs{
+		markers: []rune("●■▲◆★✚○□"),
+		bars:    []rune("▁▂▃▄▅▆▇█"),
+		axis:    '│',
+		tick:    '┤',
+		base:    '─',
+		corner:  '└',
+	}
+	asciiGlyphs = chartGlyphs{
+		markers: []rune("*#o+x@%&"),
+		bars:    []rune("_.-~=+*#"),
+		axis:    '|',
+		tick:    '+',
+		base:    '-',
+		corner:  '+',
+	}
+)
+
+// queryResultViewFlags are the flags selecting how a Query Result is printed.
+type queryResultViewFlags struct {
+	view   string
+	width  int
+	height int
+	ascii  bool
+}
+
+func (f *queryResultViewFlags) register(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&f.view, "view", "",
+		"Print the data of the Query Result as a table, chart or sparkline, rather than in the --output format.")
+	cmd.Flags().IntVar(&f.width, "width", 0,
+		"The width of a chart or sparklines. Defaults to the width of the terminal.")
+	cmd.Flags().IntVar(&f.height, "height", 12,
+		"The height of a chart, in lines.")
+	cmd.Flags().BoolVar(&f.ascii, "ascii", false,
+		"Draw charts and sparklines with ASCII characters only.")
+}
+
+// validate checks the flags before any request is sent.
+func (f *queryResultViewFlags) validate() error {
+	if f.view != "" && !slices.Contains(queryResultViews, f.view) {
+		return fmt.Errorf("--view must be one of %s", strings.Join(queryResultViews, ", "))
+	}
+	if f.height < 2 {
+		return fmt.Errorf("--height must be at least 2")
+	}
+	if f.width < 0 {
+		return fmt.Errorf("--width must not be negative")
+	}
+	return nil
+}
+
+// print writes qr to stdout in the selected view, or with printResponse if
+// no view was selected.
+func (f *queryResultViewFlags) print(function string, qr *honeycomb.QueryResult) {
+	if f.view == "" || dryRun {
+		printResponse(qr)
+		return
+	}
+
+	if !qr.Complete {
+		log.WithFields(log.Fields{
+			"query_result_id": qr.ID,
+		}).Warn("The Query Result is not complete yet, so its data may be missing.")
+	}
+
+	glyphs := unicodeGlyphs
+	if f.ascii {
+		glyphs = asciiGlyphs
+	}
+	width := f.width
+	if width == 0 {
+		width = terminalWidth()
+	}
+
+	g := newResultGrid(qr)
+	var err error
+	switch f.view {
+	case viewTable:
+		cols, rows := g.table()
+		err = writeTable(os.Stdout, cols, rows)
+	case viewChart:
+		err = writeChart(os.Stdout, g, glyphs, width, f.height)
+	case viewSparkline:
+		err = writeSparklines(os.Stdout, g, glyphs, width)
+	}
+	if err != nil {
+		fatal(log.Fields{
+			"_function": function,
+			"view":      f.view,
+		}, err, "Error received when attempting to render a query result.")
+	}
+}
+
+// terminalWidth returns the width of the terminal from $COLUMNS or stdout,
+// else defaultTerminalWidth.
+func terminalWidth() int {
+	if w, err := strconv.Atoi(os.Getenv("COLUMNS")); err == nil && w > 0 {
+		return w
+	}
+	if w := ttyWidth(); w > 0 {
+		return w
+	}
+	return defaultTerminalWidth
+}
+
+// resultGrid is the data of a Query Result arranged for rendering: a group for
+// each row of the results table, in order, followed by any groups found only
+// in the time series.
+type resultGrid struct {
+	breakdowns   []string
+	calculations []string
+	times        []time.Time
+	groups       []*resultGroup
+}
+
+// resultGroup is a single combination of breakdown values.
+type resultGroup struct {
+	label string
+
+	// The row of the results table, or nil if the group is only in the series.
+	row map[string]interface{}
+
+	// The series of each calculation, indexed like resultGrid.times. Missing
+	// points are NaN.
+	series map[string][]float64
+}
+
+func newResultGrid(qr *honeycomb.QueryResult) *resultGrid {
+	g := &resultGrid{breakdowns: qr.Query.Breakdowns}
+	for _, c := range qr.Query.Calculations {
+		g.calculations = append(g.calculations, c.String())
+	}
+	if len(g.calculations) == 0 {
+		// The API counts events when no calculations are given.
+		g.calculations = []string{"COUNT"}
+	}
+
+	byKey := map[string]*resultGroup{}
+	group := func(data map[string]interface{}) *resultGroup {
+		values := breakdownValues(g.breakdowns, data)
+		key := strings.Join(values, "\x00")
+		if rg, ok := byKey[key]; ok {
+			return rg
+		}
+		rg := &resultGroup{label: strings.Join(values, ", "), series: map[string][]float64{}}
+		byKey[key] = rg
+		g.groups = append(g.groups, rg)
+		return rg
+	}
+
+	for _, r := range qr.Data.Results {
+		group(r.Data).row = r.Data
+	}
+
+	index := map[int64]int{}
+	for _, s := range qr.Data.Series {
+		t, err := time.Parse(time.RFC3339, s.Time)
+		if _, ok := index[t.Unix()]; err != nil || ok {
+			continue
+		}
+		index[t.Unix()] = 0
+		g.times = append(g.times, t)
+	}
+	sort.Slice(g.times, func(i, j int) bool { return g.times[i].Before(g.times[j]) })
+	for i, t := range g.times {
+		index[t.Unix()] = i
+	}
+
+	for _, s := range qr.Data.Series {
+		t, err := time.Parse(time.RFC3339, s.Time)
+		if err != nil {
+			continue
+		}
+		i := index[t.Unix()]
+		rg := group(s.Data)
+		for _, c := range g.calculations {
+			if rg.series[c] == nil {
+				rg.series[c] = make([]float64, len(g.times))
+				for j := range rg.series[c] {
+					rg.series[c][j] = math.NaN()
+				}
+			}
+			rg.series[c][i] = toFloat(s.Data[c])
+		}
+	}
+
+	return g
+}
+
+// breakdownValues returns the value of each breakdown in a row of Query Result
+// data, which together identify its group.
+func breakdownValues(breakdowns []string, data map[string]interface{}) []string {
+	values := make([]string, len(breakdowns))
+	for i, b := range breakdowns {
+		values[i] = formatValue(data[b])
+		if data[b] == nil {
+			values[i] = "(none)"
+		}
+	}
+	return values
+}
+
+// table returns the columns and rows of the results table, with a column for
+// each breakdown and calculation. The columns are keyed by position, as
+// breakdowns may contain dots that would be read as paths.
+func (g *resultGrid) table() ([]outputColumn, []interface{}) {
+	names := append(append([]string{}, g.breakdowns...), g.calculations...)
+	cols := make([]outputColumn, len(names))
+	for i, n := range names {
+		cols[i] = outputColumn{Header: n, Path: fmt.Sprintf("c%d", i)}
+	}
+
+	var rows []interface{}
+	for _, rg := range g.groups {
+		if rg.row == nil {
+			continue
+		}
+		row := map[string]interface{}{}
+		for i, n := range names {
+			row[cols[i].Path] = rg.row[n]
+		}
+		rows = append(rows, row)
+	}
+	return cols, rows
+}
+
+// toFloat returns v as a number, or NaN if it is not one, such as the
+// buckets of a HEATMAP.
+func toFloat(v interface{}) float64 {
+	switch n := v.(type) {
+	case float64:
+		return n
+	case int:
+		return float64(n)
+	case int64:
+		return float64(n)
+	default:
+		return math.NaN()
+	}
+}
+
+// formatNumber renders v compactly for axis labels and sparkline summaries,
+// e.g. 12, 0.25 or 1.5k.
+func formatNumber(v float64) string {
+	abs := math.Abs(v)
+	for _, u := range []struct {
+		size   float64
+		suffix string
+	}{{1e12, "T"}, {1e9, "G"}, {1e6, "M"}, {1e3, "k"}} {
+		if abs >= u.size {
+			return strconv.FormatFloat(math.Round(v/u.size*10)/10, 'f', -1, 64) + u.suffix
+		}
+	}
+	return strconv.FormatFloat(math.Round(v*100)/100, 'f', -1, 64)
+}
+
+// formatTime renders a time on the x axis, with the date only if the series
+// spans more than a day.
+func formatTime(t time.Time, span time.Duration) string {
+	if span > 24*time.Hour {
+		return t.Local().Format("Jan 02 15:04")
+	}
+	return t.Local().Format("15:04")
+}
+
+// truncate shortens s to at most n runes, marking that it was shortened.
+func truncate(s string, n int) string {
+	if utf8.RuneCountInString(s) <= n {
+		return s
+	}
+	if n <= 1 {
+		return string([]rune(s)[:n])
+	}
+	return string([]rune(s)[:n-1]) + "…"
+}
+
+// pad right-pads s with spaces to n runes.
+func pad(s string, n int) string {
+	if c := utf8.RuneCountInString(s); c < n {
+		return s + strings.Repeat(" ", n-c)
+	}
+	return s
+}
+
+// valueRange returns the lowest and highest of the values that are not NaN,
+// and whether there were any.
+func valueRange(values ...[]float64) (lo, hi float64, ok bool) {
+	lo, hi = math.Inf(1), math.Inf(-1)
+	for _, vs := range values {
+		for _, v := range vs {
+			if !math.IsNaN(v) {
+				lo, hi = math.Min(lo, v), math.Max(hi, v)
+			}
+		}
+	}
+	return lo, hi, !math.IsInf(lo, 1)
+}
+
+// writeChart draws a line chart of the time series of each calculation, with a
+// line for each group, and a legend naming the groups.
+func writeChart(w io.Writer, g *resultGrid, glyphs chartGlyphs, width, height int) error {
+	var b strings.Builder
+
+	for ci, c := range g.calculations {
+		if ci > 0 {
+			b.WriteString("\n")
+		}
+		b.WriteString(c + "\n")
+
+		var groups []*resultGroup
+		var series [][]float64
+		hidden := 0
+		for _, rg := range g.groups {
+			if _, _, ok := valueRange(rg.series[c]); !ok {
+				continue
+			}
+			if len(groups) == len(glyphs.markers) {
+				hidden++
+				continue
+			}
+			groups = append(groups, rg)
+			series = append(series, rg.series[c])
+		}
+
+		lo, hi, ok := valueRange(series...)
+		if !ok {
+			b.WriteString("  no data\n")
+			continue
+		}
+		// Anchor the y axis at zero unless the series go below it, so that the
+		// size of a change is not exaggerated.
+		lo = math.Min(lo, 0)
+		if hi == lo {
+			hi = lo + 1
+		}
+
+		labels := make([]string, height)
+		labelWidth := 0
+		for r := range labels {
+			if r == 0 || (height-1-r)%3 == 0 {
+				labels[r] = formatNumber(hi - (hi-lo)*float64(r)/float64(height-1))
+				labelWidth = max(labelWidth, len(labels[r]))
+			}
+		}
+		plotWidth := max(width-labelWidth-2, 10)
+
+		rowFor := func(v float64) int {
+			return height - 1 - int(math.Round((v-lo)/(hi-lo)*float64(height-1)))
+		}
+		colFor := func(i int) int {
+			if len(g.times) == 1 {
+				return 0
+			}
+			return int(math.Round(float64(i) * float64(plotWidth-1) / float64(len(g.times)-1)))
+		}
+
+		canvas := make([][]rune, height)
+		for r := range canvas {
+			canvas[r] = []rune(strings.Repeat(" ", plotWidth))
+		}
+
+		// Draw the first group last, so that it is on top.
+		for gi := len(series) - 1; gi >= 0; gi-- {
+			marker := glyphs.markers[gi]
+			prev := -1
+			for i, v := range series[gi] {
+				if math.IsNaN(v) {
+					prev = -1
+					continue
+				}
+				x := colFor(i)
+				if prev < 0 {
+					canvas[rowFor(v)][x] = marker
+					prev = i
+					continue
+				}
+
+				// Join the points with a line through each column between
+				// them, filling any vertical gap between adjacent columns.
+				px, pv := colFor(prev), series[gi][prev]
+				lastRow := rowFor(pv)
+				for cx := px + 1; cx <= x; cx++ {
+					row := rowFor(pv + (v-pv)*float64(cx-px)/float64(x-px))
+					for r := lastRow; r != row; {
+						if r < row {
+							r++
+						} else {
+							r--
+						}
+						canvas[r][cx] = marker
+					}
+					canvas[row][cx] = marker
+					lastRow = row
+				}
+				prev = i
+			}
+		}
+
+		for r, line := range canvas {
+			axis := glyphs.axis
+			if labels[r] != "" {
+				axis = glyphs.tick
+			}
+			fmt.Fprintf(&b, "%*s %c%s\n", labelWidth, labels[r], axis, strings.TrimRight(string(line), " "))
+		}
+		fmt.Fprintf(&b, "%s%c%s\n", strings.Repeat(" ", labelWidth+1), glyphs.corner, strings.Repeat(string(glyphs.base), plotWidth))
+		b.WriteString(timeAxis(g.times, labelWidth+2, plotWidth) + "\n")
+
+		if len(g.breakdowns) > 0 {
+			b.WriteString(legend(groups, glyphs, width))
+		}
+		if hidden > 0 {
+			fmt.Fprintf(&b, "  (%d more groups not shown)\n", hidden)
+		}
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// timeAxis returns the labels of the x axis: the first and last times, and the
+// middle time if there is room for it.
+func timeAxis(times []time.Time, indent, width int) string {
+	if len(times) == 0 {
+		return ""
+	}
+	span := times[len(times)-1].Sub(times[0])
+	line := []rune(strings.Repeat(" ", indent+width))
+
+	place := func(at int, label string) {
+		at = min(max(at, 0), len(line)-utf8.RuneCountInString(label))
+		copy(line[at:], []rune(label))
+	}
+	first := formatTime(times[0], span)
+	place(indent, first)
+	if len(times) > 1 {
+		last := formatTime(times[len(times)-1], span)
+		if middle := formatTime(times[len(times)/2], span); width > 3*len(middle)+6 {
+			place(indent+width/2-len(middle)/2, middle)
+		}
+		if width > len(first)+len(last)+2 {
+			place(indent+width-len(last), last)
+		}
+	}
+	return strings.TrimRight(string(line), " ")
+}
+
+// legend returns a line, or lines if they do not fit in width, naming the
+// group drawn with each marker.
+func legend(groups []*resultGroup, glyphs chartGlyphs, width int) string {
+	var b strings.Builder
+	line := " "
+	for i, rg := range groups {
+		entry := fmt.Sprintf(" %c %s", glyphs.markers[i], truncate(rg.label, max(width/2, 10)))
+		if line != " " && utf8.RuneCountInString(line+" "+entry) > width {
+			b.WriteString(line + "\n")
+			line = " "
+		}
+		if line != " " {
+			line += " "
+		}
+		line += entry
+	}
+	if line != " " {
+		b.WriteString(line + "\n")
+	}
+	return b.String()
+}
+
+// writeSparklines writes a sparkline of the time series of each calculation
+// for each group, one per line, followed by the last and highest values. Each
+// sparkline is scaled to its own range.
+func writeSparklines(w io.Writer, g *resultGrid, glyphs chartGlyphs, width int) error {
+	type line struct {
+		name, summary string
+		values        []float64
+	}
+
+	var lines []line
+	nameWidth, summaryWidth := 0, 0
+	for _, c := range g.calculations {
+		for _, rg := range g.groups {
+			values := rg.series[c]
+			_, hi, ok := valueRange(values)
+			if !ok {
+				continue
+			}
+
+			name := c
+			if len(g.breakdowns) > 0 {
+				name = rg.label
+				if len(g.calculations) > 1 {
+					name += " " + c
+				}
+			}
+			name = truncate(name, max(width/3, 10))
+
+			last := math.NaN()
+			for i := len(values) - 1; i >= 0 && math.IsNaN(last); i-- {
+				last = values[i]
+			}
+			summary := fmt.Sprintf("last %s max %s", formatNumber(last), formatNumber(hi))
+
+			lines = append(lines, line{name, summary, values})
+			nameWidth = max(nameWidth, utf8.RuneCountInString(name))
+			summaryWidth = max(summaryWidth, len(summary))
+		}
+	}
+
+	sparkWidth := max(width-nameWidth-summaryWidth-2, 8)
+
+	var b strings.Builder
+	for _, l := range lines {
+		fmt.Fprintf(&b, "%s %s %s\n", pad(l.name, nameWidth), pad(sparkline(l.values, sparkWidth, glyphs.bars), sparkWidth), l.summary)
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// sparkline draws values with a bar for each, or for each bucket of values if
+// there are more than width, taking the highest value in the bucket so that
+// spikes are not hidden. Missing values are left blank.
+func sparkline(values []float64, width int, bars []rune) string {
+	if len(values) > width {
+		buckets := make([]float64, width)
+		for i := range buckets {
+			buckets[i] = math.NaN()
+			for j := i * len(values) / width; j < (i+1)*len(values)/width; j++ {
+				if math.IsNaN(buckets[i]) || values[j] > buckets[i] {
+					buckets[i] = values[j]
+				}
+			}
+		}
+		values = buckets
+	}
+
+	lo, hi, _ := valueRange(values)
+	out := make([]rune, len(values))
+	for i, v := range values {
+		switch {
+		case math.IsNaN(v):
+			out[i] = ' '
+		case hi == lo:
+			out[i] = bars[0]
+		default:
+			out[i] = bars[int(math.Round((v-lo)/(hi-lo)*float64(len(bars)-1)))]
+		}
+	}
+	return string(out)
+}
@@ -0,0 +1,431 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"golang.org/x/exp/slices"
+
+	"github.com/adz-anz/honeybadger/honeycomb"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// recipientFlags are the flags shared by the recipient create and update
+// commands. Only flags that were explicitly set are applied, so that they can
+// be used to override a Recipient read from --from-file or fetched for an
+// update.
+type recipientFlags struct {
+	fromFile                 string
+	recipientType            string
+	emailAddress             string
+	slackChannel             string
+	pagerDutyIntegrationKey  string
+	pagerDutyIntegrationName string
+	webhookName              string
+	webhookURL               string
+	webhookSecret            string
+}
+
+func (f *recipientFlags) register(cmd *cobra.Command) {
+	cmd.Flags().StringVarP(&f.fromFile, "from-file", "f", "",
+		"A JSON or YAML file holding the Recipient, or - for stdin. Other flags override values in the file.")
+	cmd.Flags().StringVarP(&f.recipientType, "type", "t", "",
+		"The type of Recipient. Enum: \"email\" \"slack\" \"pagerduty\" \"webhook\" \"msteams\"")
+	cmd.Flags().StringVar(&f.emailAddress, "email", "",
+		"For email Recipients, the email address to notify.")
+	cmd.Flags().StringVar(&f.slackChannel, "slack-channel", "",
+		"For slack Recipients, the channel to notify, e.g. #alerts.")
+	cmd.Flags().StringVar(&f.pagerDutyIntegrationKey, "pagerduty-integration-key", "",
+		"For pagerduty Recipients, the integration key of the service to raise incidents on.")
+	cmd.Flags().StringVar(&f.pagerDutyIntegrationName, "pagerduty-integration-name", "",
+		"For pagerduty Recipients, a name for the integration.")
+	cmd.Flags().StringVar(&f.webhookName, "webhook-name", "",
+		"For webhook and msteams Recipients, a name for the webhook.")
+	cmd.Flags().StringVar(&f.webhookURL, "webhook-url", "",
+		"For webhook and msteams Recipients, the URL notifications are posted to.")
+	cmd.Flags().StringVar(&f.webhookSecret, "webhook-secret", "",
+		"For webhook Recipients, a secret sent with notifications so the receiver can verify them.")
+}
+
+// apply overlays the flags that were set onto r, reading --from-file first if
+// it was given.
+func (f *recipientFlags) apply(cmd *cobra.Command, r *honeycomb.Recipient) error {
+	if f.fromFile != "" {
+		*r = honeycomb.Recipient{}
+		if err := readResourceFile(f.fromFile, r); err != nil {
+			return err
+		}
+	}
+
+	flags := cmd.Flags()
+	for name, field := range map[string]*string{
+		"type":                       &r.Type,
+		"email":                      &r.Details.EmailAddress,
+		"slack-channel":              &r.Details.SlackChannel,
+		"pagerduty-integration-key":  &r.Details.PagerDutyIntegrationKey,
+		"pagerduty-integration-name": &r.Details.PagerDutyIntegrationName,
+		"webhook-name":               &r.Details.WebhookName,
+		"webhook-url":                &r.Details.WebhookURL,
+		"webhook-secret":             &r.Details.WebhookSecret,
+	} {
+		if flags.Changed(name) {
+			*field, _ = flags.GetString(name)
+		}
+	}
+
+	return nil
+}
+
+// parseRecipientFlags converts the values of a --recipient flag, each a
+// Recipient ID optionally followed by a PagerDuty severity (ID:severity), to
+// the Recipients attached to a Trigger or Burn Alert.
+func parseRecipientFlags(values []string) []honeycomb.NotificationRecipient {
+	recipients := make([]honeycomb.NotificationRecipient, 0, len(values))
+	for _, v := range values {
+		id, severity, _ := strings.Cut(v, ":")
+		nr := honeycomb.NotificationRecipient{ID: id}
+		if severity != "" {
+			nr.Details = &honeycomb.NotificationRecipientDetails{PagerDutySeverity: severity}
+		}
+		recipients = append(recipients, nr)
+	}
+	return recipients
+}
+
+// recipientUsage is an alert that notifies a Recipient, as reported by
+// recipients where-used.
+type recipientUsage struct {
+	Kind    string `json:"kind"`
+	Dataset string `json:"dataset"`
+	ID      string `json:"id"`
+	Name    string `json:"name,omitempty"`
+	SLOID   string `json:"slo_id,omitempty"`
+}
+
+// Recipients
+// https://docs.honeycomb.io/api/tag/Recipients
+func newRecipientsCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "recipients",
+		Aliases: []string{"r"},
+		Short:   "Manage Recipients",
+		Long: "Recipients are the destinations for the notifications sent by Triggers and Burn\n" +
+			"Alerts: email addresses, Slack channels, PagerDuty services, webhooks and\n" +
+			"Microsoft Teams channels.\n" +
+			"\n" +
+			"These commands allow you to list, create, update, and delete Recipients, and to\n" +
+			"find the alerts that use a Recipient.",
+	}
+
+	cmd.AddCommand(
+		newRecipientsCreateCmd(),
+		newRecipientsListCmd(),
+		newRecipientsGetCmd(),
+		newRecipientsUpdateCmd(),
+		newRecipientsDeleteCmd(),
+		newRecipientsWhereUsedCmd(),
+	)
+
+	return cmd
+}
+
+// Create a Recipient
+// https://docs.honeycomb.io/api/tag/Recipients#operation/createRecipient
+func newRecipientsCreateCmd() *cobra.Command {
+	var f recipientFlags
+
+	cmd := &cobra.Command{
+		Use:     "create",
+		Aliases: []string{"add", "new"},
+		Short:   "Create a Recipient.",
+		Long: "Create a Recipient. The details required depend on the type of Recipient, and are\n" +
+			"checked before the request is sent.",
+		Example: "  honeybadger recipients create -t email --email oncall@example.com\n" +
+			"  honeybadger recipients create -t slack --slack-channel '#alerts'\n" +
+			"  honeybadger recipients create -t webhook --webhook-name deploys \\\n" +
+			"    --webhook-url https://example.com/hook --webhook-secret \"$SECRET\"",
+		Run: func(cmd *cobra.Command, args []string) {
+			var r honeycomb.Recipient
+			if err := f.apply(cmd, &r); err != nil {
+				fatal(log.Fields{
+					"_function": "newRecipientsCreateCmd",
+					"from_file": f.fromFile,
+				}, err, "Error received when attempting to read a recipient.")
+			}
+
+			if err := r.Validate(); err != nil {
+				fatal(log.Fields{
+					"_function": "newRecipientsCreateCmd",
+					"recipient": r,
+				}, err, "Error received when attempting to validate a recipient.")
+			}
+
+			created, err := client.CreateRecipient(cmd.Context(), &r)
+			if err != nil {
+				fatal(log.Fields{
+					"_function": "newRecipientsCreateCmd",
+					"recipient": r,
+				}, err, "Error received when attempting to create a new recipient.")
+			}
+
+			printResponse(created)
+		},
+	}
+
+	f.register(cmd)
+
+	return cmd
+}
+
+// List All Recipients
+// https://docs.honeycomb.io/api/tag/Recipients#operation/listRecipients
+func newRecipientsListCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "list",
+		Aliases: []string{"ls"},
+		Short:   "List all Recipients.",
+		Long:    "List all Recipients.",
+		Run: func(cmd *cobra.Command, args []string) {
+			recipients, err := client.ListRecipients(cmd.Context())
+			if err != nil {
+				fatal(log.Fields{
+					"_function": "newRecipientsListCmd",
+				}, err, "Error received when attempting to list all recipients.")
+			}
+
+			printResponse(recipients)
+		},
+	}
+
+	return cmd
+}
+
+// Get a Recipient
+// https://docs.honeycomb.io/api/tag/Recipients#operation/getRecipient
+func newRecipientsGetCmd() *cobra.Command {
+	var (
+		rID string
+	)
+
+	cmd := &cobra.Command{
+		Use:     "get",
+		Aliases: []string{},
+		Short:   "Get a single Recipient by ID.",
+		Long:    "Get a single Recipient by ID.",
+		Run: func(cmd *cobra.Command, args []string) {
+			r, err := client.GetRecipient(cmd.Context(), rID)
+			if err != nil {
+				fatal(log.Fields{
+					"_function":    "newRecipientsGetCmd",
+					"recipient_id": rID,
+				}, err, "Error received when attempting to get a recipient.")
+			}
+
+			printResponse(r)
+		},
+	}
+
+	cmd.Flags().StringVarP(&rID, "id", "i", "", "The unique identifier (ID) of a Recipient.")
+	cmd.MarkFlagRequired("id")
+
+	return cmd
+}
+
+// Update a Recipient
+// https://docs.honeycomb.io/api/tag/Recipients#operation/updateRecipient
+func newRecipientsUpdateCmd() *cobra.Command {
+	var (
+		rID string
+		f   recipientFlags
+	)
+
+	cmd := &cobra.Command{
+		Use:     "update",
+		Aliases: []string{"up", "edit", "modify", "change", "set"},
+		Short:   "Update a Recipient by ID.",
+		Long: "Update a Recipient by ID.\n" +
+			"\n" +
+			"Without --from-file, the existing Recipient is fetched and only the values given\n" +
+			"as flags are changed. With --from-file, the Recipient is replaced by the contents\n" +
+			"of the file, with any flags applied over it.",
+		Annotations: map[string]string{annotationLiveReads: ""},
+		Run: func(cmd *cobra.Command, args []string) {
+			var r honeycomb.Recipient
+			if f.fromFile == "" {
+				existing, err := client.GetRecipient(cmd.Context(), rID)
+				if err != nil {
+					fatal(log.Fields{
+						"_function":    "newRecipientsUpdateCmd",
+						"recipient_id": rID,
+					}, err, "Error received when attempting to get the recipient to update.")
+				}
+				r = *existing
+			}
+
+			if err := f.apply(cmd, &r); err != nil {
+				fatal(log.Fields{
+					"_function": "newRecipientsUpdateCmd",
+					"from_file": f.fromFile,
+				}, err, "Error received when attempting to read a recipient.")
+			}
+
+			// Read only fields are not accepted in an update.
+			r.ID, r.CreatedAt, r.UpdatedAt = "", nil, nil
+
+			if err := r.Validate(); err != nil {
+				fatal(log.Fields{
+					"_function": "newRecipientsUpdateCmd",
+					"recipient": r,
+				}, err, "Error received when attempting to validate a recipient.")
+			}
+
+			updated, err := client.UpdateRecipient(cmd.Context(), rID, &r)
+			if err != nil {
+				fatal(log.Fields{
+					"_function":    "newRecipientsUpdateCmd",
+					"recipient_id": rID,
+					"recipient":    r,
+				}, err, "Error received when attempting to update an existing recipient.")
+			}
+
+			printResponse(updated)
+		},
+	}
+
+	cmd.Flags().StringVarP(&rID, "id", "i", "", "The unique identifier (ID) of a Recipient.")
+	cmd.MarkFlagRequired("id")
+	f.register(cmd)
+
+	return cmd
+}
+
+// Delete a Recipient
+// https://docs.honeycomb.io/api/tag/Recipients#operation/deleteRecipient
+func newRecipientsDeleteCmd() *cobra.Command {
+	var (
+		rID string
+	)
+
+	cmd := &cobra.Command{
+		Use:     "delete",
+		Aliases: []string{"rm", "remove", "del"},
+		Short:   "Delete a Recipient by ID.",
+		Long: "Delete a Recipient by ID. Use where-used first to find the alerts that would stop\n" +
+			"notifying it.",
+		Run: func(cmd *cobra.Command, args []string) {
+			err := client.DeleteRecipient(cmd.Context(), rID)
+			if err != nil {
+				fatal(log.Fields{
+					"_function":    "newRecipientsDeleteCmd",
+					"recipient_id": rID,
+				}, err, "Error received when attempting to delete an existing recipient.")
+			}
+		},
+	}
+
+	cmd.Flags().StringVarP(&rID, "id", "i", "", "The unique identifier (ID) of a Recipient.")
+	cmd.MarkFlagRequired("id")
+
+	return cmd
+}
+
+// CUSTOM
+// Find the Triggers and Burn Alerts that notify a Recipient
+func newRecipientsWhereUsedCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "where-used <id>",
+		Short: "List the Triggers and Burn Alerts that notify a Recipient.",
+		Long: "List the Triggers and Burn Alerts that notify a Recipient, across every dataset and\n" +
+			"those that are environment-wide, so that the effect of deleting or changing it can\n" +
+			"be checked first.\n" +
+			"\n" +
+			"This makes one request per dataset for Triggers and SLOs, and one per SLO for Burn\n" +
+			"Alerts, so it can take some time in large environments.",
+		Args: cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			rID := args[0]
+			notifies := func(rs []honeycomb.NotificationRecipient) bool {
+				return slices.ContainsFunc(rs, func(r honeycomb.NotificationRecipient) bool { return r.ID == rID })
+			}
+
+			datasets, err := client.ListDatasets(cmd.Context())
+			if err != nil {
+				fatal(log.Fields{
+					"_function":    "newRecipientsWhereUsedCmd",
+					"recipient_id": rID,
+				}, err, "Error received when attempting to list all datasets.")
+			}
+
+			// Triggers and SLOs can also be environment-wide, which listing
+			// the datasets does not cover.
+			slugs := []string{honeycomb.EnvironmentWide}
+			for _, d := range datasets {
+				slugs = append(slugs, d.Slug)
+			}
+
+			usages := []recipientUsage{}
+			for _, slug := range slugs {
+				triggers, err := client.ListTriggers(cmd.Context(), slug)
+				if slug == honeycomb.EnvironmentWide && errors.Is(err, honeycomb.ErrNotFound) {
+					// Environments without environment-wide resources
+					// have nothing more to report here.
+					continue
+				}
+				if err != nil {
+					fatal(log.Fields{
+						"_function": "newRecipientsWhereUsedCmd",
+						"dataset":   slug,
+					}, err, "Error received when attempting to list all triggers.")
+				}
+				for _, t := range triggers {
+					if notifies(t.Recipients) {
+						usages = append(usages, recipientUsage{Kind: "trigger", Dataset: slug, ID: t.ID, Name: t.Name})
+					}
+				}
+
+				slos, err := client.ListSLOs(cmd.Context(), slug)
+				if errors.Is(err, honeycomb.ErrAuth) || errors.Is(err, honeycomb.ErrNotFound) {
+					// SLOs are not available on every plan, which should not
+					// stop the Triggers from being reported.
+					log.WithFields(log.Fields{
+						"dataset": slug,
+						"err":     err,
+					}).Warn("Skipping burn alerts, as the SLOs of the dataset could not be listed.")
+					continue
+				}
+				if err != nil {
+					fatal(log.Fields{
+						"_function": "newRecipientsWhereUsedCmd",
+						"dataset":   slug,
+					}, err, "Error received when attempting to list all SLOs.")
+				}
+				for _, s := range slos {
+					burnAlerts, err := client.ListBurnAlerts(cmd.Context(), slug, s.ID)
+					if err != nil {
+						fatal(log.Fields{
+							"_function": "newRecipientsWhereUsedCmd",
+							"dataset":   slug,
+							"slo_id":    s.ID,
+						}, err, "Error received when attempting to list all burn alerts.")
+					}
+					for _, b := range burnAlerts {
+						if notifies(b.Recipients) {
+							name := b.Description
+							if name == "" {
+								name = fmt.Sprintf("%s (%s)", s.Name, b.AlertType)
+							}
+							usages = append(usages, recipientUsage{Kind: "burn_alert", Dataset: slug, ID: b.ID, Name: name, SLOID: s.ID})
+						}
+					}
+				}
+			}
+
+			printResponse(usages)
+		},
+	}
+
+	return cmd
+}
@@ -0,0 +1,37 @@
+package cmd
+
+import (
+	log "github.com/sirupsen/logrus"
+
+	"github.com/adz-anz/honeybadger/honeycomb"
+)
+
+// redactingFormatter masks secrets in every log entry after it has been
+// formatted, so that structs logged as fields cannot leak the configuration key
+// or recipient secrets.
+type redactingFormatter struct {
+	log.Formatter
+	redactor *honeycomb.Redactor
+}
+
+func (f *redactingFormatter) Format(entry *log.Entry) ([]byte, error) {
+	out, err := f.Formatter.Format(entry)
+	if err != nil {
+		return nil, err
+	}
+	return f.redactor.JSON(out), nil
+}
+
+// initializeRedaction masks secrets in all log output using the client's
+// Redactor, unless --show-secrets was given.
+func initializeRedaction() {
+	redactor = client.Redactor()
+	if redactor == nil {
+		return
+	}
+
+	log.SetFormatter(&redactingFormatter{
+		Formatter: &log.JSONFormatter{},
+		redactor:  redactor,
+	})
+}
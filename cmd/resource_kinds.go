@@ -0,0 +1,984 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/adz-anz/honeybadger/honeycomb"
+)
+
+// pendingID stands in for the ID of a resource that does not exist yet, in
+// plans and dry runs.
+const pendingID = "(known after apply)"
+
+// commonServerFields are set by Honeycomb on every kind of resource, and are
+// never declared.
+var commonServerFields = []string{"id", "created_at", "updated_at"}
+
+// remoteResource is a resource as it currently exists in Honeycomb.
+type remoteResource struct {
+	ID   string
+	Spec map[string]interface{}
+}
+
+// resourceKind describes how to manage one kind of resource declared in
+// manifests.
+type resourceKind struct {
+	// The name of the kind, as given in manifests.
+	name string
+
+	// Kinds are created and updated in order of rank, and deleted in reverse,
+	// so that resources exist before those that refer to them.
+	rank int
+
+	// Whether resources of the kind belong to a dataset.
+	datasetScoped bool
+
+	// Whether there is a single resource of the kind per dataset, named
+	// after it, such as the settings of the dataset.
+	singleton bool
+
+	// Whether resources of the kind can be deleted. Datasets and their
+	// definitions are never deleted, as that would lose data.
+	deletable bool
+
+	// keyName describes the natural key of the kind, and key returns it from
+	// a spec. Resources are matched by it when they are not owned yet.
+	keyName string
+	key     func(spec map[string]interface{}) string
+
+	// list returns the resources of the kind in a dataset, or in the
+	// environment for kinds that do not belong to a dataset.
+	list func(ctx context.Context, r *refResolver, dataset string) ([]remoteResource, error)
+
+	// expand, if set, adds what a listed resource refers to by ID to its
+	// spec, such as the Queries of a Board, so that it can be compared with a
+	// manifest.
+	expand func(ctx context.Context, r *refResolver, dataset string, spec map[string]interface{}) error
+
+	// normalize, if set, rewrites the references in a spec into the form
+	// manifests give them in, such as Recipients by name rather than ID. It
+	// is applied to both listed and declared specs before they are compared.
+	normalize func(ctx context.Context, r *refResolver, spec map[string]interface{}) error
+
+	// prepare resolves the references in a declared spec and validates it,
+	// returning the resource to send. References to resources that do not
+	// exist yet are resolved to pendingID unless live is true.
+	prepare func(ctx context.Context, r *refResolver, dataset string, spec map[string]interface{}, live bool) (interface{}, error)
+
+	// send creates the resource if id is empty, or else updates it, returning
+	// its ID.
+	send func(ctx context.Context, dataset, id string, v interface{}) (string, error)
+
+	// delete deletes the resource, if the kind is deletable.
+	delete func(ctx context.Context, dataset, id string) error
+}
+
+// nameOf returns the name of a declared resource that does not give its own.
+func (k *resourceKind) nameOf(dataset string, spec map[string]interface{}) string {
+	if k.singleton {
+		return dataset
+	}
+	return k.key(spec)
+}
+
+// resourceSpec converts a resource to a spec, without the fields set by
+// Honeycomb: those common to all resources, and serverFields.
+func resourceSpec(v interface{}, serverFields ...string) (map[string]interface{}, error) {
+	spec, err := toSpec(v)
+	if err != nil {
+		return nil, err
+	}
+	for _, f := range commonServerFields {
+		delete(spec, f)
+	}
+	for _, f := range serverFields {
+		delete(spec, f)
+	}
+	return spec, nil
+}
+
+// resourceKinds are the kinds that can be declared in manifests, in order of
+// rank.
+var resourceKinds = []*resourceKind{
+	recipientKind,
+	datasetKind,
+	columnKind,
+	derivedColumnKind,
+	markerSettingKind,
+	datasetDefinitionKind,
+	sloKind,
+	triggerKind,
+	burnAlertKind,
+	queryAnnotationKind,
+	boardKind,
+}
+
+// kindByName returns the kind with the given name, or nil.
+func kindByName(name string) *resourceKind {
+	for _, k := range resourceKinds {
+		if k.name == name {
+			return k
+		}
+	}
+	return nil
+}
+
+func kindNames() []string {
+	names := make([]string, len(resourceKinds))
+	for i, k := range resourceKinds {
+		names[i] = k.name
+	}
+	return names
+}
+
+// stringField returns a key function reading a string field of a spec.
+func stringField(field string) func(map[string]interface{}) string {
+	return func(spec map[string]interface{}) string {
+		s, _ := spec[field].(string)
+		return s
+	}
+}
+
+// cloneSpec returns a shallow copy of spec.
+func cloneSpec(spec map[string]interface{}) map[string]interface{} {
+	out := make(map[string]interface{}, len(spec))
+	for k, v := range spec {
+		out[k] = v
+	}
+	return out
+}
+
+// ignoreNotFound treats a dataset that does not exist yet as holding nothing.
+func ignoreNotFound(rs []remoteResource, err error) ([]remoteResource, error) {
+	if errors.Is(err, honeycomb.ErrNotFound) {
+		return nil, nil
+	}
+	return rs, err
+}
+
+// Recipients are matched by their type and the name of what they notify, such
+// as an email address or Slack channel, by which Triggers and Burn Alerts
+// refer to them in manifests.
+var recipientKind = &resourceKind{
+	name:      "Recipient",
+	rank:      0,
+	deletable: true,
+	keyName:   "type and target",
+	key: func(spec map[string]interface{}) string {
+		var rec honeycomb.Recipient
+		if err := decodeStrict(spec, &rec); err != nil || rec.Type == "" {
+			return ""
+		}
+		if target := recipientTarget(&rec); target != "" {
+			return rec.Type + " " + target
+		}
+		return ""
+	},
+	list: func(ctx context.Context, r *refResolver, dataset string) ([]remoteResource, error) {
+		recs, err := r.listRecipients(ctx)
+		if err != nil {
+			return nil, err
+		}
+		out := make([]remoteResource, 0, len(recs))
+		for i := range recs {
+			spec, err := resourceSpec(&recs[i])
+			if err != nil {
+				return nil, err
+			}
+			out = append(out, remoteResource{ID: recs[i].ID, Spec: spec})
+		}
+		return out, nil
+	},
+	prepare: func(ctx context.Context, r *refResolver, dataset string, spec map[string]interface{}, live bool) (interface{}, error) {
+		var rec honeycomb.Recipient
+		if err := decodeStrict(spec, &rec); err != nil {
+			return nil, err
+		}
+		return &rec, rec.Validate()
+	},
+	send: func(ctx context.Context, dataset, id string, v interface{}) (string, error) {
+		if id == "" {
+			out, err := client.CreateRecipient(ctx, v.(*honeycomb.Recipient))
+			if err != nil {
+				return "", err
+			}
+			return out.ID, nil
+		}
+		_, err := client.UpdateRecipient(ctx, id, v.(*honeycomb.Recipient))
+		return id, err
+	},
+	delete: func(ctx context.Context, dataset, id string) error {
+		return client.DeleteRecipient(ctx, id)
+	},
+}
+
+// recipientTarget returns the name of what a Recipient notifies, as given as
+// the target of the Recipients of Triggers and Burn Alerts.
+func recipientTarget(rec *honeycomb.Recipient) string {
+	switch rec.Type {
+	case "email":
+		return rec.Details.EmailAddress
+	case "slack":
+		return rec.Details.SlackChannel
+	case "pagerduty":
+		return rec.Details.PagerDutyIntegrationName
+	case "webhook", "msteams":
+		return rec.Details.WebhookName
+	}
+	return ""
+}
+
+var datasetKind = &resourceKind{
+	name:          "Dataset",
+	rank:          0,
+	datasetScoped: true,
+	singleton:     true,
+	keyName:       "slug",
+	list: func(ctx context.Context, r *refResolver, dataset string) ([]remoteResource, error) {
+		d, err := client.GetDataset(ctx, dataset)
+		if err != nil {
+			return ignoreNotFound(nil, err)
+		}
+		spec, err := resourceSpec(d, "slug", "regular_columns_count", "last_written_at")
+		return []remoteResource{{ID: d.Slug, Spec: spec}}, err
+	},
+	prepare: func(ctx context.Context, r *refResolver, dataset string, spec map[string]interface{}, live bool) (interface{}, error) {
+		var d honeycomb.Dataset
+		if err := decodeStrict(spec, &d); err != nil {
+			return nil, err
+		}
+		if d.Name == "" {
+			d.Name = dataset
+		}
+		return &d, nil
+	},
+	send: func(ctx context.Context, dataset, id string, v interface{}) (string, error) {
+		if id == "" {
+			_, err := client.CreateDataset(ctx, v.(*honeycomb.Dataset))
+			return dataset, err
+		}
+		_, err := client.UpdateDataset(ctx, id, v.(*honeycomb.Dataset))
+		return id, err
+	},
+}
+
+// Columns are created by the events sent to a dataset, and are declared to
+// describe or hide them. They are never deleted, as that would lose data.
+var columnKind = &resourceKind{
+	name:          "Column",
+	rank:          1,
+	datasetScoped: true,
+	keyName:       "key_name",
+	key:           stringField("key_name"),
+	list: func(ctx context.Context, r *refResolver, dataset string) ([]remoteResource, error) {
+		cols, err := client.ListColumns(ctx, dataset)
+		if err != nil {
+			return ignoreNotFound(nil, err)
+		}
+		out := make([]remoteResource, 0, len(cols))
+		for i := range cols {
+			spec, err := resourceSpec(&cols[i], "last_written")
+			if err != nil {
+				return nil, err
+			}
+			out = append(out, remoteResource{ID: cols[i].ID, Spec: spec})
+		}
+		return out, nil
+	},
+	prepare: func(ctx context.Context, r *refResolver, dataset string, spec map[string]interface{}, live bool) (interface{}, error) {
+		var col honeycomb.Column
+		if err := decodeStrict(spec, &col); err != nil {
+			return nil, err
+		}
+		return &col, col.Validate()
+	},
+	send: func(ctx context.Context, dataset, id string, v interface{}) (string, error) {
+		if id == "" {
+			out, err := client.CreateColumn(ctx, dataset, v.(*honeycomb.Column))
+			if err != nil {
+				return "", err
+			}
+			return out.ID, nil
+		}
+		_, err := client.UpdateColumn(ctx, dataset, id, v.(*honeycomb.Column))
+		return id, err
+	},
+}
+
+var derivedColumnKind = &resourceKind{
+	name:          "DerivedColumn",
+	rank:          1,
+	datasetScoped: true,
+	deletable:     true,
+	keyName:       "alias",
+	key:           stringField("alias"),
+	list: func(ctx context.Context, r *refResolver, dataset string) ([]remoteResource, error) {
+		dcs, err := client.ListDerivedColumns(ctx, dataset)
+		if err != nil {
+			return ignoreNotFound(nil, err)
+		}
+		out := make([]remoteResource, 0, len(dcs))
+		for i := range dcs {
+			spec, err := resourceSpec(&dcs[i])
+			if err != nil {
+				return nil, err
+			}
+			out = append(out, remoteResource{ID: dcs[i].ID, Spec: spec})
+		}
+		return out, nil
+	},
+	prepare: func(ctx context.Context, r *refResolver, dataset string, spec map[string]interface{}, live bool) (interface{}, error) {
+		var dc honeycomb.DerivedColumn
+		if err := decodeStrict(spec, &dc); err != nil {
+			return nil, err
+		}
+		return &dc, dc.Validate()
+	},
+	send: func(ctx context.Context, dataset, id string, v interface{}) (string, error) {
+		if id == "" {
+			out, err := client.CreateDerivedColumn(ctx, dataset, v.(*honeycomb.DerivedColumn))
+			if err != nil {
+				return "", err
+			}
+			return out.ID, nil
+		}
+		_, err := client.UpdateDerivedColumn(ctx, dataset, id, v.(*honeycomb.DerivedColumn))
+		return id, err
+	},
+	delete: func(ctx context.Context, dataset, id string) error {
+		return client.DeleteDerivedColumn(ctx, dataset, id)
+	},
+}
+
+var markerSettingKind = &resourceKind{
+	name:          "MarkerSetting",
+	rank:          1,
+	datasetScoped: true,
+	deletable:     true,
+	keyName:       "type",
+	key:           stringField("type"),
+	list: func(ctx context.Context, r *refResolver, dataset string) ([]remoteResource, error) {
+		mss, err := client.ListMarkerSettings(ctx, dataset)
+		if err != nil {
+			return ignoreNotFound(nil, err)
+		}
+		out := make([]remoteResource, 0, len(mss))
+		for i := range mss {
+			spec, err := resourceSpec(&mss[i])
+			if err != nil {
+				return nil, err
+			}
+			out = append(out, remoteResource{ID: mss[i].ID, Spec: spec})
+		}
+		return out, nil
+	},
+	prepare: func(ctx context.Context, r *refResolver, dataset string, spec map[string]interface{}, live bool) (interface{}, error) {
+		var ms honeycomb.MarkerSetting
+		return &ms, decodeStrict(spec, &ms)
+	},
+	send: func(ctx context.Context, dataset, id string, v interface{}) (string, error) {
+		if id == "" {
+			out, err := client.CreateMarkerSetting(ctx, dataset, v.(*honeycomb.MarkerSetting))
+			if err != nil {
+				return "", err
+			}
+			return out.ID, nil
+		}
+		_, err := client.UpdateMarkerSetting(ctx, dataset, id, v.(*honeycomb.MarkerSetting))
+		return id, err
+	},
+	delete: func(ctx context.Context, dataset, id string) error {
+		return client.DeleteMarkerSetting(ctx, dataset, id)
+	},
+}
+
+var datasetDefinitionKind = &resourceKind{
+	name:          "DatasetDefinition",
+	rank:          2,
+	datasetScoped: true,
+	singleton:     true,
+	keyName:       "dataset",
+	list: func(ctx context.Context, r *refResolver, dataset string) ([]remoteResource, error) {
+		dd, err := client.GetDatasetDefinitions(ctx, dataset)
+		if err != nil {
+			return ignoreNotFound(nil, err)
+		}
+		spec, err := resourceSpec(dd)
+		return []remoteResource{{ID: dataset, Spec: spec}}, err
+	},
+	prepare: func(ctx context.Context, r *refResolver, dataset string, spec map[string]interface{}, live bool) (interface{}, error) {
+		var dd honeycomb.DatasetDefinition
+		return &dd, decodeStrict(spec, &dd)
+	},
+	send: func(ctx context.Context, dataset, id string, v interface{}) (string, error) {
+		_, err := client.UpdateDatasetDefinitions(ctx, dataset, v.(*honeycomb.DatasetDefinition))
+		return dataset, err
+	},
+}
+
+var sloKind = &resourceKind{
+	name:          "SLO",
+	rank:          3,
+	datasetScoped: true,
+	deletable:     true,
+	keyName:       "name",
+	key:           stringField("name"),
+	list: func(ctx context.Context, r *refResolver, dataset string) ([]remoteResource, error) {
+		slos, err := r.listSLOs(ctx, dataset)
+		if err != nil {
+			return nil, err
+		}
+		out := make([]remoteResource, 0, len(slos))
+		for i := range slos {
+			spec, err := resourceSpec(&slos[i])
+			if err != nil {
+				return nil, err
+			}
+			out = append(out, remoteResource{ID: slos[i].ID, Spec: spec})
+		}
+		return out, nil
+	},
+	prepare: func(ctx context.Context, r *refResolver, dataset string, spec map[string]interface{}, live bool) (interface{}, error) {
+		var s honeycomb.SLO
+		if err := decodeStrict(spec, &s); err != nil {
+			return nil, err
+		}
+		return &s, s.Validate()
+	},
+	send: func(ctx context.Context, dataset, id string, v interface{}) (string, error) {
+		if id == "" {
+			out, err := client.CreateSLO(ctx, dataset, v.(*honeycomb.SLO))
+			if err != nil {
+				return "", err
+			}
+			return out.ID, nil
+		}
+		_, err := client.UpdateSLO(ctx, dataset, id, v.(*honeycomb.SLO))
+		return id, err
+	},
+	delete: func(ctx context.Context, dataset, id string) error {
+		return client.DeleteSLO(ctx, dataset, id)
+	},
+}
+
+// Triggers give their Query inline, which the API creates along with them.
+// Those that refer to a saved Query by query_id have it inlined when listed.
+var triggerKind = &resourceKind{
+	name:          "Trigger",
+	rank:          3,
+	datasetScoped: true,
+	deletable:     true,
+	keyName:       "name",
+	key:           stringField("name"),
+	list: func(ctx context.Context, r *refResolver, dataset string) ([]remoteResource, error) {
+		ts, err := client.ListTriggers(ctx, dataset)
+		if err != nil {
+			return ignoreNotFound(nil, err)
+		}
+		out := make([]remoteResource, 0, len(ts))
+		for i := range ts {
+			spec, err := resourceSpec(&ts[i], "dataset_slug", "triggered")
+			if err != nil {
+				return nil, err
+			}
+			out = append(out, remoteResource{ID: ts[i].ID, Spec: spec})
+		}
+		return out, nil
+	},
+	expand: func(ctx context.Context, r *refResolver, dataset string, spec map[string]interface{}) error {
+		id, _ := spec["query_id"].(string)
+		if id == "" || spec["query"] != nil {
+			return nil
+		}
+		qs, err := r.querySpec(ctx, dataset, id)
+		if err != nil {
+			return err
+		}
+		spec["query"] = qs
+		return nil
+	},
+	normalize: func(ctx context.Context, r *refResolver, spec map[string]interface{}) error {
+		return r.nameRecipients(ctx, spec)
+	},
+	prepare: func(ctx context.Context, r *refResolver, dataset string, spec map[string]interface{}, live bool) (interface{}, error) {
+		spec = cloneSpec(spec)
+		if err := r.resolveRecipients(ctx, spec); err != nil {
+			return nil, err
+		}
+
+		var t honeycomb.Trigger
+		if err := decodeStrict(spec, &t); err != nil {
+			return nil, err
+		}
+		return &t, t.Validate()
+	},
+	send: func(ctx context.Context, dataset, id string, v interface{}) (string, error) {
+		if id == "" {
+			out, err := client.CreateTrigger(ctx, dataset, v.(*honeycomb.Trigger))
+			if err != nil {
+				return "", err
+			}
+			return out.ID, nil
+		}
+		_, err := client.UpdateTrigger(ctx, dataset, id, v.(*honeycomb.Trigger))
+		return id, err
+	},
+	delete: func(ctx context.Context, dataset, id string) error {
+		return client.DeleteTrigger(ctx, dataset, id)
+	},
+}
+
+// Query Annotations may give their Query inline, as query: {...} in place of
+// query_id, which is created when applied. Those created for Boards are
+// managed through the Board.
+var queryAnnotationKind = &resourceKind{
+	name:          "QueryAnnotation",
+	rank:          4,
+	datasetScoped: true,
+	deletable:     true,
+	keyName:       "name",
+	key:           stringField("name"),
+	list: func(ctx context.Context, r *refResolver, dataset string) ([]remoteResource, error) {
+		qas, err := client.ListQueryAnnotations(ctx, dataset, false)
+		if err != nil {
+			return ignoreNotFound(nil, err)
+		}
+		out := make([]remoteResource, 0, len(qas))
+		for i := range qas {
+			spec, err := resourceSpec(&qas[i], "source")
+			if err != nil {
+				return nil, err
+			}
+			out = append(out, remoteResource{ID: qas[i].ID, Spec: spec})
+		}
+		return out, nil
+	},
+	expand: func(ctx context.Context, r *refResolver, dataset string, spec map[string]interface{}) error {
+		id, _ := spec["query_id"].(string)
+		if id == "" {
+			return nil
+		}
+		qs, err := r.querySpec(ctx, dataset, id)
+		if err != nil {
+			return err
+		}
+		spec["query"] = qs
+		return nil
+	},
+	prepare: func(ctx context.Context, r *refResolver, dataset string, spec map[string]interface{}, live bool) (interface{}, error) {
+		spec = cloneSpec(spec)
+		if q, ok := spec["query"]; ok {
+			id, err := r.queryID(ctx, dataset, q, live)
+			if err != nil {
+				return nil, fmt.Errorf("query: %w", err)
+			}
+			delete(spec, "query")
+			spec["query_id"] = id
+		}
+
+		var qa honeycomb.QueryAnnotation
+		if err := decodeStrict(spec, &qa); err != nil {
+			return nil, err
+		}
+		return &qa, qa.Validate()
+	},
+	send: func(ctx context.Context, dataset, id string, v interface{}) (string, error) {
+		if id == "" {
+			out, err := client.CreateQueryAnnotation(ctx, dataset, v.(*honeycomb.QueryAnnotation))
+			if err != nil {
+				return "", err
+			}
+			return out.ID, nil
+		}
+		_, err := client.UpdateQueryAnnotation(ctx, dataset, id, v.(*honeycomb.QueryAnnotation))
+		return id, err
+	},
+	delete: func(ctx context.Context, dataset, id string) error {
+		return client.DeleteQueryAnnotation(ctx, dataset, id)
+	},
+}
+
+// Burn Alerts refer to their SLO by name in manifests, as slo: {name: ...}.
+// They have no name of their own, so are matched by their SLO and threshold.
+var burnAlertKind = &resourceKind{
+	name:          "BurnAlert",
+	rank:          4,
+	datasetScoped: true,
+	deletable:     true,
+	keyName:       "slo and threshold",
+	key: func(spec map[string]interface{}) string {
+		slo, _ := spec["slo"].(map[string]interface{})
+		name, _ := slo["name"].(string)
+		if name == "" {
+			return ""
+		}
+		switch spec["alert_type"] {
+		case "exhaustion_time":
+			return fmt.Sprintf("%s exhaustion in %vm", name, spec["exhaustion_minutes"])
+		case "budget_rate":
+			return fmt.Sprintf("%s budget rate %v per million in %vm", name,
+				spec["budget_rate_decrease_threshold_per_million"], spec["budget_rate_window_minutes"])
+		}
+		return ""
+	},
+	list: func(ctx context.Context, r *refResolver, dataset string) ([]remoteResource, error) {
+		slos, err := r.listSLOs(ctx, dataset)
+		if err != nil {
+			return nil, err
+		}
+		var out []remoteResource
+		for _, slo := range slos {
+			bas, err := client.ListBurnAlerts(ctx, dataset, slo.ID)
+			if err != nil {
+				return nil, err
+			}
+			for i := range bas {
+				spec, err := resourceSpec(&bas[i])
+				if err != nil {
+					return nil, err
+				}
+				spec["slo"] = map[string]interface{}{"name": slo.Name}
+				out = append(out, remoteResource{ID: bas[i].ID, Spec: spec})
+			}
+		}
+		return out, nil
+	},
+	normalize: func(ctx context.Context, r *refResolver, spec map[string]interface{}) error {
+		return r.nameRecipients(ctx, spec)
+	},
+	prepare: func(ctx context.Context, r *refResolver, dataset string, spec map[string]interface{}, live bool) (interface{}, error) {
+		spec = cloneSpec(spec)
+		if err := r.resolveRecipients(ctx, spec); err != nil {
+			return nil, err
+		}
+		if slo, ok := spec["slo"].(map[string]interface{}); ok {
+			if name, ok := slo["name"].(string); ok {
+				id, err := r.sloID(ctx, dataset, name, live)
+				if err != nil {
+					return nil, err
+				}
+				spec["slo"] = map[string]interface{}{"id": id}
+			}
+		}
+
+		var b honeycomb.BurnAlert
+		if err := decodeStrict(spec, &b); err != nil {
+			return nil, err
+		}
+		return &b, b.Validate()
+	},
+	send: func(ctx context.Context, dataset, id string, v interface{}) (string, error) {
+		if id == "" {
+			out, err := client.CreateBurnAlert(ctx, dataset, v.(*honeycomb.BurnAlert))
+			if err != nil {
+				return "", err
+			}
+			return out.ID, nil
+		}
+		_, err := client.UpdateBurnAlert(ctx, dataset, id, v.(*honeycomb.BurnAlert))
+		return id, err
+	},
+	delete: func(ctx context.Context, dataset, id string) error {
+		return client.DeleteBurnAlert(ctx, dataset, id)
+	},
+}
+
+// Boards may give their Queries inline, as query: {...} in place of query_id,
+// which are created in the dataset of the Board Query when applied.
+var boardKind = &resourceKind{
+	name:      "Board",
+	rank:      5,
+	deletable: true,
+	keyName:   "name",
+	key:       stringField("name"),
+	list: func(ctx context.Context, r *refResolver, dataset string) ([]remoteResource, error) {
+		boards, err := client.ListBoards(ctx)
+		if err != nil {
+			return nil, err
+		}
+		out := make([]remoteResource, 0, len(boards))
+		for i := range boards {
+			spec, err := resourceSpec(&boards[i], "links")
+			if err != nil {
+				return nil, err
+			}
+			out = append(out, remoteResource{ID: boards[i].ID, Spec: spec})
+		}
+		return out, nil
+	},
+	expand: func(ctx context.Context, r *refResolver, dataset string, spec map[string]interface{}) error {
+		queries, _ := spec["queries"].([]interface{})
+		for _, item := range queries {
+			q, ok := item.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			id, _ := q["query_id"].(string)
+			if id == "" {
+				continue
+			}
+			qs, err := r.querySpec(ctx, boardQueryDataset(q), id)
+			if err != nil {
+				return err
+			}
+			q["query"] = qs
+		}
+		return nil
+	},
+	prepare: func(ctx context.Context, r *refResolver, dataset string, spec map[string]interface{}, live bool) (interface{}, error) {
+		spec = cloneSpec(spec)
+		if queries, ok := spec["queries"].([]interface{}); ok {
+			resolved := make([]interface{}, len(queries))
+			for i, item := range queries {
+				resolved[i] = item
+				q, ok := item.(map[string]interface{})
+				if !ok || q["query"] == nil {
+					continue
+				}
+				id, err := r.queryID(ctx, boardQueryDataset(q), q["query"], live)
+				if err != nil {
+					return nil, fmt.Errorf("query %d: %w", i+1, err)
+				}
+				q = cloneSpec(q)
+				delete(q, "query")
+				q["query_id"] = id
+				resolved[i] = q
+			}
+			spec["queries"] = resolved
+		}
+
+		var b honeycomb.Board
+		return &b, decodeStrict(spec, &b)
+	},
+	send: func(ctx context.Context, dataset, id string, v interface{}) (string, error) {
+		if id == "" {
+			out, err := client.CreateBoard(ctx, v.(*honeycomb.Board))
+			if err != nil {
+				return "", err
+			}
+			return out.ID, nil
+		}
+		_, err := client.UpdateBoard(ctx, id, v.(*honeycomb.Board))
+		return id, err
+	},
+	delete: func(ctx context.Context, dataset, id string) error {
+		return client.DeleteBoard(ctx, id)
+	},
+}
+
+// boardQueryDataset returns the dataset of a Board Query.
+func boardQueryDataset(q map[string]interface{}) string {
+	if ds, _ := q["dataset"].(string); ds != "" {
+		return ds
+	}
+	return honeycomb.EnvironmentWide
+}
+
+// refResolver resolves the references between resources in manifests, which
+// are given by name or inline rather than by ID, caching what it looks up.
+type refResolver struct {
+	// The names of the SLOs declared in each dataset, which can be referred
+	// to before they exist.
+	declaredSLOs map[string]map[string]bool
+
+	slos       map[string][]honeycomb.SLO
+	queries    map[string]map[string]interface{}
+	recipients []honeycomb.Recipient
+}
+
+func newRefResolver(ms []manifest) *refResolver {
+	r := &refResolver{
+		declaredSLOs: map[string]map[string]bool{},
+		slos:         map[string][]honeycomb.SLO{},
+		queries:      map[string]map[string]interface{}{},
+	}
+	for _, m := range ms {
+		if m.Kind != sloKind.name {
+			continue
+		}
+		if r.declaredSLOs[m.Metadata.Dataset] == nil {
+			r.declaredSLOs[m.Metadata.Dataset] = map[string]bool{}
+		}
+		r.declaredSLOs[m.Metadata.Dataset][sloKind.key(m.Spec)] = true
+	}
+	return r
+}
+
+// listSLOs returns the SLOs in a dataset.
+func (r *refResolver) listSLOs(ctx context.Context, dataset string) ([]honeycomb.SLO, error) {
+	if slos, ok := r.slos[dataset]; ok {
+		return slos, nil
+	}
+	slos, err := client.ListSLOs(ctx, dataset)
+	if err != nil && !errors.Is(err, honeycomb.ErrNotFound) {
+		return nil, err
+	}
+	sort.SliceStable(slos, func(i, j int) bool { return slos[i].Name < slos[j].Name })
+	r.slos[dataset] = slos
+	return slos, nil
+}
+
+// listRecipients returns the Recipients in the environment.
+func (r *refResolver) listRecipients(ctx context.Context) ([]honeycomb.Recipient, error) {
+	if r.recipients != nil {
+		return r.recipients, nil
+	}
+	recs, err := client.ListRecipients(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if recs == nil {
+		recs = []honeycomb.Recipient{}
+	}
+	r.recipients = recs
+	return recs, nil
+}
+
+// forget drops what is cached of a kind of resource in a dataset, once one
+// has been created, so that it can be referred to.
+func (r *refResolver) forget(k *resourceKind, dataset string) {
+	switch k {
+	case sloKind:
+		delete(r.slos, dataset)
+	case recipientKind:
+		r.recipients = nil
+	}
+}
+
+// nameRecipients replaces the IDs of the Recipients of a Trigger or Burn Alert
+// with their type and target, as manifests give them. Recipients that no
+// longer exist are left as they are.
+func (r *refResolver) nameRecipients(ctx context.Context, spec map[string]interface{}) error {
+	items, ok := spec["recipients"].([]interface{})
+	if !ok {
+		return nil
+	}
+	var recs []honeycomb.Recipient
+	named := make([]interface{}, len(items))
+	for i, item := range items {
+		named[i] = item
+		nr, ok := item.(map[string]interface{})
+		id, _ := nr["id"].(string)
+		if !ok || id == "" {
+			continue
+		}
+		if recs == nil {
+			var err error
+			if recs, err = r.listRecipients(ctx); err != nil {
+				return err
+			}
+		}
+		for j := range recs {
+			if recs[j].ID != id {
+				continue
+			}
+			nr = cloneSpec(nr)
+			delete(nr, "id")
+			nr["type"] = recs[j].Type
+			nr["target"] = recipientTarget(&recs[j])
+			named[i] = nr
+		}
+	}
+	spec["recipients"] = named
+	return nil
+}
+
+// resolveRecipients replaces the type and target of the Recipients of a
+// Trigger or Burn Alert with the ID of the Recipient they name, if it exists.
+func (r *refResolver) resolveRecipients(ctx context.Context, spec map[string]interface{}) error {
+	items, ok := spec["recipients"].([]interface{})
+	if !ok {
+		return nil
+	}
+	var recs []honeycomb.Recipient
+	resolved := make([]interface{}, len(items))
+	for i, item := range items {
+		resolved[i] = item
+		nr, ok := item.(map[string]interface{})
+		typ, _ := nr["type"].(string)
+		target, _ := nr["target"].(string)
+		if !ok || nr["id"] != nil || typ == "" || target == "" {
+			continue
+		}
+		if recs == nil {
+			var err error
+			if recs, err = r.listRecipients(ctx); err != nil {
+				return err
+			}
+		}
+		for j := range recs {
+			if recs[j].Type != typ || recipientTarget(&recs[j]) != target {
+				continue
+			}
+			nr = cloneSpec(nr)
+			delete(nr, "type")
+			delete(nr, "target")
+			nr["id"] = recs[j].ID
+			resolved[i] = nr
+		}
+	}
+	spec["recipients"] = resolved
+	return nil
+}
+
+// sloID returns the ID of the SLO with the given name. An SLO that is declared
+// but does not exist yet resolves to pendingID when planning, or on a dry run.
+func (r *refResolver) sloID(ctx context.Context, dataset, name string, live bool) (string, error) {
+	slos, err := r.listSLOs(ctx, dataset)
+	if err != nil {
+		return "", err
+	}
+	for _, s := range slos {
+		if s.Name == name {
+			return s.ID, nil
+		}
+	}
+	if r.declaredSLOs[dataset][name] && (!live || dryRun) {
+		return pendingID, nil
+	}
+	return "", fmt.Errorf("SLO %q does not exist in dataset %s, and is not declared", name, dataset)
+}
+
+// querySpec returns the spec of the saved Query with the given ID.
+func (r *refResolver) querySpec(ctx context.Context, dataset, id string) (map[string]interface{}, error) {
+	key := dataset + "/" + id
+	if spec, ok := r.queries[key]; ok {
+		return spec, nil
+	}
+	q, err := client.GetQuery(ctx, dataset, id)
+	if err != nil {
+		return nil, err
+	}
+	spec, err := toSpec(q)
+	if err != nil {
+		return nil, err
+	}
+	delete(spec, "id")
+	r.queries[key] = spec
+	return spec, nil
+}
+
+// queryID validates an inline Query and, if live, creates it to return its ID.
+func (r *refResolver) queryID(ctx context.Context, dataset string, spec interface{}, live bool) (string, error) {
+	var q honeycomb.Query
+	if err := decodeStrict(spec, &q); err != nil {
+		return "", err
+	}
+	if err := q.Validate(); err != nil {
+		return "", err
+	}
+	if !live {
+		return pendingID, nil
+	}
+	out, err := client.CreateQuery(ctx, dataset, &q)
+	if err != nil {
+		return "", err
+	}
+	if out.ID == "" {
+		return pendingID, nil
+	}
+	return out.ID, nil
+}
@@ -0,0 +1,194 @@
+package cmd
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+const testRecipients = `[
+	{"id": "r1", "type": "email", "details": {"email_address": "oncall@example.com"}},
+	{"id": "r2", "type": "slack", "details": {"slack_channel": "#alerts"}},
+	{"id": "r3", "type": "pagerduty", "details": {"pagerduty_integration_name": "Platform", "pagerduty_integration_key": "key"}}
+]`
+
+func TestRefResolverRecipients(t *testing.T) {
+	tests := []struct {
+		name     string
+		byID     string
+		byTarget string
+	}{
+		{
+			name:     "email",
+			byID:     `{"recipients": [{"id": "r1"}]}`,
+			byTarget: `{"recipients": [{"type": "email", "target": "oncall@example.com"}]}`,
+		},
+		{
+			name:     "other fields kept",
+			byID:     `{"recipients": [{"id": "r3", "details": {"pagerduty_severity": "critical"}}]}`,
+			byTarget: `{"recipients": [{"type": "pagerduty", "target": "Platform", "details": {"pagerduty_severity": "critical"}}]}`,
+		},
+		{
+			name:     "several",
+			byID:     `{"recipients": [{"id": "r2"}, {"id": "r1"}]}`,
+			byTarget: `{"recipients": [{"type": "slack", "target": "#alerts"}, {"type": "email", "target": "oncall@example.com"}]}`,
+		},
+		{
+			name:     "no recipients",
+			byID:     `{"name": "a"}`,
+			byTarget: `{"name": "a"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			newFakeAPI(t, map[string]string{"GET /1/recipients": testRecipients})
+			r := newRefResolver(nil)
+
+			named := specValue(t, tt.byID).(map[string]interface{})
+			if err := r.nameRecipients(context.Background(), named); err != nil {
+				t.Fatalf("nameRecipients(): %v", err)
+			}
+			if want := specValue(t, tt.byTarget); !reflect.DeepEqual(named, want) {
+				t.Errorf("nameRecipients()\n got %v\nwant %v", named, want)
+			}
+
+			resolved := specValue(t, tt.byTarget).(map[string]interface{})
+			if err := r.resolveRecipients(context.Background(), resolved); err != nil {
+				t.Fatalf("resolveRecipients(): %v", err)
+			}
+			if want := specValue(t, tt.byID); !reflect.DeepEqual(resolved, want) {
+				t.Errorf("resolveRecipients()\n got %v\nwant %v", resolved, want)
+			}
+		})
+	}
+}
+
+func TestRefResolverUnknownRecipients(t *testing.T) {
+	newFakeAPI(t, map[string]string{"GET /1/recipients": testRecipients})
+	r := newRefResolver(nil)
+
+	// Recipients that do not exist are left as they are, for the API or
+	// checkPromoteRecipients to report.
+	named := specValue(t, `{"recipients": [{"id": "gone"}]}`).(map[string]interface{})
+	if err := r.nameRecipients(context.Background(), named); err != nil {
+		t.Fatalf("nameRecipients(): %v", err)
+	}
+	if want := specValue(t, `{"recipients": [{"id": "gone"}]}`); !reflect.DeepEqual(named, want) {
+		t.Errorf("nameRecipients() = %v, want %v", named, want)
+	}
+
+	resolved := specValue(t, `{"recipients": [{"type": "email", "target": "nobody@example.com"}]}`).(map[string]interface{})
+	if err := r.resolveRecipients(context.Background(), resolved); err != nil {
+		t.Fatalf("resolveRecipients(): %v", err)
+	}
+	if want := specValue(t, `{"recipients": [{"type": "email", "target": "nobody@example.com"}]}`); !reflect.DeepEqual(resolved, want) {
+		t.Errorf("resolveRecipients() = %v, want %v", resolved, want)
+	}
+}
+
+func TestRefResolverCachesRecipients(t *testing.T) {
+	api := newFakeAPI(t, map[string]string{"GET /1/recipients": testRecipients})
+	r := newRefResolver(nil)
+	ctx := context.Background()
+
+	for i := 0; i < 2; i++ {
+		if _, err := r.listRecipients(ctx); err != nil {
+			t.Fatalf("listRecipients(): %v", err)
+		}
+	}
+	if n := api.count("GET /1/recipients"); n != 1 {
+		t.Errorf("listed Recipients %d times, want 1", n)
+	}
+
+	// Once a Recipient is created, it must be listed again to be found.
+	r.forget(recipientKind, "")
+	if _, err := r.listRecipients(ctx); err != nil {
+		t.Fatalf("listRecipients(): %v", err)
+	}
+	if n := api.count("GET /1/recipients"); n != 2 {
+		t.Errorf("listed Recipients %d times after forget, want 2", n)
+	}
+}
+
+func TestRefResolverSLOID(t *testing.T) {
+	declared := []manifest{testManifest("SLO", "api", "Latency", `{"name": "Latency"}`)}
+
+	tests := []struct {
+		name    string
+		slo     string
+		live    bool
+		want    string
+		wantErr string
+	}{
+		{name: "exists", slo: "Availability", want: "s1"},
+		{name: "exists, live", slo: "Availability", live: true, want: "s1"},
+		{name: "declared", slo: "Latency", want: pendingID},
+		{name: "declared, live", slo: "Latency", live: true, wantErr: `SLO "Latency" does not exist in dataset api, and is not declared`},
+		{name: "unknown", slo: "Errors", wantErr: `SLO "Errors" does not exist in dataset api, and is not declared`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			newFakeAPI(t, map[string]string{"GET /1/slos/api": `[{"id": "s1", "name": "Availability"}]`})
+			r := newRefResolver(declared)
+
+			got, err := r.sloID(context.Background(), "api", tt.slo, tt.live)
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Errorf("sloID() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("sloID(): %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("sloID() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRefResolverQuerySpec(t *testing.T) {
+	api := newFakeAPI(t, map[string]string{
+		"GET /1/queries/api/q1": `{"id": "q1", "calculations": [{"op": "COUNT"}], "time_range": 7200}`,
+	})
+	r := newRefResolver(nil)
+
+	for i := 0; i < 2; i++ {
+		spec, err := r.querySpec(context.Background(), "api", "q1")
+		if err != nil {
+			t.Fatalf("querySpec(): %v", err)
+		}
+		if want := specValue(t, `{"calculations": [{"op": "COUNT"}], "time_range": 7200}`); !reflect.DeepEqual(spec, want) {
+			t.Errorf("querySpec() = %v, want %v", spec, want)
+		}
+	}
+	if n := api.count("GET /1/queries/api/q1"); n != 1 {
+		t.Errorf("read the Query %d times, want 1", n)
+	}
+}
+
+func TestRefResolverQueryID(t *testing.T) {
+	api := newFakeAPI(t, map[string]string{"POST /1/queries/api": `{"id": "q9"}`})
+	r := newRefResolver(nil)
+	ctx := context.Background()
+	query := specValue(t, `{"calculations": [{"op": "COUNT"}], "time_range": 7200}`)
+
+	if id, err := r.queryID(ctx, "api", query, false); err != nil || id != pendingID {
+		t.Errorf("queryID() when planning = %q, %v, want %q", id, err, pendingID)
+	}
+	if n := api.count("POST /1/queries/api"); n != 0 {
+		t.Errorf("created %d Queries when planning, want 0", n)
+	}
+
+	if id, err := r.queryID(ctx, "api", query, true); err != nil || id != "q9" {
+		t.Errorf("queryID() = %q, %v, want q9", id, err)
+	}
+
+	invalid := specValue(t, `{"calculations": [{"op": "P99"}]}`)
+	if _, err := r.queryID(ctx, "api", invalid, false); err == nil {
+		t.Errorf("queryID() accepted a P99 without a column")
+	}
+}
@@ -0,0 +1,229 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"golang.org/x/exp/slices"
+
+	"github.com/adz-anz/honeybadger/honeycomb"
+)
+
+// restoredResource is a resource in a backup, and what restore did with it.
+type restoredResource struct {
+	Kind    string `json:"kind"`
+	Dataset string `json:"dataset,omitempty"`
+	Name    string `json:"name"`
+	Action  string `json:"action"`
+
+	// The ID of the resource when it was backed up, and its ID now, which
+	// differs when it was created again.
+	BackupID string `json:"backup_id"`
+	ID       string `json:"id"`
+}
+
+// CUSTOM
+// Recreate the resources in a backup archive that are missing.
+func newRestoreCmd() *cobra.Command {
+	var (
+		rOnly   []string
+		rRevert bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "restore <archive>",
+		Short: "Recreate resources from a backup archive.",
+		Long: "Recreate the resources in an archive written by backup that no longer exist in\n" +
+			"the environment, such as a Board deleted by mistake. Resources are matched by\n" +
+			"their names, as apply matches manifests, and those that have changed since the\n" +
+			"backup are reported but left alone unless --revert is given, which updates them\n" +
+			"to match it.\n" +
+			"\n" +
+			"The checksums in the archive are checked before anything is restored. --only\n" +
+			"restricts the restore to the given kinds, such as board,trigger. The changes\n" +
+			"are shown on stderr before they are made, and with --dry-run they are previewed\n" +
+			"instead.\n" +
+			"\n" +
+			"Honeycomb assigns new IDs to the resources it recreates, so the ID each had in\n" +
+			"the backup is reported beside its ID now. Recipients backed up with masked\n" +
+			"secrets cannot be recreated; take the backup with --show-secrets to restore them.",
+		Example: "  honeybadger restore honeybadger-backup-acme-prod-20260101T000000Z.tar.gz --dry-run\n" +
+			"  honeybadger restore backups/prod.tar.gz --only board,trigger --revert",
+		Annotations: map[string]string{annotationLiveReads: ""},
+		Args:        cobra.ExactArgs(1),
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			for _, name := range rOnly {
+				if kindByLooseName(name) == nil {
+					return fmt.Errorf("unknown kind %q in --only, must be one of %s", name, strings.Join(kindNames(), ", "))
+				}
+			}
+			return nil
+		},
+		Run: func(cmd *cobra.Command, args []string) {
+			ctx := cmd.Context()
+			fields := log.Fields{
+				"_function": "newRestoreCmd",
+				"archive":   args[0],
+			}
+
+			bm, ms, err := readBackup(args[0])
+			if err != nil {
+				fatal(fields, err, "Error received when attempting to read the backup.")
+			}
+			backupIDs := map[string]string{}
+			for i, e := range bm.Resources {
+				backupIDs[ms[i].ref().String()] = e.ID
+			}
+			if len(rOnly) > 0 {
+				var kinds []string
+				for _, name := range rOnly {
+					kinds = append(kinds, kindByLooseName(name).name)
+				}
+				ms = slices.DeleteFunc(ms, func(m manifest) bool { return !slices.Contains(kinds, m.Kind) })
+			}
+
+			r := newRefResolver(ms)
+			plan, changed, err := planRestore(ctx, ms, r, rRevert)
+			if err != nil {
+				fatal(fields, err, "Error received when attempting to plan the restore.")
+			}
+			if plan.Environment != bm.Environment {
+				fmt.Fprintf(os.Stderr, "Restoring a backup of %s, taken %s, to %s.\n\n",
+					bm.Environment, bm.CreatedAt.Format("2006-01-02 15:04:05 MST"), plan.Environment)
+			}
+			writePlan(os.Stderr, plan, false)
+			writeChanged(os.Stderr, changed)
+
+			state := &applyState{Environments: map[string]map[string]ownedResource{}}
+			if err := applyPlan(ctx, plan, state, r); err != nil {
+				fatal(fields, err, "Error received when attempting to restore resources.")
+			}
+
+			restored := []restoredResource{}
+			add := func(actions []planAction, action string) {
+				for _, a := range actions {
+					act := action
+					if act == "" {
+						act = a.Action
+					}
+					restored = append(restored, restoredResource{
+						Kind:     a.Kind,
+						Dataset:  a.Dataset,
+						Name:     a.Name,
+						Action:   act,
+						BackupID: backupIDs[a.ref().String()],
+						ID:       a.ID,
+					})
+				}
+			}
+			add(plan.Actions, "")
+			add(changed, "changed")
+			add(plan.inSync, "unchanged")
+			printResponse(restored)
+			writeRemapped(os.Stderr, restored)
+		},
+	}
+
+	cmd.Flags().StringSliceVar(&rOnly, "only", nil,
+		"The kinds of resources to restore, such as board,trigger. Defaults to all of them.")
+	cmd.Flags().BoolVar(&rRevert, "revert", false,
+		"Update the resources that have changed since the backup to match it.")
+
+	return cmd
+}
+
+// planRestore plans the creation of the resources in a backup that are
+// missing, and with revert, the updates of those that have changed. Without
+// it, the resources that have changed are returned apart from the plan.
+func planRestore(ctx context.Context, ms []manifest, r *refResolver, revert bool) (*resourcePlan, []planAction, error) {
+	if err := validateManifests(ms); err != nil {
+		return nil, nil, err
+	}
+	state := &applyState{Environments: map[string]map[string]ownedResource{}}
+	plan, err := buildPlan(ctx, ms, state, r, false)
+	if err != nil {
+		return nil, nil, err
+	}
+	updateExisting(plan)
+
+	var changed []planAction
+	var problems []string
+	actions := plan.Actions[:0]
+	for _, a := range plan.Actions {
+		if a.Action == planUpdate && !revert {
+			changed = append(changed, a)
+			continue
+		}
+		if containsRedacted(a.declared) {
+			problems = append(problems, fmt.Sprintf("%s: %s was backed up with its secrets masked, and cannot be restored", a.source, a.ref()))
+		}
+		actions = append(actions, a)
+	}
+	plan.Actions = actions
+	if len(problems) > 0 {
+		return nil, nil, &honeycomb.ValidationError{Resource: "backup", Problems: problems}
+	}
+	return plan, changed, nil
+}
+
+// containsRedacted reports whether a spec holds a masked secret.
+func containsRedacted(v interface{}) bool {
+	switch v := v.(type) {
+	case string:
+		return v == honeycomb.RedactedValue
+	case map[string]interface{}:
+		for _, item := range v {
+			if containsRedacted(item) {
+				return true
+			}
+		}
+	case []interface{}:
+		for _, item := range v {
+			if containsRedacted(item) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// writeChanged writes the resources that have changed since the backup and
+// are left alone.
+func writeChanged(w io.Writer, changed []planAction) {
+	if len(changed) == 0 {
+		return
+	}
+	for _, a := range changed {
+		fmt.Fprintf(w, "~ %s (%s) has changed since the backup\n", a.ref(), a.ID)
+		for _, c := range a.Changes {
+			fmt.Fprintf(w, "    %s: backed up %s, found %s\n", c.Path, formatSpecValue(c.To), formatSpecValue(c.From))
+		}
+	}
+	fmt.Fprintf(w, "\nLeft %d changed resources alone. Use --revert to restore them as well.\n", len(changed))
+}
+
+// writeRemapped writes the resources given new IDs by the restore.
+func writeRemapped(w io.Writer, restored []restoredResource) {
+	if dryRun {
+		return
+	}
+	var remapped []restoredResource
+	for _, r := range restored {
+		if r.Action == planCreate && r.ID != r.BackupID {
+			remapped = append(remapped, r)
+		}
+	}
+	if len(remapped) == 0 {
+		return
+	}
+	fmt.Fprintln(w, "\nResources given new IDs:")
+	for _, r := range remapped {
+		ref := resourceRef{Kind: r.Kind, Dataset: r.Dataset, Name: r.Name}
+		fmt.Fprintf(w, "  %s: %s → %s\n", ref, r.BackupID, r.ID)
+	}
+}
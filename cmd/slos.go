@@ -0,0 +1,348 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/adz-anz/honeybadger/honeycomb"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// sloFlags are the flags shared by the SLO create and update commands. Only
+// flags that were explicitly set are applied, so that they can be used to
+// override an SLO read from --from-file or fetched for an update.
+type sloFlags struct {
+	fromFile         string
+	name             string
+	description      string
+	sliAlias         string
+	timePeriodDays   int
+	target           float64
+	targetPerMillion int
+}
+
+func (f *sloFlags) register(cmd *cobra.Command) {
+	cmd.Flags().StringVarP(&f.fromFile, "from-file", "f", "",
+		"A JSON or YAML file holding the SLO, or - for stdin. Other flags override values in the file.")
+	cmd.Flags().StringVarP(&f.name, "name", "n", "",
+		"The name of the SLO.")
+	cmd.Flags().StringVar(&f.description, "description", "",
+		"A description of the SLO.")
+	cmd.Flags().StringVar(&f.sliAlias, "sli", "",
+		"The alias of the Derived Column used as the SLI. It must already exist in the dataset or environment-wide.")
+	cmd.Flags().IntVar(&f.timePeriodDays, "time-period-days", 30,
+		"The length of the rolling time period, in days, between 1 and 90.")
+	cmd.Flags().Float64Var(&f.target, "target", 0,
+		"The target percentage of successful events, e.g. 99.9.")
+	cmd.Flags().IntVar(&f.targetPerMillion, "target-per-million", 0,
+		"The target number of successful events per million, e.g. 999000. Cannot be used with --target.")
+	cmd.MarkFlagsMutuallyExclusive("target", "target-per-million")
+}
+
+// apply overlays the flags that were set onto s, reading --from-file first if
+// it was given.
+func (f *sloFlags) apply(cmd *cobra.Command, s *honeycomb.SLO) error {
+	if f.fromFile != "" {
+		*s = honeycomb.SLO{}
+		if err := readResourceFile(f.fromFile, s); err != nil {
+			return err
+		}
+	}
+
+	flags := cmd.Flags()
+	if flags.Changed("name") {
+		s.Name = f.name
+	}
+	if flags.Changed("description") {
+		s.Description = f.description
+	}
+	if flags.Changed("sli") {
+		s.SLI.Alias = f.sliAlias
+	}
+	if flags.Changed("time-period-days") || s.TimePeriodDays == 0 {
+		s.TimePeriodDays = f.timePeriodDays
+	}
+	if flags.Changed("target") {
+		if f.target <= 0 || f.target >= 100 {
+			return fmt.Errorf("--target %g must be a percentage between 0 and 100", f.target)
+		}
+		s.TargetPerMillion = honeycomb.PercentToPerMillion(f.target)
+	}
+	if flags.Changed("target-per-million") {
+		s.TargetPerMillion = f.targetPerMillion
+	}
+
+	return nil
+}
+
+// checkSLIExists exits if the SLI of an SLO does not name a Derived Column in
+// the target dataset or the environment, as the API would otherwise reject
+// the SLO with a less helpful error.
+func checkSLIExists(cmd *cobra.Command, function string, s *honeycomb.SLO) {
+	_, err := client.GetDerivedColumnByAlias(cmd.Context(), targetDataset, s.SLI.Alias)
+	if errors.Is(err, honeycomb.ErrNotFound) && targetDataset != honeycomb.EnvironmentWide {
+		_, err = client.GetDerivedColumnByAlias(cmd.Context(), honeycomb.EnvironmentWide, s.SLI.Alias)
+	}
+	if errors.Is(err, honeycomb.ErrNotFound) {
+		err = &honeycomb.ValidationError{
+			Resource: "slo",
+			Problems: []string{fmt.Sprintf("sli derived column %q does not exist in dataset %q or environment-wide", s.SLI.Alias, targetDataset)},
+		}
+	}
+	if err != nil {
+		fatal(log.Fields{
+			"_function": function,
+			"dataset":   targetDataset,
+			"sli":       s.SLI.Alias,
+		}, err, "Error received when attempting to validate an SLO.")
+	}
+}
+
+// SLOs
+// https://docs.honeycomb.io/api/tag/SLOs
+func newSLOsCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "slos",
+		Aliases: []string{"s"},
+		Short:   "Manage SLOs",
+		Long: "Service Level Objectives (SLOs) track the proportion of qualified events that\n" +
+			"succeed, as decided by a Service Level Indicator (SLI), against a target over a\n" +
+			"rolling time period. The SLI is a Derived Column that must already exist.\n" +
+			"\n" +
+			"These commands allow you to list, create, update, and delete SLOs.",
+	}
+
+	cmd.PersistentFlags().StringVarP(&targetDataset, "dataset", "d", honeycomb.EnvironmentWide,
+		"The dataset slug or use __all__ (or omit) for endpoints that support environment-wide operations.")
+
+	cmd.AddCommand(
+		newSLOsCreateCmd(),
+		newSLOsListCmd(),
+		newSLOsGetCmd(),
+		newSLOsUpdateCmd(),
+		newSLOsDeleteCmd(),
+	)
+
+	return cmd
+}
+
+// Create an SLO
+// https://docs.honeycomb.io/api/tag/SLOs#operation/createSlo
+func newSLOsCreateCmd() *cobra.Command {
+	var f sloFlags
+
+	cmd := &cobra.Command{
+		Use:     "create",
+		Aliases: []string{"add", "new"},
+		Short:   "Create an SLO in the specified dataset.",
+		Long: "Create an SLO in the specified dataset.\n" +
+			"\n" +
+			"One of --target or --target-per-million is required, unless the SLO is read\n" +
+			"from --from-file. The SLI Derived Column is looked up before the SLO is sent,\n" +
+			"and the SLO is not created if it does not exist.",
+		Example: "  honeybadger slos create -d my-service -n \"Availability\" --sli is_success --target 99.9\n" +
+			"  honeybadger slos create -d my-service -f slo.yaml",
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			flags := cmd.Flags()
+			if f.fromFile == "" && !flags.Changed("target") && !flags.Changed("target-per-million") {
+				return fmt.Errorf("one of --target or --target-per-million is required")
+			}
+			return nil
+		},
+		Run: func(cmd *cobra.Command, args []string) {
+			var s honeycomb.SLO
+			if err := f.apply(cmd, &s); err != nil {
+				fatal(log.Fields{
+					"_function": "newSLOsCreateCmd",
+					"from_file": f.fromFile,
+				}, err, "Error received when attempting to read an SLO.")
+			}
+
+			if err := s.Validate(); err != nil {
+				fatal(log.Fields{
+					"_function": "newSLOsCreateCmd",
+					"slo":       s,
+				}, err, "Error received when attempting to validate an SLO.")
+			}
+			checkSLIExists(cmd, "newSLOsCreateCmd", &s)
+
+			created, err := client.CreateSLO(cmd.Context(), targetDataset, &s)
+			if err != nil {
+				fatal(log.Fields{
+					"_function": "newSLOsCreateCmd",
+					"dataset":   targetDataset,
+					"slo":       s,
+				}, err, "Error received when attempting to create a new SLO.")
+			}
+
+			printResponse(created)
+		},
+	}
+
+	f.register(cmd)
+
+	return cmd
+}
+
+// List All SLOs
+// https://docs.honeycomb.io/api/tag/SLOs#operation/listSlos
+func newSLOsListCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "list",
+		Aliases: []string{"ls"},
+		Short:   "List all SLOs in the specified dataset.",
+		Long:    "List all SLOs in the specified dataset.",
+		Run: func(cmd *cobra.Command, args []string) {
+			slos, err := client.ListSLOs(cmd.Context(), targetDataset)
+			if err != nil {
+				fatal(log.Fields{
+					"_function": "newSLOsListCmd",
+					"dataset":   targetDataset,
+				}, err, "Error received when attempting to list all SLOs.")
+			}
+
+			printResponse(slos)
+		},
+	}
+
+	return cmd
+}
+
+// Get an SLO
+// https://docs.honeycomb.io/api/tag/SLOs#operation/getSlo
+func newSLOsGetCmd() *cobra.Command {
+	var (
+		sID string
+	)
+
+	cmd := &cobra.Command{
+		Use:     "get",
+		Aliases: []string{},
+		Short:   "Get a single SLO by ID.",
+		Long:    "Get a single SLO by ID.",
+		Run: func(cmd *cobra.Command, args []string) {
+			s, err := client.GetSLO(cmd.Context(), targetDataset, sID)
+			if err != nil {
+				fatal(log.Fields{
+					"_function": "newSLOsGetCmd",
+					"dataset":   targetDataset,
+					"slo_id":    sID,
+				}, err, "Error received when attempting to get an SLO.")
+			}
+
+			printResponse(s)
+		},
+	}
+
+	cmd.Flags().StringVarP(&sID, "id", "i", "", "The unique identifier (ID) of an SLO.")
+	cmd.MarkFlagRequired("id")
+
+	return cmd
+}
+
+// Update an SLO
+// https://docs.honeycomb.io/api/tag/SLOs#operation/updateSlo
+func newSLOsUpdateCmd() *cobra.Command {
+	var (
+		sID string
+		f   sloFlags
+	)
+
+	cmd := &cobra.Command{
+		Use:     "update",
+		Aliases: []string{"up", "edit", "modify", "change", "set"},
+		Short:   "Update an SLO by ID.",
+		Long: "Update an SLO by ID.\n" +
+			"\n" +
+			"Without --from-file, the existing SLO is fetched and only the values given as flags\n" +
+			"are changed. With --from-file, the SLO is replaced by the contents of the file, with\n" +
+			"any flags applied over it. A changed SLI is looked up before the SLO is sent.",
+		Annotations: map[string]string{annotationLiveReads: ""},
+		Run: func(cmd *cobra.Command, args []string) {
+			var s honeycomb.SLO
+			if f.fromFile == "" {
+				existing, err := client.GetSLO(cmd.Context(), targetDataset, sID)
+				if err != nil {
+					fatal(log.Fields{
+						"_function": "newSLOsUpdateCmd",
+						"dataset":   targetDataset,
+						"slo_id":    sID,
+					}, err, "Error received when attempting to get the SLO to update.")
+				}
+				s = *existing
+			}
+			previousSLI := s.SLI.Alias
+
+			if err := f.apply(cmd, &s); err != nil {
+				fatal(log.Fields{
+					"_function": "newSLOsUpdateCmd",
+					"from_file": f.fromFile,
+				}, err, "Error received when attempting to read an SLO.")
+			}
+
+			// Read only fields are not accepted in an update.
+			s.ID, s.CreatedAt, s.UpdatedAt = "", nil, nil
+
+			if err := s.Validate(); err != nil {
+				fatal(log.Fields{
+					"_function": "newSLOsUpdateCmd",
+					"slo":       s,
+				}, err, "Error received when attempting to validate an SLO.")
+			}
+			if s.SLI.Alias != previousSLI {
+				checkSLIExists(cmd, "newSLOsUpdateCmd", &s)
+			}
+
+			updated, err := client.UpdateSLO(cmd.Context(), targetDataset, sID, &s)
+			if err != nil {
+				fatal(log.Fields{
+					"_function": "newSLOsUpdateCmd",
+					"dataset":   targetDataset,
+					"slo_id":    sID,
+					"slo":       s,
+				}, err, "Error received when attempting to update an existing SLO.")
+			}
+
+			printResponse(updated)
+		},
+	}
+
+	cmd.Flags().StringVarP(&sID, "id", "i", "", "The unique identifier (ID) of an SLO.")
+	cmd.MarkFlagRequired("id")
+	f.register(cmd)
+
+	return cmd
+}
+
+// Delete an SLO
+// https://docs.honeycomb.io/api/tag/SLOs#operation/deleteSlo
+func newSLOsDeleteCmd() *cobra.Command {
+	var (
+		sID string
+	)
+
+	cmd := &cobra.Command{
+		Use:     "delete",
+		Aliases: []string{"rm", "remove", "del"},
+		Short:   "Delete an SLO by ID.",
+		Long:    "Delete an SLO by ID. Any Burn Alerts for the SLO are deleted with it.",
+		Run: func(cmd *cobra.Command, args []string) {
+			err := client.DeleteSLO(cmd.Context(), targetDataset, sID)
+			if err != nil {
+				fatal(log.Fields{
+					"_function": "newSLOsDeleteCmd",
+					"dataset":   targetDataset,
+					"slo_id":    sID,
+				}, err, "Error received when attempting to delete an existing SLO.")
+			}
+		},
+	}
+
+	cmd.Flags().StringVarP(&sID, "id", "i", "", "The unique identifier (ID) of an SLO.")
+	cmd.MarkFlagRequired("id")
+
+	return cmd
+}
@@ -0,0 +1,8 @@
+//go:build !unix
+
+package cmd
+
+// ttyWidth returns 0, as the terminal width is not known on this platform.
+func ttyWidth() int {
+	return 0
+}
@@ -0,0 +1,367 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/adz-anz/honeybadger/honeycomb"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// triggerFlags are the flags shared by the trigger create and update commands.
+// Only flags that were explicitly set are applied, so that they can be used to
+// override a trigger read from --from-file or fetched for an update.
+type triggerFlags struct {
+	fromFile       string
+	name           string
+	description    string
+	disabled       bool
+	queryID        string
+	alertType      string
+	frequency      int
+	thresholdOp    string
+	thresholdValue float64
+	exceededLimit  int
+	recipients     []string
+	windowDays     []string
+	windowStart    string
+	windowEnd      string
+}
+
+func (f *triggerFlags) register(cmd *cobra.Command) {
+	cmd.Flags().StringVarP(&f.fromFile, "from-file", "f", "",
+		"A JSON or YAML file holding the Trigger, or - for stdin. Other flags override values in the file.")
+	cmd.Flags().StringVarP(&f.name, "name", "n", "",
+		"The name of the Trigger.")
+	cmd.Flags().StringVar(&f.description, "description", "",
+		"A description of the Trigger.")
+	cmd.Flags().BoolVar(&f.disabled, "disabled", false,
+		"Disable the Trigger, so that it is not evaluated and sends no alerts.")
+	cmd.Flags().StringVarP(&f.queryID, "query-id", "q", "",
+		"The ID of a saved Query to evaluate. An inline query can be given with --from-file instead.")
+	cmd.Flags().StringVar(&f.alertType, "alert-type", "",
+		"Whether to alert only when the Trigger changes state, or on every evaluation where the threshold is met. Enum: \"on_change\" \"on_true\"")
+	cmd.Flags().IntVar(&f.frequency, "frequency", 0,
+		"How often, in seconds, to evaluate the Trigger. Must be a multiple of 60 between 60 and 86400.")
+	cmd.Flags().StringVar(&f.thresholdOp, "threshold-op", "",
+		"The comparison against the threshold. Enum: \">\" \">=\" \"<\" \"<=\"")
+	cmd.Flags().Float64Var(&f.thresholdValue, "threshold-value", 0,
+		"The value to compare the result of the Trigger's calculation against.")
+	cmd.Flags().IntVar(&f.exceededLimit, "exceeded-limit", 0,
+		"The number of times the threshold must be met before an alert is sent, between 1 and 5.")
+	cmd.Flags().StringSliceVar(&f.recipients, "recipient", nil,
+		"The ID of a Recipient to notify when the Trigger fires. Give ID:severity to set the severity of a PagerDuty incident. Can be repeated, and replaces any existing Recipients.")
+	cmd.Flags().StringSliceVar(&f.windowDays, "window-days", nil,
+		"Only evaluate the Trigger on these days of the week, e.g. monday,tuesday.")
+	cmd.Flags().StringVar(&f.windowStart, "window-start", "",
+		"Only evaluate the Trigger after this UTC time, in HH:mm format.")
+	cmd.Flags().StringVar(&f.windowEnd, "window-end", "",
+		"Only evaluate the Trigger before this UTC time, in HH:mm format.")
+}
+
+// apply overlays the flags that were set onto t, reading --from-file first if
+// it was given.
+func (f *triggerFlags) apply(cmd *cobra.Command, t *honeycomb.Trigger) error {
+	if f.fromFile != "" {
+		*t = honeycomb.Trigger{}
+		if err := readResourceFile(f.fromFile, t); err != nil {
+			return err
+		}
+	}
+
+	flags := cmd.Flags()
+	if flags.Changed("name") {
+		t.Name = f.name
+	}
+	if flags.Changed("description") {
+		t.Description = f.description
+	}
+	if flags.Changed("disabled") {
+		t.Disabled = f.disabled
+	}
+	if flags.Changed("query-id") {
+		t.QueryID = f.queryID
+		t.Query = nil
+	}
+	if flags.Changed("alert-type") {
+		t.AlertType = f.alertType
+	}
+	if flags.Changed("frequency") {
+		t.Frequency = f.frequency
+	}
+	if flags.Changed("threshold-op") || flags.Changed("threshold-value") || flags.Changed("exceeded-limit") {
+		if t.Threshold == nil {
+			t.Threshold = &honeycomb.TriggerThreshold{}
+		}
+		if flags.Changed("threshold-op") {
+			t.Threshold.Op = f.thresholdOp
+		}
+		if flags.Changed("threshold-value") {
+			t.Threshold.Value = f.thresholdValue
+		}
+		if flags.Changed("exceeded-limit") {
+			t.Threshold.ExceededLimit = f.exceededLimit
+		}
+	}
+	if flags.Changed("recipient") {
+		t.Recipients = parseRecipientFlags(f.recipients)
+	}
+	if flags.Changed("window-days") || flags.Changed("window-start") || flags.Changed("window-end") {
+		if t.EvaluationSchedule == nil {
+			t.EvaluationSchedule = &honeycomb.TriggerEvaluationSchedule{}
+		}
+		t.EvaluationScheduleType = "window"
+		if flags.Changed("window-days") {
+			t.EvaluationSchedule.Window.DaysOfWeek = f.windowDays
+		}
+		if flags.Changed("window-start") {
+			t.EvaluationSchedule.Window.StartTime = f.windowStart
+		}
+		if flags.Changed("window-end") {
+			t.EvaluationSchedule.Window.EndTime = f.windowEnd
+		}
+	}
+
+	return nil
+}
+
+// Triggers
+// https://docs.honeycomb.io/api/tag/Triggers
+func newTriggersCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "triggers",
+		Aliases: []string{"t"},
+		Short:   "Manage Triggers",
+		Long: "Triggers let you receive notifications when your data in Honeycomb crosses the\n" +
+			"thresholds that you configure. The graph on which to alert is as flexible as a\n" +
+			"Honeycomb query, which helps reduce false positives due to known errors.\n" +
+			"\n" +
+			"These commands allow you to list, create, update, and delete Triggers.",
+	}
+
+	cmd.PersistentFlags().StringVarP(&targetDataset, "dataset", "d", honeycomb.EnvironmentWide,
+		"The dataset slug or use __all__ (or omit) for endpoints that support environment-wide operations.")
+
+	cmd.AddCommand(
+		newTriggersCreateCmd(),
+		newTriggersListCmd(),
+		newTriggersGetCmd(),
+		newTriggersUpdateCmd(),
+		newTriggersDeleteCmd(),
+	)
+
+	return cmd
+}
+
+// Create a Trigger
+// https://docs.honeycomb.io/api/tag/Triggers#operation/createTrigger
+func newTriggersCreateCmd() *cobra.Command {
+	var f triggerFlags
+
+	cmd := &cobra.Command{
+		Use:     "create",
+		Aliases: []string{"add", "new"},
+		Short:   "Create a Trigger in the specified dataset.",
+		Long: "Create a Trigger in the specified dataset.\n" +
+			"\n" +
+			"Trigger definitions, particularly inline queries, are too rich for flags alone. Use\n" +
+			"--from-file to provide the Trigger as JSON or YAML, and flags to override values in\n" +
+			"the file.",
+		Example: "  honeybadger triggers create -d my-service -n \"Slow requests\" -q abc123 \\\n" +
+			"    --threshold-op \">\" --threshold-value 500 --frequency 300 --recipient def456\n" +
+			"  honeybadger triggers create -d my-service -f trigger.yaml",
+		Run: func(cmd *cobra.Command, args []string) {
+			var t honeycomb.Trigger
+			if err := f.apply(cmd, &t); err != nil {
+				fatal(log.Fields{
+					"_function": "newTriggersCreateCmd",
+					"from_file": f.fromFile,
+				}, err, "Error received when attempting to read a trigger.")
+			}
+
+			if err := t.Validate(); err != nil {
+				fatal(log.Fields{
+					"_function": "newTriggersCreateCmd",
+					"trigger":   t,
+				}, err, "Error received when attempting to validate a trigger.")
+			}
+
+			created, err := client.CreateTrigger(cmd.Context(), targetDataset, &t)
+			if err != nil {
+				fatal(log.Fields{
+					"_function": "newTriggersCreateCmd",
+					"dataset":   targetDataset,
+					"trigger":   t,
+				}, err, "Error received when attempting to create a new trigger.")
+			}
+
+			printResponse(created)
+		},
+	}
+
+	f.register(cmd)
+
+	return cmd
+}
+
+// List All Triggers
+// https://docs.honeycomb.io/api/tag/Triggers#operation/listTriggers
+func newTriggersListCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "list",
+		Aliases: []string{"ls"},
+		Short:   "List all Triggers in the specified dataset.",
+		Long:    "List all Triggers in the specified dataset.",
+		Run: func(cmd *cobra.Command, args []string) {
+			triggers, err := client.ListTriggers(cmd.Context(), targetDataset)
+			if err != nil {
+				fatal(log.Fields{
+					"_function": "newTriggersListCmd",
+					"dataset":   targetDataset,
+				}, err, "Error received when attempting to list all triggers.")
+			}
+
+			printResponse(triggers)
+		},
+	}
+
+	return cmd
+}
+
+// Get a Trigger
+// https://docs.honeycomb.io/api/tag/Triggers#operation/getTrigger
+func newTriggersGetCmd() *cobra.Command {
+	var (
+		tID string
+	)
+
+	cmd := &cobra.Command{
+		Use:     "get",
+		Aliases: []string{},
+		Short:   "Get a single Trigger by ID.",
+		Long:    "Get a single Trigger by ID.",
+		Run: func(cmd *cobra.Command, args []string) {
+			t, err := client.GetTrigger(cmd.Context(), targetDataset, tID)
+			if err != nil {
+				fatal(log.Fields{
+					"_function":  "newTriggersGetCmd",
+					"dataset":    targetDataset,
+					"trigger_id": tID,
+				}, err, "Error received when attempting to get a trigger.")
+			}
+
+			printResponse(t)
+		},
+	}
+
+	cmd.Flags().StringVarP(&tID, "id", "i", "", "The unique identifier (ID) of a Trigger.")
+	cmd.MarkFlagRequired("id")
+
+	return cmd
+}
+
+// Update a Trigger
+// https://docs.honeycomb.io/api/tag/Triggers#operation/updateTrigger
+func newTriggersUpdateCmd() *cobra.Command {
+	var (
+		tID string
+		f   triggerFlags
+	)
+
+	cmd := &cobra.Command{
+		Use:     "update",
+		Aliases: []string{"up", "edit", "modify", "change", "set"},
+		Short:   "Update a Trigger by ID.",
+		Long: "Update a Trigger by ID.\n" +
+			"\n" +
+			"Without --from-file, the existing Trigger is fetched and only the values given as\n" +
+			"flags are changed. With --from-file, the Trigger is replaced by the contents of the\n" +
+			"file, with any flags applied over it.",
+		Annotations: map[string]string{annotationLiveReads: ""},
+		Run: func(cmd *cobra.Command, args []string) {
+			var t honeycomb.Trigger
+			if f.fromFile == "" {
+				existing, err := client.GetTrigger(cmd.Context(), targetDataset, tID)
+				if err != nil {
+					fatal(log.Fields{
+						"_function":  "newTriggersUpdateCmd",
+						"dataset":    targetDataset,
+						"trigger_id": tID,
+					}, err, "Error received when attempting to get the trigger to update.")
+				}
+				t = *existing
+
+				// A saved query is returned inline as well, but only one of
+				// the two may be sent.
+				if t.QueryID != "" {
+					t.Query = nil
+				}
+			}
+
+			if err := f.apply(cmd, &t); err != nil {
+				fatal(log.Fields{
+					"_function": "newTriggersUpdateCmd",
+					"from_file": f.fromFile,
+				}, err, "Error received when attempting to read a trigger.")
+			}
+
+			// Read only fields are not accepted in an update.
+			t.ID, t.Triggered, t.CreatedAt, t.UpdatedAt = "", false, nil, nil
+
+			if err := t.Validate(); err != nil {
+				fatal(log.Fields{
+					"_function": "newTriggersUpdateCmd",
+					"trigger":   t,
+				}, err, "Error received when attempting to validate a trigger.")
+			}
+
+			updated, err := client.UpdateTrigger(cmd.Context(), targetDataset, tID, &t)
+			if err != nil {
+				fatal(log.Fields{
+					"_function":  "newTriggersUpdateCmd",
+					"dataset":    targetDataset,
+					"trigger_id": tID,
+					"trigger":    t,
+				}, err, "Error received when attempting to update an existing trigger.")
+			}
+
+			printResponse(updated)
+		},
+	}
+
+	cmd.Flags().StringVarP(&tID, "id", "i", "", "The unique identifier (ID) of a Trigger.")
+	cmd.MarkFlagRequired("id")
+	f.register(cmd)
+
+	return cmd
+}
+
+// Delete a Trigger
+// https://docs.honeycomb.io/api/tag/Triggers#operation/deleteTrigger
+func newTriggersDeleteCmd() *cobra.Command {
+	var (
+		tID string
+	)
+
+	cmd := &cobra.Command{
+		Use:     "delete",
+		Aliases: []string{"rm", "remove", "del"},
+		Short:   "Delete a Trigger by ID.",
+		Long:    "Delete a Trigger by ID.",
+		Run: func(cmd *cobra.Command, args []string) {
+			err := client.DeleteTrigger(cmd.Context(), targetDataset, tID)
+			if err != nil {
+				fatal(log.Fields{
+					"_function":  "newTriggersDeleteCmd",
+					"dataset":    targetDataset,
+					"trigger_id": tID,
+				}, err, "Error received when attempting to delete an existing trigger.")
+			}
+		},
+	}
+
+	cmd.Flags().StringVarP(&tID, "id", "i", "", "The unique identifier (ID) of a Trigger.")
+	cmd.MarkFlagRequired("id")
+
+	return cmd
+}
@@ -0,0 +1,50 @@
+package honeycomb
+
+import (
+	"context"
+	"net/http"
+)
+
+// AuthAPIKeyAccess lists the permissions granted to an API key.
+type AuthAPIKeyAccess struct {
+	Events         bool `json:"events,omitempty"`
+	Markers        bool `json:"markers,omitempty"`
+	Triggers       bool `json:"triggers,omitempty"`
+	Boards         bool `json:"boards,omitempty"`
+	Queries        bool `json:"queries,omitempty"`
+	Columns        bool `json:"columns,omitempty"`
+	CreateDatasets bool `json:"createDatasets,omitempty"`
+	SLOs           bool `json:"slos,omitempty"`
+	Recipients     bool `json:"recipients,omitempty"`
+	PrivateBoards  bool `json:"privateBoards,omitempty"`
+}
+
+// AuthEnvironment is the Environment an API key belongs to.
+type AuthEnvironment struct {
+	Name string `json:"name,omitempty"`
+	Slug string `json:"slug,omitempty"`
+}
+
+// AuthTeam is the Team an API key belongs to.
+type AuthTeam struct {
+	Name string `json:"name,omitempty"`
+	Slug string `json:"slug,omitempty"`
+}
+
+// Auth describes the authorizations granted to an API key.
+type Auth struct {
+	APIKeyAccess AuthAPIKeyAccess `json:"api_key_access,omitempty"`
+	Environment  AuthEnvironment  `json:"environment,omitempty"`
+	Team         AuthTeam         `json:"team,omitempty"`
+}
+
+// GetAuth lists all authorizations that have been granted for the Client's
+// API key.
+// https://docs.honeycomb.io/api/tag/Auth#operation/getAuth
+func (c *Client) GetAuth(ctx context.Context) (*Auth, error) {
+	var a Auth
+	if err := c.Do(ctx, http.MethodGet, "/1/auth", nil, &a); err != nil {
+		return nil, err
+	}
+	return &a, nil
+}
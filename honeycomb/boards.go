@@ -0,0 +1,88 @@
+package honeycomb
+
+import (
+	"context"
+	"net/http"
+)
+
+// BoardGraphSettings controls how a Query is graphed on a Board.
+type BoardGraphSettings struct {
+	HideMarkers       bool `json:"hide_markers"`
+	LogScale          bool `json:"log_scale"`
+	OmitMissingValues bool `json:"omit_missing_values"`
+	StackedGraphs     bool `json:"stacked_graphs"`
+	UTCXAxis          bool `json:"utc_xaxis"`
+	OverlaidCharts    bool `json:"overlaid_charts"`
+}
+
+// BoardQuery is a Query pinned to a Board.
+type BoardQuery struct {
+	Caption           string             `json:"caption,omitempty"`
+	GraphSettings     BoardGraphSettings `json:"graph_settings,omitempty"`
+	QueryStyle        string             `json:"query_style,omitempty"`
+	Dataset           string             `json:"dataset,omitempty"`
+	QueryID           string             `json:"query_id,omitempty"`
+	QueryAnnotationID string             `json:"query_annotation_id,omitempty"`
+}
+
+// BoardLinks holds the links returned with a Board.
+type BoardLinks struct {
+	BoardURL string `json:"board_url,omitempty"`
+}
+
+// Board is a place to pin and save useful queries and graphs.
+type Board struct {
+	Name         string       `json:"name,omitempty"`
+	Description  string       `json:"description,omitempty"`
+	Style        string       `json:"style,omitempty"`
+	ColumnLayout string       `json:"column_layout,omitempty"`
+	Queries      []BoardQuery `json:"queries,omitempty"`
+	Links        BoardLinks   `json:"links,omitempty"`
+	ID           string       `json:"id,omitempty"`
+}
+
+// CreateBoard creates a Board.
+// https://docs.honeycomb.io/api/tag/Boards#operation/createBoard
+func (c *Client) CreateBoard(ctx context.Context, b *Board) (*Board, error) {
+	var out Board
+	if err := c.Do(ctx, http.MethodPost, "/1/boards", b, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// ListBoards retrieves all non-secret Boards within an environment.
+// https://docs.honeycomb.io/api/tag/Boards#operation/listBoards
+func (c *Client) ListBoards(ctx context.Context) ([]Board, error) {
+	var out []Board
+	if err := c.Do(ctx, http.MethodGet, "/1/boards", nil, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// GetBoard gets a single Board by ID.
+// https://docs.honeycomb.io/api/tag/Boards#operation/getBoard
+func (c *Client) GetBoard(ctx context.Context, id string) (*Board, error) {
+	var out Board
+	if err := c.Do(ctx, http.MethodGet, pathFor("/1/boards", id), nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// UpdateBoard replaces the Board with the given ID.
+// https://docs.honeycomb.io/api/tag/Boards#operation/updateBoard
+func (c *Client) UpdateBoard(ctx context.Context, id string, b *Board) (*Board, error) {
+	var out Board
+	if err := c.Do(ctx, http.MethodPut, pathFor("/1/boards", id), b, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// DeleteBoard deletes a single Board by ID.
+// https://docs.honeycomb.io/api/tag/Boards#operation/deleteBoard
+func (c *Client) DeleteBoard(ctx context.Context, id string) error {
+	return c.Do(ctx, http.MethodDelete, pathFor("/1/boards", id), nil, nil)
+}
@@ -0,0 +1,143 @@
+package honeycomb
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// BurnAlertTypes are the valid values of BurnAlert.AlertType.
+var BurnAlertTypes = []string{"exhaustion_time", "budget_rate"}
+
+// BurnAlertSLO identifies the SLO a Burn Alert belongs to.
+type BurnAlertSLO struct {
+	// The unique identifier (ID) of the SLO.
+	ID string `json:"id"`
+}
+
+// BurnAlert notifies Recipients when the error budget of an SLO is being
+// consumed too quickly. An "exhaustion_time" alert fires when the budget is
+// predicted to run out within ExhaustionMinutes; a "budget_rate" alert fires
+// when the budget falls by more than a threshold within a window.
+type BurnAlert struct {
+	// The unique identifier (ID) of a Burn Alert.
+	ID string `json:"id,omitempty"`
+
+	// Enum: "exhaustion_time" "budget_rate"
+	AlertType string `json:"alert_type,omitempty"`
+
+	// A description of the Burn Alert.
+	Description string `json:"description,omitempty"`
+
+	// For "exhaustion_time" alerts, the number of minutes before the budget
+	// is predicted to be exhausted at which to alert. Zero alerts once the
+	// budget has been exhausted.
+	ExhaustionMinutes *int `json:"exhaustion_minutes,omitempty"`
+
+	// For "budget_rate" alerts, the length of the window, in minutes, over
+	// which the decrease in budget is measured.
+	BudgetRateWindowMinutes int `json:"budget_rate_window_minutes,omitempty"`
+
+	// For "budget_rate" alerts, the decrease in budget within the window,
+	// per million, at which to alert. For example, 1% is 10000.
+	BudgetRateDecreaseThresholdPerMillion int `json:"budget_rate_decrease_threshold_per_million,omitempty"`
+
+	// The SLO the Burn Alert belongs to.
+	SLO BurnAlertSLO `json:"slo"`
+
+	// The Recipients notified when the Burn Alert fires.
+	Recipients []NotificationRecipient `json:"recipients,omitempty"`
+
+	// The ISO8601-formatted time when the Burn Alert was created.
+	CreatedAt *time.Time `json:"created_at,omitempty"`
+
+	// The ISO8601-formatted time when the Burn Alert was updated.
+	UpdatedAt *time.Time `json:"updated_at,omitempty"`
+}
+
+// Validate checks the Burn Alert against the constraints documented by the
+// API, so that mistakes are caught before a request is sent.
+func (b *BurnAlert) Validate() error {
+	var problems []string
+
+	if b.SLO.ID == "" {
+		problems = append(problems, "slo id is required")
+	}
+
+	switch b.AlertType {
+	case "exhaustion_time":
+		if b.ExhaustionMinutes == nil {
+			problems = append(problems, "exhaustion_minutes is required for exhaustion_time alerts")
+		} else if *b.ExhaustionMinutes < 0 {
+			problems = append(problems, fmt.Sprintf("exhaustion_minutes %d must not be negative", *b.ExhaustionMinutes))
+		}
+		if b.BudgetRateWindowMinutes != 0 || b.BudgetRateDecreaseThresholdPerMillion != 0 {
+			problems = append(problems, "budget_rate fields cannot be set for exhaustion_time alerts")
+		}
+	case "budget_rate":
+		if b.BudgetRateWindowMinutes < 60 {
+			problems = append(problems, fmt.Sprintf("budget_rate_window_minutes %d must be at least 60", b.BudgetRateWindowMinutes))
+		}
+		if b.BudgetRateDecreaseThresholdPerMillion < 1 || b.BudgetRateDecreaseThresholdPerMillion > 1000000 {
+			problems = append(problems, fmt.Sprintf("budget_rate_decrease_threshold_per_million %d must be between 1 and 1000000", b.BudgetRateDecreaseThresholdPerMillion))
+		}
+		if b.ExhaustionMinutes != nil {
+			problems = append(problems, "exhaustion_minutes cannot be set for budget_rate alerts")
+		}
+	default:
+		problems = append(problems, fmt.Sprintf("alert_type %q must be one of %q", b.AlertType, BurnAlertTypes))
+	}
+
+	problems = append(problems, notificationRecipientProblems(b.Recipients)...)
+
+	return newValidationError("burn alert", problems)
+}
+
+// CreateBurnAlert creates a Burn Alert for an SLO in the given dataset.
+// https://docs.honeycomb.io/api/tag/Burn-Alerts#operation/createBurnAlert
+func (c *Client) CreateBurnAlert(ctx context.Context, dataset string, b *BurnAlert) (*BurnAlert, error) {
+	var out BurnAlert
+	if err := c.Do(ctx, http.MethodPost, pathFor("/1/burn_alerts", dataset), b, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// ListBurnAlerts lists all Burn Alerts for the SLO with the given ID.
+// https://docs.honeycomb.io/api/tag/Burn-Alerts#operation/listBurnAlertsBySlo
+func (c *Client) ListBurnAlerts(ctx context.Context, dataset, sloID string) ([]BurnAlert, error) {
+	var out []BurnAlert
+	path := pathFor("/1/burn_alerts", dataset) + "?" + url.Values{"slo_id": {sloID}}.Encode()
+	if err := c.Do(ctx, http.MethodGet, path, nil, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// GetBurnAlert gets a single Burn Alert by ID.
+// https://docs.honeycomb.io/api/tag/Burn-Alerts#operation/getBurnAlert
+func (c *Client) GetBurnAlert(ctx context.Context, dataset, id string) (*BurnAlert, error) {
+	var out BurnAlert
+	if err := c.Do(ctx, http.MethodGet, pathFor("/1/burn_alerts", dataset, id), nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// UpdateBurnAlert replaces the Burn Alert with the given ID.
+// https://docs.honeycomb.io/api/tag/Burn-Alerts#operation/updateBurnAlert
+func (c *Client) UpdateBurnAlert(ctx context.Context, dataset, id string, b *BurnAlert) (*BurnAlert, error) {
+	var out BurnAlert
+	if err := c.Do(ctx, http.MethodPut, pathFor("/1/burn_alerts", dataset, id), b, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// DeleteBurnAlert deletes the Burn Alert with the given ID.
+// https://docs.honeycomb.io/api/tag/Burn-Alerts#operation/deleteBurnAlert
+func (c *Client) DeleteBurnAlert(ctx context.Context, dataset, id string) error {
+	return c.Do(ctx, http.MethodDelete, pathFor("/1/burn_alerts", dataset, id), nil, nil)
+}
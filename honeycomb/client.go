@@ -0,0 +1,308 @@
+// Package honeycomb provides a client for the Honeycomb API.
+//
+// A Client is created with NewClient and a set of Options, and exposes typed
+// methods for each of the supported API resources. Every method returns the
+// decoded response (or an error) rather than printing it, so the package can be
+// used from any Go program, not just the honeybadger command line.
+package honeycomb
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"golang.org/x/exp/slices"
+)
+
+const (
+	// DefaultAPIHost is the host used when no other host is specified.
+	DefaultAPIHost = "https://api.honeycomb.io/"
+
+	// DefaultUserAgent is the User-Agent sent when no other is specified.
+	DefaultUserAgent = "honeybadger"
+
+	// EnvironmentWide is the dataset slug used for endpoints that support
+	// environment-wide operations, such as Markers and Marker Settings.
+	EnvironmentWide = "__all__"
+)
+
+var (
+	status200Codes = []int{
+		http.StatusOK,
+		http.StatusCreated,
+		http.StatusAccepted,
+		http.StatusNonAuthoritativeInfo,
+		http.StatusNoContent,
+		http.StatusResetContent,
+		http.StatusPartialContent,
+		http.StatusMultiStatus,
+		http.StatusAlreadyReported,
+		http.StatusIMUsed,
+	}
+
+	validMethods = []string{
+		http.MethodGet,
+		http.MethodHead,
+		http.MethodPost,
+		http.MethodPut,
+		http.MethodPatch,
+		http.MethodDelete,
+		http.MethodConnect,
+		http.MethodOptions,
+		http.MethodTrace,
+	}
+)
+
+// Client sends requests to the Honeycomb API on behalf of a single
+// configuration key.
+type Client struct {
+	apiHost     *url.URL
+	configKey   string
+	httpClient  *http.Client
+	userAgent   string
+	dryRun      io.Writer
+	liveReads   bool
+	retryPolicy RetryPolicy
+	redactor    *Redactor
+}
+
+// Option configures a Client.
+type Option func(*Client) error
+
+// WithAPIHost sets the host to query. It only needs to be changed for hosted
+// Honeycomb environments.
+func WithAPIHost(host string) Option {
+	return func(c *Client) error {
+		u, err := url.Parse(host)
+		if err != nil {
+			return fmt.Errorf("failed to parse URL %s: %w", host, err)
+		}
+		c.apiHost = u
+		return nil
+	}
+}
+
+// WithConfigKey sets the Honeycomb configuration key sent with every request.
+func WithConfigKey(key string) Option {
+	return func(c *Client) error {
+		c.configKey = key
+		return nil
+	}
+}
+
+// WithHTTPClient sets the HTTP client used to execute requests.
+func WithHTTPClient(hc *http.Client) Option {
+	return func(c *Client) error {
+		if hc == nil {
+			return errors.New("http client must not be nil")
+		}
+		c.httpClient = hc
+		return nil
+	}
+}
+
+// WithUserAgent sets the User-Agent header sent with every request.
+func WithUserAgent(ua string) Option {
+	return func(c *Client) error {
+		c.userAgent = ua
+		return nil
+	}
+}
+
+// WithDryRun causes the Client to write each request to w instead of sending
+// it. Methods called on a dry run Client return zero values and no error.
+func WithDryRun(w io.Writer) Option {
+	return func(c *Client) error {
+		c.dryRun = w
+		return nil
+	}
+}
+
+// WithLiveReads causes a dry run Client to send GET requests anyway, so that
+// callers reading the current state before changing it can show exactly what
+// they would change. It has no effect unless WithDryRun is also given.
+func WithLiveReads() Option {
+	return func(c *Client) error {
+		c.liveReads = true
+		return nil
+	}
+}
+
+// WithRedactor sets the Redactor used to mask secrets in dry run output and
+// error messages. The configuration key is always added to it. A nil Redactor
+// disables masking entirely.
+func WithRedactor(r *Redactor) Option {
+	return func(c *Client) error {
+		c.redactor = r
+		return nil
+	}
+}
+
+// NewClient creates a Client, applying opts over the defaults.
+func NewClient(opts ...Option) (*Client, error) {
+	c := &Client{
+		httpClient:  &http.Client{Timeout: 10 * time.Second},
+		userAgent:   DefaultUserAgent,
+		retryPolicy: DefaultRetryPolicy,
+		redactor:    NewRedactor(),
+	}
+	if err := WithAPIHost(DefaultAPIHost)(c); err != nil {
+		return nil, err
+	}
+
+	for _, opt := range opts {
+		if err := opt(c); err != nil {
+			return nil, err
+		}
+	}
+
+	if c.redactor != nil {
+		c.redactor = c.redactor.clone()
+		c.redactor.AddValues(c.configKey)
+	}
+
+	return c, nil
+}
+
+// Redactor returns the Redactor used by the Client, which masks its
+// configuration key. It is nil if masking has been disabled.
+func (c *Client) Redactor() *Redactor {
+	return c.redactor
+}
+
+// Do sends a request to the Honeycomb API. If body is non-nil it is marshalled
+// to JSON and sent as the request body. If out is non-nil the JSON response is
+// decoded into it. path may include a query string, and is expected to be
+// escaped already, as returned by pathFor.
+//
+// Do is exported so that endpoints without a typed method can still be called.
+func (c *Client) Do(ctx context.Context, method, path string, body, out interface{}) error {
+	var reqBody []byte
+	if body != nil {
+		var err error
+		reqBody, err = json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("failed to marshal request body: %w", err)
+		}
+	}
+
+	return c.do(ctx, method, path, nil, reqBody, out)
+}
+
+// do sends a request with an already encoded body, and any headers beyond
+// those common to every call, such as the time of an event.
+func (c *Client) do(ctx context.Context, method, path string, header http.Header, reqBody []byte, out interface{}) error {
+	// Ensure that a valid method has been specified.
+	if !slices.Contains(validMethods, method) {
+		return fmt.Errorf("invalid method %s", method)
+	}
+
+	reqURL := *c.apiHost
+	rawPath, rawQuery, _ := strings.Cut(path, "?")
+	unescaped, err := url.PathUnescape(rawPath)
+	if err != nil {
+		return fmt.Errorf("invalid path %s: %w", path, err)
+	}
+	reqURL.Path, reqURL.RawPath, reqURL.RawQuery = unescaped, rawPath, rawQuery
+
+	// Output on dry run, skip execution of the request.
+	if c.dryRun != nil && !(c.liveReads && method == http.MethodGet) {
+		req, err := c.newRequest(ctx, method, reqURL.String(), header, reqBody)
+		if err != nil {
+			return err
+		}
+		reqOut, err := c.redactor.DumpRequest(req, reqBody)
+		if err != nil {
+			return fmt.Errorf("failed to dump the request to a byte sequence: %w", err)
+		}
+		fmt.Fprintf(c.dryRun, "Would have sent the following request:\n---\n%s\n", reqOut)
+		return nil
+	}
+
+	resp, respBody, err := c.send(ctx, method, reqURL.String(), header, reqBody)
+	if err != nil {
+		return err
+	}
+
+	// Error if the response code is not a 2XX code.
+	if !slices.Contains(status200Codes, resp.StatusCode) {
+		return newAPIError(method, path, resp, respBody, c.redactor)
+	}
+
+	// Some endpoints, such as deletes, respond without a body.
+	if out == nil || len(bytes.TrimSpace(respBody)) == 0 {
+		return nil
+	}
+
+	if err := json.Unmarshal(respBody, out); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	return nil
+}
+
+// newRequest creates a request carrying the headers common to every call,
+// followed by any in header.
+func (c *Client) newRequest(ctx context.Context, method, rawURL string, header http.Header, body []byte) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, method, rawURL, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("User-Agent", c.userAgent)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Honeycomb-Team", c.configKey)
+	for k, v := range header {
+		req.Header[k] = v
+	}
+
+	return req, nil
+}
+
+// send executes a request, retrying it according to the Client's RetryPolicy.
+// The response body is read in full so that the connection can be reused
+// between attempts.
+func (c *Client) send(ctx context.Context, method, rawURL string, header http.Header, body []byte) (*http.Response, []byte, error) {
+	for attempt := 1; ; attempt++ {
+		req, err := c.newRequest(ctx, method, rawURL, header, body)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		var respBody []byte
+		resp, err := c.httpClient.Do(req)
+		if err == nil {
+			respBody, err = io.ReadAll(resp.Body)
+			resp.Body.Close()
+			if err != nil {
+				return nil, nil, fmt.Errorf("failed to read response: %w", err)
+			}
+		}
+
+		if attempt >= c.retryPolicy.MaxAttempts || ctx.Err() != nil || !c.retryPolicy.shouldRetry(method, resp, err) {
+			if err != nil {
+				return nil, nil, fmt.Errorf("failed to execute the request: %w", err)
+			}
+			return resp, respBody, nil
+		}
+
+		if err := sleep(ctx, c.retryPolicy.delay(attempt, resp)); err != nil {
+			return nil, nil, fmt.Errorf("failed to execute the request: %w", err)
+		}
+	}
+}
+
+// pathFor joins the API path segments, escaping each of the trailing elements.
+func pathFor(base string, elems ...string) string {
+	p := base
+	for _, e := range elems {
+		p += "/" + url.PathEscape(e)
+	}
+	return p
+}
@@ -0,0 +1,122 @@
+package honeycomb
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"golang.org/x/exp/slices"
+)
+
+// ColumnTypes are the valid values of Column.Type.
+var ColumnTypes = []string{"string", "float", "integer", "boolean"}
+
+// Column is a field of the events in a dataset.
+type Column struct {
+	// The unique identifier (ID) of a Column.
+	ID string `json:"id,omitempty"`
+
+	// The name of the Column.
+	KeyName string `json:"key_name,omitempty"`
+
+	// The type of the Column's values. Defaults to "string".
+	// Enum: "string" "float" "integer" "boolean"
+	Type string `json:"type,omitempty"`
+
+	// A description of the Column.
+	Description string `json:"description,omitempty"`
+
+	// Whether the Column is hidden from autocomplete and the sidebar in the
+	// query builder.
+	Hidden bool `json:"hidden"`
+
+	// The ISO8601-formatted time when the Column was last written to. Read
+	// only.
+	LastWritten *time.Time `json:"last_written,omitempty"`
+
+	// The ISO8601-formatted time when the Column was created.
+	CreatedAt *time.Time `json:"created_at,omitempty"`
+
+	// The ISO8601-formatted time when the Column was updated.
+	UpdatedAt *time.Time `json:"updated_at,omitempty"`
+}
+
+// Validate checks the Column against the constraints documented by the API,
+// so that mistakes are caught before a request is sent.
+func (col *Column) Validate() error {
+	var problems []string
+
+	if col.KeyName == "" {
+		problems = append(problems, "key_name is required")
+	}
+	if len(col.KeyName) > 255 {
+		problems = append(problems, "key_name must be at most 255 characters")
+	}
+	if col.Type != "" && !slices.Contains(ColumnTypes, col.Type) {
+		problems = append(problems, fmt.Sprintf("type %q must be one of %q", col.Type, ColumnTypes))
+	}
+	if len(col.Description) > 255 {
+		problems = append(problems, "description must be at most 255 characters")
+	}
+
+	return newValidationError("column", problems)
+}
+
+// CreateColumn creates a Column in the given dataset.
+// https://docs.honeycomb.io/api/tag/Columns#operation/createColumn
+func (c *Client) CreateColumn(ctx context.Context, dataset string, col *Column) (*Column, error) {
+	var out Column
+	if err := c.Do(ctx, http.MethodPost, pathFor("/1/columns", dataset), col, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// ListColumns lists all Columns in the given dataset.
+// https://docs.honeycomb.io/api/tag/Columns#operation/listColumns
+func (c *Client) ListColumns(ctx context.Context, dataset string) ([]Column, error) {
+	var out []Column
+	if err := c.Do(ctx, http.MethodGet, pathFor("/1/columns", dataset), nil, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// GetColumn gets a single Column by ID.
+// https://docs.honeycomb.io/api/tag/Columns#operation/getColumn
+func (c *Client) GetColumn(ctx context.Context, dataset, id string) (*Column, error) {
+	var out Column
+	if err := c.Do(ctx, http.MethodGet, pathFor("/1/columns", dataset, id), nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetColumnByKeyName gets a single Column by its name.
+// https://docs.honeycomb.io/api/tag/Columns#operation/listColumns
+func (c *Client) GetColumnByKeyName(ctx context.Context, dataset, keyName string) (*Column, error) {
+	var out Column
+	path := pathFor("/1/columns", dataset) + "?" + url.Values{"key_name": {keyName}}.Encode()
+	if err := c.Do(ctx, http.MethodGet, path, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// UpdateColumn replaces the Column with the given ID.
+// https://docs.honeycomb.io/api/tag/Columns#operation/updateColumn
+func (c *Client) UpdateColumn(ctx context.Context, dataset, id string, col *Column) (*Column, error) {
+	var out Column
+	if err := c.Do(ctx, http.MethodPut, pathFor("/1/columns", dataset, id), col, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// DeleteColumn deletes the Column with the given ID, along with its data.
+// https://docs.honeycomb.io/api/tag/Columns#operation/deleteColumn
+func (c *Client) DeleteColumn(ctx context.Context, dataset, id string) error {
+	return c.Do(ctx, http.MethodDelete, pathFor("/1/columns", dataset, id), nil, nil)
+}
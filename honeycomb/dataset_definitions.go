@@ -0,0 +1,95 @@
+package honeycomb
+
+import (
+	"context"
+	"net/http"
+)
+
+// DatasetDefinitionColumn maps a Column or Derived Column to a Dataset
+// Definition type.
+type DatasetDefinitionColumn struct {
+	// The name of the Column or Derived Column to map to this Dataset
+	// Definition Type. An empty string clears the mapping, potentially
+	// reverting to a default mapping.
+	Name string `json:"name,omitempty"`
+
+	// Optional: column for regular columns and derived_column for derived
+	// columns when setting Dataset Definitions. Honeycomb does not use this
+	// field when updating Dataset definitions.
+	ColumnType string `json:"column_type,omitempty"`
+}
+
+// DatasetDefinition describes the fields with special meaning in a Dataset.
+type DatasetDefinition struct {
+	// The unique identifier (ID) for each span.
+	SpanID DatasetDefinitionColumn `json:"span_id,omitempty"`
+
+	// The ID of the trace this span belongs to.
+	TraceID DatasetDefinitionColumn `json:"trace_id,omitempty"`
+
+	// The Parent Span ID - The ID of this span's parent span, the call
+	// location the current span was called from.
+	ParentID DatasetDefinitionColumn `json:"parent_id,omitempty"`
+
+	// The name of the function or method where the span was created.
+	Name DatasetDefinitionColumn `json:"name,omitempty"`
+
+	// The name of the instrumented service.
+	ServiceName DatasetDefinitionColumn `json:"service_name,omitempty"`
+
+	// Span Duration - How much time the span took, in milliseconds.
+	DurationMs DatasetDefinitionColumn `json:"duration_ms,omitempty"`
+
+	// Metadata: Kind - The kind of Span. For example, client or server. The
+	// use of this field to identify Span Events and Links is deprecated. Use
+	// the field Metadata: Annotation Type.
+	SpanKind DatasetDefinitionColumn `json:"span_kind,omitempty"`
+
+	// Metadata: Annotation Type - The type of span annotation. For example,
+	// span_event or link. This lets Honeycomb visualize this type of event
+	// differently in a trace. Do not use this field for other purposes.
+	AnnotationType DatasetDefinitionColumn `json:"annotation_type,omitempty"`
+
+	// Metadata: Link Span ID - Links let you tie traces and spans to one
+	// another. The Link Span ID lets you link to a different span (when used
+	// with Link Trace ID).
+	LinkSpanID DatasetDefinitionColumn `json:"link_span_id,omitempty"`
+
+	// Metadata: Link Trace ID - Links let you tie traces and spans to one
+	// another. The Link Trace Id lets you link to a different trace or a
+	// different span in the same trace (when used with Link Span ID).
+	LinkTraceID DatasetDefinitionColumn `json:"link_trace_id,omitempty"`
+
+	// Use a Boolean or String to indicate error.
+	Error DatasetDefinitionColumn `json:"error,omitempty"`
+
+	// Indicates the success, failure, or other status of a request.
+	Status DatasetDefinitionColumn `json:"status,omitempty"`
+
+	// The HTTP URL or equivalent route processed by the request.
+	Route DatasetDefinitionColumn `json:"route,omitempty"`
+
+	// The user making the request in the system.
+	User DatasetDefinitionColumn `json:"user,omitempty"`
+}
+
+// GetDatasetDefinitions gets all definitions for a Dataset.
+// https://docs.honeycomb.io/api/tag/Dataset-Definitions#operation/listDatasetDefinitions
+func (c *Client) GetDatasetDefinitions(ctx context.Context, slug string) (*DatasetDefinition, error) {
+	var out DatasetDefinition
+	if err := c.Do(ctx, http.MethodGet, pathFor("/1/dataset_definitions", slug), nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// UpdateDatasetDefinitions sets or updates one or more definitions for a
+// Dataset.
+// https://docs.honeycomb.io/api/tag/Dataset-Definitions#operation/patchDatasetDefinitions
+func (c *Client) UpdateDatasetDefinitions(ctx context.Context, slug string, dd *DatasetDefinition) (*DatasetDefinition, error) {
+	var out DatasetDefinition
+	if err := c.Do(ctx, http.MethodPatch, pathFor("/1/dataset_definitions", slug), dd, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
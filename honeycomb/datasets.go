@@ -0,0 +1,80 @@
+package honeycomb
+
+import (
+	"context"
+	"net/http"
+)
+
+// Dataset is a collection of related events that come from the same source.
+type Dataset struct {
+	// The name of the dataset.
+	Name string `json:"name,omitempty"`
+
+	// A description for the dataset.
+	Description string `json:"description,omitempty"`
+
+	// The maximum unpacking depth of nested JSON fields.
+	ExpandJSONDepth int `json:"expand_json_depth,omitempty"`
+
+	// The 'slug' of the dataset to be used in URLs.
+	Slug string `json:"slug,omitempty"`
+
+	// The total number of unique fields for this Dataset. The value will be
+	// null if the dataset does not contain any fields yet.
+	RegularColumnsCount int `json:"regular_columns_count,omitempty"`
+
+	// The ISO8601-formatted time when the dataset last received event data.
+	// The value will be null if no data has been received yet.
+	LastWrittenAt string `json:"last_written_at,omitempty"`
+
+	// The ISO8601-formatted time when the dataset was created.
+	CreatedAt string `json:"created_at,omitempty"`
+}
+
+// CreateDataset creates a Dataset. If a Dataset already exists by that name
+// (or slug), then the existing Dataset is returned.
+// https://docs.honeycomb.io/api/tag/Datasets#operation/createDataset
+func (c *Client) CreateDataset(ctx context.Context, d *Dataset) (*Dataset, error) {
+	var out Dataset
+	if err := c.Do(ctx, http.MethodPost, "/1/datasets", d, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// ListDatasets lists all Datasets for an environment.
+// https://docs.honeycomb.io/api/tag/Datasets#operation/listDatasets
+func (c *Client) ListDatasets(ctx context.Context) ([]Dataset, error) {
+	var out []Dataset
+	if err := c.Do(ctx, http.MethodGet, "/1/datasets", nil, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// GetDataset gets a single Dataset by slug.
+// https://docs.honeycomb.io/api/tag/Datasets#operation/getDataset
+func (c *Client) GetDataset(ctx context.Context, slug string) (*Dataset, error) {
+	var out Dataset
+	if err := c.Do(ctx, http.MethodGet, pathFor("/1/datasets", slug), nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// UpdateDataset updates a Dataset's description and expand_json_depth
+// setting. Omitting either has the effect of reverting it to the default.
+// https://docs.honeycomb.io/api/tag/Datasets#operation/updateDataset
+func (c *Client) UpdateDataset(ctx context.Context, slug string, d *Dataset) (*Dataset, error) {
+	var out Dataset
+	if err := c.Do(ctx, http.MethodPut, pathFor("/1/datasets", slug), d, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// DeleteDataset asynchronously deletes a Dataset.
+// https://docs.honeycomb.io/api/tag/Datasets#operation/deleteDataset
+func (c *Client) DeleteDataset(ctx context.Context, slug string) error {
+	return c.Do(ctx, http.MethodDelete, pathFor("/1/datasets", slug), nil, nil)
+}
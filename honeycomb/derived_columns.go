@@ -0,0 +1,113 @@
+package honeycomb
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// DerivedColumn is a column whose value is calculated from other columns of
+// each event, using an expression.
+type DerivedColumn struct {
+	// The unique identifier (ID) of a Derived Column.
+	ID string `json:"id,omitempty"`
+
+	// The name of the Derived Column. Must be unique within the dataset.
+	Alias string `json:"alias,omitempty"`
+
+	// The expression used to calculate the value of the Derived Column.
+	// https://docs.honeycomb.io/reference/derived-column-formula/
+	Expression string `json:"expression,omitempty"`
+
+	// A description of the Derived Column.
+	Description string `json:"description,omitempty"`
+
+	// The ISO8601-formatted time when the Derived Column was created.
+	CreatedAt *time.Time `json:"created_at,omitempty"`
+
+	// The ISO8601-formatted time when the Derived Column was updated.
+	UpdatedAt *time.Time `json:"updated_at,omitempty"`
+}
+
+// GetDerivedColumnByAlias gets a single Derived Column by its alias. Use
+// EnvironmentWide to get an environment-wide Derived Column.
+// https://docs.honeycomb.io/api/tag/Derived-Columns#operation/listDerivedColumns
+func (c *Client) GetDerivedColumnByAlias(ctx context.Context, dataset, alias string) (*DerivedColumn, error) {
+	var out DerivedColumn
+	path := pathFor("/1/derived_columns", dataset) + "?" + url.Values{"alias": {alias}}.Encode()
+	if err := c.Do(ctx, http.MethodGet, path, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// Validate checks the Derived Column against the constraints documented by
+// the API, so that mistakes are caught before a request is sent.
+func (dc *DerivedColumn) Validate() error {
+	var problems []string
+
+	if dc.Alias == "" {
+		problems = append(problems, "alias is required")
+	}
+	if len(dc.Alias) > 255 {
+		problems = append(problems, "alias must be at most 255 characters")
+	}
+	if strings.TrimSpace(dc.Expression) == "" {
+		problems = append(problems, "expression is required")
+	}
+	if len(dc.Description) > 255 {
+		problems = append(problems, "description must be at most 255 characters")
+	}
+
+	return newValidationError("derived column", problems)
+}
+
+// CreateDerivedColumn creates a Derived Column in the given dataset. Use
+// EnvironmentWide to create an environment-wide Derived Column.
+// https://docs.honeycomb.io/api/tag/Derived-Columns#operation/createDerivedColumn
+func (c *Client) CreateDerivedColumn(ctx context.Context, dataset string, dc *DerivedColumn) (*DerivedColumn, error) {
+	var out DerivedColumn
+	if err := c.Do(ctx, http.MethodPost, pathFor("/1/derived_columns", dataset), dc, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// ListDerivedColumns lists all Derived Columns in the given dataset. Use
+// EnvironmentWide to list environment-wide Derived Columns.
+// https://docs.honeycomb.io/api/tag/Derived-Columns#operation/listDerivedColumns
+func (c *Client) ListDerivedColumns(ctx context.Context, dataset string) ([]DerivedColumn, error) {
+	var out []DerivedColumn
+	if err := c.Do(ctx, http.MethodGet, pathFor("/1/derived_columns", dataset), nil, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// GetDerivedColumn gets a single Derived Column by ID.
+// https://docs.honeycomb.io/api/tag/Derived-Columns#operation/getDerivedColumn
+func (c *Client) GetDerivedColumn(ctx context.Context, dataset, id string) (*DerivedColumn, error) {
+	var out DerivedColumn
+	if err := c.Do(ctx, http.MethodGet, pathFor("/1/derived_columns", dataset, id), nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// UpdateDerivedColumn replaces the Derived Column with the given ID.
+// https://docs.honeycomb.io/api/tag/Derived-Columns#operation/updateDerivedColumn
+func (c *Client) UpdateDerivedColumn(ctx context.Context, dataset, id string, dc *DerivedColumn) (*DerivedColumn, error) {
+	var out DerivedColumn
+	if err := c.Do(ctx, http.MethodPut, pathFor("/1/derived_columns", dataset, id), dc, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// DeleteDerivedColumn deletes the Derived Column with the given ID.
+// https://docs.honeycomb.io/api/tag/Derived-Columns#operation/deleteDerivedColumn
+func (c *Client) DeleteDerivedColumn(ctx context.Context, dataset, id string) error {
+	return c.Do(ctx, http.MethodDelete, pathFor("/1/derived_columns", dataset, id), nil, nil)
+}
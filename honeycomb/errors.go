@@ -0,0 +1,221 @@
+package honeycomb
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// ErrorClass groups API errors by how a caller is likely to handle them.
+type ErrorClass int
+
+const (
+	// ClassUnknown is any error that does not fit one of the other classes.
+	ClassUnknown ErrorClass = iota
+
+	// ClassAuth is a missing, invalid or insufficiently privileged API key
+	// (401 and 403).
+	ClassAuth
+
+	// ClassNotFound is a resource that does not exist (404).
+	ClassNotFound
+
+	// ClassValidation is a request the server refused to process as sent
+	// (400, 409 and 422).
+	ClassValidation
+
+	// ClassRateLimit is a request that was rate limited (429).
+	ClassRateLimit
+
+	// ClassServer is a failure on the server side (5XX).
+	ClassServer
+)
+
+// String returns the name of the class.
+func (ec ErrorClass) String() string {
+	switch ec {
+	case ClassAuth:
+		return "auth"
+	case ClassNotFound:
+		return "not-found"
+	case ClassValidation:
+		return "validation"
+	case ClassRateLimit:
+		return "rate-limit"
+	case ClassServer:
+		return "server"
+	default:
+		return "unknown"
+	}
+}
+
+// Sentinel errors matching each ErrorClass, so callers can branch with
+// errors.Is(err, honeycomb.ErrNotFound) without inspecting status codes.
+var (
+	ErrAuth        = errors.New("honeycomb: unauthorized")
+	ErrNotFound    = errors.New("honeycomb: not found")
+	ErrValidation  = errors.New("honeycomb: invalid request")
+	ErrRateLimited = errors.New("honeycomb: rate limited")
+	ErrServer      = errors.New("honeycomb: server error")
+
+	classSentinels = map[ErrorClass]error{
+		ClassAuth:       ErrAuth,
+		ClassNotFound:   ErrNotFound,
+		ClassValidation: ErrValidation,
+		ClassRateLimit:  ErrRateLimited,
+		ClassServer:     ErrServer,
+	}
+)
+
+// APIErrorDetail describes a problem with a single field of a request.
+type APIErrorDetail struct {
+	Field       string `json:"field,omitempty"`
+	Code        string `json:"code,omitempty"`
+	Description string `json:"description,omitempty"`
+}
+
+// APIError is returned for any response with a non-2XX status code. Its fields
+// are parsed from Honeycomb's JSON error bodies, which may be either the
+// legacy {"error": "..."} form or an RFC 7807 problem.
+type APIError struct {
+	// The HTTP status code of the response.
+	StatusCode int `json:"status"`
+
+	// The problem type, usually a URI identifying the class of problem.
+	Type string `json:"type,omitempty"`
+
+	// A short, human-readable summary of the problem type.
+	Title string `json:"title,omitempty"`
+
+	// A human-readable explanation specific to this occurrence of the problem.
+	Detail string `json:"detail,omitempty"`
+
+	// Problems with individual fields of the request, if any.
+	TypeDetail []APIErrorDetail `json:"type_detail,omitempty"`
+
+	// The ID Honeycomb assigned to the request, for use with Support.
+	RequestID string `json:"request_id,omitempty"`
+
+	// The method and path of the request that failed.
+	Method string `json:"method,omitempty"`
+	Path   string `json:"path,omitempty"`
+}
+
+// maxRawErrorBody limits how much of a non-JSON error body is kept.
+const maxRawErrorBody = 512
+
+// newAPIError builds an APIError from a failed response and its body, masking
+// any secrets the server echoed back.
+func newAPIError(method, path string, resp *http.Response, body []byte, r *Redactor) *APIError {
+	e := &APIError{
+		StatusCode: resp.StatusCode,
+		Method:     method,
+		Path:       path,
+	}
+
+	body = r.JSON(body)
+
+	var problem struct {
+		Error      string           `json:"error"`
+		Type       string           `json:"type"`
+		Title      string           `json:"title"`
+		Detail     string           `json:"detail"`
+		TypeDetail []APIErrorDetail `json:"type_detail"`
+		RequestID  string           `json:"request_id"`
+	}
+	if err := json.Unmarshal(body, &problem); err == nil {
+		e.Type = problem.Type
+		e.Title = problem.Title
+		e.Detail = problem.Detail
+		if e.Detail == "" {
+			e.Detail = problem.Error
+		}
+		e.TypeDetail = problem.TypeDetail
+		e.RequestID = problem.RequestID
+	} else {
+		raw := strings.TrimSpace(string(body))
+		if len(raw) > maxRawErrorBody {
+			raw = raw[:maxRawErrorBody] + "..."
+		}
+		e.Detail = raw
+	}
+
+	if e.RequestID == "" {
+		e.RequestID = resp.Header.Get("Request-Id")
+	}
+	if e.RequestID == "" {
+		e.RequestID = resp.Header.Get("X-Request-Id")
+	}
+
+	return e
+}
+
+// Class returns the ErrorClass of the error, based on its status code.
+func (e *APIError) Class() ErrorClass {
+	switch {
+	case e.StatusCode == http.StatusUnauthorized, e.StatusCode == http.StatusForbidden:
+		return ClassAuth
+	case e.StatusCode == http.StatusNotFound:
+		return ClassNotFound
+	case e.StatusCode == http.StatusBadRequest, e.StatusCode == http.StatusConflict,
+		e.StatusCode == http.StatusUnprocessableEntity:
+		return ClassValidation
+	case e.StatusCode == http.StatusTooManyRequests:
+		return ClassRateLimit
+	case e.StatusCode >= 500:
+		return ClassServer
+	default:
+		return ClassUnknown
+	}
+}
+
+// Is reports whether target is the sentinel error for the error's class.
+func (e *APIError) Is(target error) bool {
+	sentinel, ok := classSentinels[e.Class()]
+	return ok && sentinel == target
+}
+
+// Error returns a single line summary of the error.
+func (e *APIError) Error() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s %s: %d %s", e.Method, e.Path, e.StatusCode, http.StatusText(e.StatusCode))
+	if e.Title != "" {
+		fmt.Fprintf(&b, ": %s", e.Title)
+	}
+	if e.Detail != "" && e.Detail != e.Title {
+		fmt.Fprintf(&b, ": %s", e.Detail)
+	}
+	return b.String()
+}
+
+// ValidationError is returned by the Validate methods of resources, listing
+// every problem found before a request is sent. It matches ErrValidation, like
+// an APIError for a request the server refused.
+type ValidationError struct {
+	// The kind of resource that was validated, e.g. "trigger".
+	Resource string
+
+	// Each problem found, e.g. "name is required".
+	Problems []string
+}
+
+// newValidationError returns a ValidationError for the given problems, or nil
+// if there are none.
+func newValidationError(resource string, problems []string) error {
+	if len(problems) == 0 {
+		return nil
+	}
+	return &ValidationError{Resource: resource, Problems: problems}
+}
+
+// Is reports whether target is ErrValidation.
+func (e *ValidationError) Is(target error) bool {
+	return target == ErrValidation
+}
+
+// Error returns a single line summary of the error.
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("invalid %s: %s", e.Resource, strings.Join(e.Problems, "; "))
+}
@@ -0,0 +1,81 @@
+package honeycomb
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const (
+	// MaxEventBytes is the largest event, encoded as JSON, that the API accepts.
+	MaxEventBytes = 1_000_000
+
+	// MaxBatchBytes is the largest request body that the batch endpoint
+	// accepts.
+	MaxBatchBytes = 5_000_000
+)
+
+// Event is a single event to send to a dataset.
+type Event struct {
+	// The time of the event. The time it is received is used if it is not
+	// set.
+	Time *time.Time `json:"time,omitempty"`
+
+	// The sample rate of the event: 1 in SampleRate events like it were
+	// sent. Unset, or 1, means the event was not sampled.
+	SampleRate int `json:"samplerate,omitempty"`
+
+	// The fields of the event.
+	Data map[string]interface{} `json:"data"`
+}
+
+// BatchEventStatus is the outcome of sending a single event in a batch.
+type BatchEventStatus struct {
+	// The HTTP status code for the event; 202 if it was accepted.
+	Status int `json:"status"`
+
+	// Why the event was not accepted.
+	Error string `json:"error,omitempty"`
+}
+
+// Accepted reports whether the event was accepted.
+func (s BatchEventStatus) Accepted() bool {
+	return s.Status == http.StatusAccepted
+}
+
+// SendEvent sends a single event to the given dataset, creating the dataset if
+// it does not exist.
+// https://docs.honeycomb.io/api/tag/Events#operation/createEvent
+func (c *Client) SendEvent(ctx context.Context, dataset string, e *Event) error {
+	body, err := json.Marshal(e.Data)
+	if err != nil {
+		return fmt.Errorf("failed to marshal event: %w", err)
+	}
+
+	header := http.Header{}
+	if e.Time != nil {
+		header.Set("X-Honeycomb-Event-Time", e.Time.Format(time.RFC3339Nano))
+	}
+	if e.SampleRate > 0 {
+		header.Set("X-Honeycomb-Samplerate", strconv.Itoa(e.SampleRate))
+	}
+
+	return c.do(ctx, http.MethodPost, pathFor("/1/events", dataset), header, body, nil)
+}
+
+// SendBatch sends events to the given dataset in a single request, creating
+// the dataset if it does not exist. The status of each event is returned in
+// the order the events were given; the request as a whole succeeds even if
+// some events are not accepted. Callers must keep each batch within
+// MaxBatchBytes.
+// https://docs.honeycomb.io/api/tag/Events#operation/createEvents
+func (c *Client) SendBatch(ctx context.Context, dataset string, events []Event) ([]BatchEventStatus, error) {
+	var out []BatchEventStatus
+	if err := c.Do(ctx, http.MethodPost, pathFor("/1/batch", dataset), events, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
@@ -0,0 +1,178 @@
+package honeycomb
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+// KinesisEnvelope is a delivery request of the Amazon Kinesis Data Firehose
+// HTTP endpoint destination, the form in which Firehose forwards records to
+// Honeycomb.
+// https://docs.aws.amazon.com/firehose/latest/dev/httpdeliveryrequestresponse.html
+type KinesisEnvelope struct {
+	// A unique ID for the request, echoed in the response.
+	RequestID string `json:"requestId"`
+
+	// When the request was sent, in milliseconds since the UNIX epoch.
+	Timestamp int64 `json:"timestamp"`
+
+	// The records delivered.
+	Records []KinesisRecord `json:"records"`
+}
+
+// KinesisRecord is a single record in a KinesisEnvelope. Its data is base64
+// encoded in JSON.
+type KinesisRecord struct {
+	Data []byte `json:"data"`
+}
+
+// KinesisResponse is the response to a KinesisEnvelope.
+type KinesisResponse struct {
+	// The ID of the request responded to.
+	RequestID string `json:"requestId"`
+
+	// When the response was sent, in milliseconds since the UNIX epoch.
+	Timestamp int64 `json:"timestamp"`
+
+	// Why the request failed, if it did.
+	ErrorMessage string `json:"errorMessage,omitempty"`
+}
+
+// NewKinesisEnvelope creates a KinesisEnvelope holding the given records, with
+// a new request ID and the current time. If gzipRecords is true the data of
+// each record is compressed, as CloudWatch Logs subscriptions do.
+func NewKinesisEnvelope(records [][]byte, gzipRecords bool) (*KinesisEnvelope, error) {
+	id, err := newRequestID()
+	if err != nil {
+		return nil, err
+	}
+
+	env := &KinesisEnvelope{
+		RequestID: id,
+		Timestamp: time.Now().UnixMilli(),
+		Records:   make([]KinesisRecord, 0, len(records)),
+	}
+	for _, data := range records {
+		if gzipRecords {
+			if data, err = gzipBytes(data); err != nil {
+				return nil, err
+			}
+		}
+		env.Records = append(env.Records, KinesisRecord{Data: data})
+	}
+	return env, nil
+}
+
+// DecodeKinesisEnvelope decodes a KinesisEnvelope, which may itself be gzip
+// compressed.
+func DecodeKinesisEnvelope(raw []byte) (*KinesisEnvelope, error) {
+	if isGzip(raw) {
+		var err error
+		if raw, err = gunzip(raw); err != nil {
+			return nil, fmt.Errorf("failed to decompress envelope: %w", err)
+		}
+	}
+
+	var env KinesisEnvelope
+	if err := json.Unmarshal(raw, &env); err != nil {
+		return nil, fmt.Errorf("failed to decode envelope: %w", err)
+	}
+	if env.RequestID == "" || env.Records == nil {
+		return nil, newValidationError("kinesis envelope", []string{"requestId and records are required"})
+	}
+	return &env, nil
+}
+
+// Encode returns the envelope as JSON, as Firehose sends it, compressed if
+// gzipBody is true.
+func (env *KinesisEnvelope) Encode(gzipBody bool) ([]byte, error) {
+	body, err := json.Marshal(env)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal envelope: %w", err)
+	}
+	if gzipBody {
+		return gzipBytes(body)
+	}
+	return body, nil
+}
+
+// Decoded returns the data of the record, decompressed if it was gzipped, and
+// whether it was.
+func (r KinesisRecord) Decoded() ([]byte, bool, error) {
+	if !isGzip(r.Data) {
+		return r.Data, false, nil
+	}
+	data, err := gunzip(r.Data)
+	if err != nil {
+		return nil, true, fmt.Errorf("failed to decompress record: %w", err)
+	}
+	return data, true, nil
+}
+
+// SendKinesisEvents sends a KinesisEnvelope to the given dataset as Firehose
+// would, compressing the request if gzipBody is true. The dataset is created
+// if it does not exist.
+// https://docs.honeycomb.io/api/tag/Kinesis-Events
+func (c *Client) SendKinesisEvents(ctx context.Context, dataset string, env *KinesisEnvelope, gzipBody bool) (*KinesisResponse, error) {
+	body, err := env.Encode(gzipBody)
+	if err != nil {
+		return nil, err
+	}
+
+	header := http.Header{}
+	header.Set("X-Amz-Firehose-Request-Id", env.RequestID)
+	header.Set("X-Amz-Firehose-Access-Key", c.configKey)
+	if gzipBody {
+		header.Set("Content-Encoding", "gzip")
+	}
+
+	var out KinesisResponse
+	if err := c.do(ctx, http.MethodPost, pathFor("/1/kinesis_events", dataset), header, body, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// newRequestID returns a random (version 4) UUID.
+func newRequestID() (string, error) {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return "", fmt.Errorf("failed to generate request ID: %w", err)
+	}
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16]), nil
+}
+
+// isGzip reports whether b starts with the gzip magic number.
+func isGzip(b []byte) bool {
+	return len(b) >= 2 && b[0] == 0x1f && b[1] == 0x8b
+}
+
+func gzipBytes(b []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if _, err := zw.Write(b); err != nil {
+		return nil, fmt.Errorf("failed to compress: %w", err)
+	}
+	if err := zw.Close(); err != nil {
+		return nil, fmt.Errorf("failed to compress: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+func gunzip(b []byte) ([]byte, error) {
+	zr, err := gzip.NewReader(bytes.NewReader(b))
+	if err != nil {
+		return nil, err
+	}
+	defer zr.Close()
+	return io.ReadAll(zr)
+}
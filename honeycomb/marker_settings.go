@@ -0,0 +1,64 @@
+package honeycomb
+
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
+// MarkerSetting applies to a group of similar Markers, for example giving all
+// 'deploys' Markers the same color.
+type MarkerSetting struct {
+	// Groups similar Markers. For example, 'deploys'. All Markers of the same
+	// type appear with the same color on the graph.
+	Type string `json:"type,omitempty"`
+
+	// Color to use for display of this marker type. Specified as hexadecimal
+	// RGB. For example, "#F96E11".
+	Color string `json:"color,omitempty"`
+
+	// The unique identifier (ID) of a Marker Setting.
+	ID string `json:"id,omitempty"`
+
+	// The ISO8601-formatted time when the Marker Setting was created.
+	CreatedAt *time.Time `json:"created_at,omitempty"`
+
+	// The ISO8601-formatted time when the Marker Setting was updated.
+	UpdatedAt *time.Time `json:"updated_at,omitempty"`
+}
+
+// CreateMarkerSetting creates a Marker Setting in the given dataset.
+// https://docs.honeycomb.io/api/tag/Marker-Settings#operation/createMarkerSetting
+func (c *Client) CreateMarkerSetting(ctx context.Context, dataset string, ms *MarkerSetting) (*MarkerSetting, error) {
+	var out MarkerSetting
+	if err := c.Do(ctx, http.MethodPost, pathFor("/1/marker_settings", dataset), ms, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// ListMarkerSettings lists all Marker Settings in the given dataset.
+// https://docs.honeycomb.io/api/tag/Marker-Settings#operation/listMarkerSettings
+func (c *Client) ListMarkerSettings(ctx context.Context, dataset string) ([]MarkerSetting, error) {
+	var out []MarkerSetting
+	if err := c.Do(ctx, http.MethodGet, pathFor("/1/marker_settings", dataset), nil, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// UpdateMarkerSetting updates the Marker Setting with the given ID.
+// https://docs.honeycomb.io/api/tag/Marker-Settings#operation/updateMarkerSettings
+func (c *Client) UpdateMarkerSetting(ctx context.Context, dataset, id string, ms *MarkerSetting) (*MarkerSetting, error) {
+	var out MarkerSetting
+	if err := c.Do(ctx, http.MethodPut, pathFor("/1/marker_settings", dataset, id), ms, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// DeleteMarkerSetting deletes the Marker Setting with the given ID.
+// https://docs.honeycomb.io/api/tag/Marker-Settings#operation/deleteMarkerSettings
+func (c *Client) DeleteMarkerSetting(ctx context.Context, dataset, id string) error {
+	return c.Do(ctx, http.MethodDelete, pathFor("/1/marker_settings", dataset, id), nil, nil)
+}
@@ -0,0 +1,81 @@
+package honeycomb
+
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
+// Marker indicates a point in time on graphs where something interesting
+// happened, such as a deploy or an outage.
+type Marker struct {
+	// Indicates the time the Marker should be placed. If missing, defaults to
+	// the time the request arrives. Expressed in Unix Time.
+	StartTime int64 `json:"start_time,omitempty"`
+
+	// Specifies end time, and allows a Marker to be recorded as representing a
+	// time range, such as a 5 minute deploy. Expressed in Unix Time.
+	EndTime int64 `json:"end_time,omitempty"`
+
+	// A message to describe this specific Marker.
+	Message string `json:"message,omitempty"`
+
+	// Groups similar Markers. For example, 'deploys'. All Markers of the same
+	// type appear with the same color on the graph.
+	Type string `json:"type,omitempty"`
+
+	// A target for the marker. Clicking the marker text will take you to this
+	// URL.
+	URL string `json:"url,omitempty"`
+
+	// The unique identifier (ID) of a Marker.
+	ID string `json:"id,omitempty"`
+
+	// Color can be assigned to Markers using the Marker Settings endpoint. This
+	// field will be populated when List All Markers is called.
+	Color string `json:"color,omitempty"`
+
+	// The ISO8601-formatted time when the Marker was created.
+	CreatedAt *time.Time `json:"created_at,omitempty"`
+
+	// The ISO8601-formatted time when the Marker was updated.
+	UpdatedAt *time.Time `json:"updated_at,omitempty"`
+}
+
+// CreateMarker creates a Marker in the given dataset. Use EnvironmentWide to
+// create an environment Marker.
+// https://docs.honeycomb.io/api/tag/Markers#operation/createMarker
+func (c *Client) CreateMarker(ctx context.Context, dataset string, m *Marker) (*Marker, error) {
+	var out Marker
+	if err := c.Do(ctx, http.MethodPost, pathFor("/1/markers", dataset), m, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// ListMarkers lists all Markers for the given dataset. Use EnvironmentWide to
+// list environment Markers.
+// https://docs.honeycomb.io/api/tag/Markers#operation/getMarker
+func (c *Client) ListMarkers(ctx context.Context, dataset string) ([]Marker, error) {
+	var out []Marker
+	if err := c.Do(ctx, http.MethodGet, pathFor("/1/markers", dataset), nil, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// UpdateMarker updates the Marker with the given ID.
+// https://docs.honeycomb.io/api/tag/Markers#operation/updateMarker
+func (c *Client) UpdateMarker(ctx context.Context, dataset, id string, m *Marker) (*Marker, error) {
+	var out Marker
+	if err := c.Do(ctx, http.MethodPut, pathFor("/1/markers", dataset, id), m, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// DeleteMarker deletes the Marker with the given ID.
+// https://docs.honeycomb.io/api/tag/Markers#operation/deleteMarker
+func (c *Client) DeleteMarker(ctx context.Context, dataset, id string) error {
+	return c.Do(ctx, http.MethodDelete, pathFor("/1/markers", dataset, id), nil, nil)
+}
@@ -0,0 +1,272 @@
+package honeycomb
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"golang.org/x/exp/slices"
+)
+
+var (
+	// QueryCalculationOps are the valid values of QueryCalculation.Op,
+	// QueryOrder.Op and QueryHaving.CalculateOp.
+	QueryCalculationOps = []string{
+		"COUNT", "CONCURRENCY", "SUM", "AVG", "COUNT_DISTINCT", "HEATMAP", "MAX",
+		"MIN", "P001", "P01", "P05", "P10", "P25", "P50", "P75", "P90", "P95", "P99",
+		"P999", "RATE_AVG", "RATE_SUM", "RATE_MAX",
+	}
+
+	// QueryFilterOps are the valid values of QueryFilter.Op.
+	QueryFilterOps = []string{
+		"=", "!=", ">", ">=", "<", "<=", "starts-with", "does-not-start-with",
+		"exists", "does-not-exist", "contains", "does-not-contain", "in",
+		"not-in",
+	}
+
+	// QueryHavingOps are the valid values of QueryHaving.Op.
+	QueryHavingOps = []string{"=", "!=", ">", ">=", "<", "<="}
+
+	// QueryOrderDirections are the valid values of QueryOrder.Order.
+	QueryOrderDirections = []string{"ascending", "descending"}
+)
+
+// MaxQueryLimit is the largest Query.Limit accepted when creating a Query.
+const MaxQueryLimit = 1000
+
+// calculationTakesColumn reports whether a calculation op is performed over a
+// column. COUNT and CONCURRENCY are performed over whole events.
+func calculationTakesColumn(op string) bool {
+	return op != "COUNT" && op != "CONCURRENCY"
+}
+
+// QueryCalculation is a calculation to return as a time series and summary
+// table.
+type QueryCalculation struct {
+	// Enum:
+	//		"COUNT" "CONCURRENCY" "SUM" "AVG" "COUNT_DISTINCT" "HEATMAP" "MAX"
+	//		"MIN" "P001" "P01" "P05" "P10" "P25" "P50" "P75" "P90" "P95" "P99"
+	//		"P999" "RATE_AVG" "RATE_SUM" "RATE_MAX"
+	Op string `json:"op,omitempty"`
+
+	// The name of the column.
+	Column string `json:"column,omitempty"`
+}
+
+// String returns the calculation as it is written in the query language, e.g.
+// P99(duration_ms), which is also its key in the data of a Query Result.
+func (c QueryCalculation) String() string {
+	return formatCalculation(c.Op, c.Column)
+}
+
+// QueryFilter restricts the events considered by a Query.
+type QueryFilter struct {
+	// Enum:
+	//		"=" "!=" ">" ">=" "<" "<=" "starts-with" "does-not-start-with"
+	//		"exists" "does-not-exist" "contains" "does-not-contain" "in"
+	//		"not-in"
+	Op string `json:"op,omitempty"`
+
+	// The name of the column.
+	Column string `json:"column,omitempty"`
+
+	// The value to compare against.
+	Value interface{} `json:"value,omitempty"`
+}
+
+// QueryOrder is a term on which to order the results of a Query.
+type QueryOrder struct {
+	// The name of the column.
+	Column string `json:"column,omitempty"`
+
+	// Enum:
+	//		"COUNT" "CONCURRENCY" "SUM" "AVG" "COUNT_DISTINCT" "HEATMAP" "MAX"
+	//		"MIN" "P001" "P01" "P05" "P10" "P25" "P50" "P75" "P90" "P95" "P99"
+	//		"P999" "RATE_AVG" "RATE_SUM" "RATE_MAX"
+	Op string `json:"op,omitempty"`
+
+	Order string `json:"order,omitempty"`
+}
+
+// QueryHaving filters the results table of a Query.
+type QueryHaving struct {
+	// Enum:
+	//		"COUNT" "CONCURRENCY" "SUM" "AVG" "COUNT_DISTINCT" "HEATMAP" "MAX"
+	//		"MIN" "P001" "P01" "P05" "P10" "P25" "P50" "P75" "P90" "P95" "P99"
+	//		"P999" "RATE_AVG" "RATE_SUM" "RATE_MAX"
+	CalculateOp string `json:"calculate_op,omitempty"`
+
+	// The name of the column to filter against.
+	Column string `json:"column,omitempty"`
+
+	// Enum:
+	//		 "=" "!=" ">" ">=" "<" "<="
+	Op string `json:"op,omitempty"`
+
+	// The value to compare the result of the calculation against.
+	Value float64 `json:"value"`
+}
+
+// Query is a Query Specification, describing the events to consider and the
+// calculations to perform over them.
+type Query struct {
+	// The ID of a query returned from the Queries endpoint.
+	ID string `json:"id,omitempty"`
+
+	// The columns by which to break events down into groups.
+	Breakdowns []string `json:"breakdowns,omitempty"`
+
+	// The calculations to return as a time series and summary table.
+	Calculations []QueryCalculation `json:"calculations,omitempty"`
+
+	// The filters with which to restrict the considered events.
+	Filters []QueryFilter `json:"filters,omitempty"`
+
+	// set to "OR" to match ANY filter in the filter list.
+	FilterCombination string `json:"filter_combination,omitempty"`
+
+	// The time resolution of the query's graph, in seconds. Given a query time
+	// range T, valid values (T/1000...T/10).
+	Granularity int `json:"granularity,omitempty"`
+
+	// The terms on which to order the query results. Each term must appear in
+	// either the breakdowns field or the calculations field.
+	Orders []QueryOrder `json:"orders,omitempty"`
+
+	// The maximum number of unique groups returned in 'results'. Aggregating
+	// many unique groups across a large time range is computationally
+	// expensive, and too high a limit with too many unique groups may cause
+	// queries to fail completey. Limiting the results to only the needed
+	// values can significantly speed up queries. The normal allowed maximum
+	// value when creating a query is 1_000. When running 'disable_series'
+	// queries, this can be overridden to be up to 10_000, so the maximum value
+	// returned from the API when fetching a query may be up to 10_000.
+	Limit int `json:"limit,omitempty"`
+
+	// Absolute start time of query, in seconds since UNIX epoch.
+	// Must be <= end_time.
+	StartTime int `json:"start_time,omitempty"`
+
+	// Absolute end time of query, in seconds since UNIX epoch.
+	EndTime int `json:"end_time,omitempty"`
+
+	// Time range of query in seconds. Can be used with either start_time
+	// (seconds after start_time), end_time (seconds before end_time), or
+	// without either (seconds before now).
+	TimeRange int `json:"time_range,omitempty"`
+
+	// The Having clause allows you to filter on the results table. This
+	// operation is distinct from the Where clause, which filters the
+	// underlying events. Order By allows you to order the results, and Having
+	// filters them.
+	Havings []QueryHaving `json:"havings,omitempty"`
+}
+
+// CreateQuery creates a Query in the given dataset, returning it with its ID.
+// Use EnvironmentWide to query across all datasets in the environment.
+// https://docs.honeycomb.io/api/tag/Queries#operation/createQuery
+func (c *Client) CreateQuery(ctx context.Context, dataset string, q *Query) (*Query, error) {
+	var out Query
+	if err := c.Do(ctx, http.MethodPost, pathFor("/1/queries", dataset), q, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetQuery gets a single Query by ID.
+// https://docs.honeycomb.io/api/tag/Queries#operation/getQuery
+func (c *Client) GetQuery(ctx context.Context, dataset, id string) (*Query, error) {
+	var out Query
+	if err := c.Do(ctx, http.MethodGet, pathFor("/1/queries", dataset, id), nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// Validate checks the Query against the constraints documented by the API, so
+// that mistakes are caught before a request is sent.
+func (q *Query) Validate() error {
+	var problems []string
+
+	for _, c := range q.Calculations {
+		switch {
+		case !slices.Contains(QueryCalculationOps, c.Op):
+			problems = append(problems, fmt.Sprintf("calculation op %q must be one of %q", c.Op, QueryCalculationOps))
+		case calculationTakesColumn(c.Op) && c.Column == "":
+			problems = append(problems, fmt.Sprintf("calculation %s requires a column", c.Op))
+		case !calculationTakesColumn(c.Op) && c.Column != "":
+			problems = append(problems, fmt.Sprintf("calculation %s does not take a column", c.Op))
+		}
+	}
+
+	for _, f := range q.Filters {
+		if f.Column == "" {
+			problems = append(problems, "each filter requires a column")
+		}
+		switch {
+		case !slices.Contains(QueryFilterOps, f.Op):
+			problems = append(problems, fmt.Sprintf("filter op %q must be one of %q", f.Op, QueryFilterOps))
+		case f.Op == "exists" || f.Op == "does-not-exist":
+			if f.Value != nil {
+				problems = append(problems, fmt.Sprintf("filter %s %s does not take a value", f.Column, f.Op))
+			}
+		case f.Op == "in" || f.Op == "not-in":
+			if _, ok := f.Value.([]interface{}); !ok {
+				problems = append(problems, fmt.Sprintf("filter %s %s requires a list of values", f.Column, f.Op))
+			}
+		case f.Value == nil:
+			problems = append(problems, fmt.Sprintf("filter %s %s requires a value", f.Column, f.Op))
+		}
+	}
+	if q.FilterCombination != "" && q.FilterCombination != "AND" && q.FilterCombination != "OR" {
+		problems = append(problems, fmt.Sprintf("filter_combination %q must be AND or OR", q.FilterCombination))
+	}
+
+	for _, o := range q.Orders {
+		if o.Order != "" && !slices.Contains(QueryOrderDirections, o.Order) {
+			problems = append(problems, fmt.Sprintf("order %q must be one of %q", o.Order, QueryOrderDirections))
+		}
+		if o.Op == "" {
+			if !slices.Contains(q.Breakdowns, o.Column) {
+				problems = append(problems, fmt.Sprintf("order by %s must be one of the breakdowns", o.Column))
+			}
+			continue
+		}
+		if !slices.Contains(q.Calculations, QueryCalculation{Op: o.Op, Column: o.Column}) {
+			problems = append(problems, fmt.Sprintf("order by %s must be one of the calculations", formatCalculation(o.Op, o.Column)))
+		}
+	}
+
+	for _, h := range q.Havings {
+		if !slices.Contains(QueryHavingOps, h.Op) {
+			problems = append(problems, fmt.Sprintf("having op %q must be one of %q", h.Op, QueryHavingOps))
+		}
+		if !slices.Contains(q.Calculations, QueryCalculation{Op: h.CalculateOp, Column: h.Column}) {
+			problems = append(problems, fmt.Sprintf("having %s must be one of the calculations", formatCalculation(h.CalculateOp, h.Column)))
+		}
+	}
+
+	if q.Limit < 0 || q.Limit > MaxQueryLimit {
+		problems = append(problems, fmt.Sprintf("limit %d must be between 1 and %d, or omitted", q.Limit, MaxQueryLimit))
+	}
+	if q.Granularity < 0 {
+		problems = append(problems, fmt.Sprintf("granularity %d must not be negative", q.Granularity))
+	}
+	if q.TimeRange < 0 {
+		problems = append(problems, fmt.Sprintf("time_range %d must not be negative", q.TimeRange))
+	}
+	if q.StartTime != 0 && q.EndTime != 0 && q.StartTime > q.EndTime {
+		problems = append(problems, "start_time must not be after end_time")
+	}
+
+	return newValidationError("query", problems)
+}
+
+// formatCalculation renders a calculation as it is written in the query
+// language, e.g. P99(duration_ms) or COUNT.
+func formatCalculation(op, column string) string {
+	if column == "" {
+		return op
+	}
+	return op + "(" + column + ")"
+}
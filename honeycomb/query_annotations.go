@@ -0,0 +1,102 @@
+package honeycomb
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// QueryAnnotation gives a Query a name and description, which are shown
+// wherever the Query is displayed, such as on a Board.
+type QueryAnnotation struct {
+	// The unique identifier (ID) of a Query Annotation.
+	ID string `json:"id,omitempty"`
+
+	// The name to display for the Query.
+	Name string `json:"name,omitempty"`
+
+	// The description to display for the Query.
+	Description string `json:"description,omitempty"`
+
+	// The ID of the Query that the Query Annotation describes.
+	QueryID string `json:"query_id,omitempty"`
+
+	// Whether the Query Annotation was created for a Query or for a Board.
+	// Enum:
+	//		"query" "board"
+	Source string `json:"source,omitempty"`
+
+	// The ISO8601-formatted time when the Query Annotation was created.
+	CreatedAt *time.Time `json:"created_at,omitempty"`
+
+	// The ISO8601-formatted time when the Query Annotation was updated.
+	UpdatedAt *time.Time `json:"updated_at,omitempty"`
+}
+
+// Validate checks the Query Annotation against the constraints documented by
+// the API, so that mistakes are caught before a request is sent.
+func (qa *QueryAnnotation) Validate() error {
+	var problems []string
+
+	if qa.Name == "" {
+		problems = append(problems, "name is required")
+	}
+	if qa.QueryID == "" {
+		problems = append(problems, "query_id is required")
+	}
+
+	return newValidationError("query annotation", problems)
+}
+
+// CreateQueryAnnotation creates a Query Annotation in the given dataset. Use
+// EnvironmentWide to annotate an environment-wide Query.
+// https://docs.honeycomb.io/api/tag/Query-Annotations#operation/createQueryAnnotation
+func (c *Client) CreateQueryAnnotation(ctx context.Context, dataset string, qa *QueryAnnotation) (*QueryAnnotation, error) {
+	var out QueryAnnotation
+	if err := c.Do(ctx, http.MethodPost, pathFor("/1/query_annotations", dataset), qa, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// ListQueryAnnotations lists all Query Annotations in the given dataset. Query
+// Annotations created for Boards are only included if includeBoard is true.
+// https://docs.honeycomb.io/api/tag/Query-Annotations#operation/listQueryAnnotations
+func (c *Client) ListQueryAnnotations(ctx context.Context, dataset string, includeBoard bool) ([]QueryAnnotation, error) {
+	var out []QueryAnnotation
+	path := pathFor("/1/query_annotations", dataset)
+	if includeBoard {
+		path += "?" + url.Values{"include_board_annotations": {"true"}}.Encode()
+	}
+	if err := c.Do(ctx, http.MethodGet, path, nil, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// GetQueryAnnotation gets a single Query Annotation by ID.
+// https://docs.honeycomb.io/api/tag/Query-Annotations#operation/getQueryAnnotation
+func (c *Client) GetQueryAnnotation(ctx context.Context, dataset, id string) (*QueryAnnotation, error) {
+	var out QueryAnnotation
+	if err := c.Do(ctx, http.MethodGet, pathFor("/1/query_annotations", dataset, id), nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// UpdateQueryAnnotation replaces the Query Annotation with the given ID.
+// https://docs.honeycomb.io/api/tag/Query-Annotations#operation/updateQueryAnnotation
+func (c *Client) UpdateQueryAnnotation(ctx context.Context, dataset, id string, qa *QueryAnnotation) (*QueryAnnotation, error) {
+	var out QueryAnnotation
+	if err := c.Do(ctx, http.MethodPut, pathFor("/1/query_annotations", dataset, id), qa, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// DeleteQueryAnnotation deletes the Query Annotation with the given ID.
+// https://docs.honeycomb.io/api/tag/Query-Annotations#operation/deleteQueryAnnotation
+func (c *Client) DeleteQueryAnnotation(ctx context.Context, dataset, id string) error {
+	return c.Do(ctx, http.MethodDelete, pathFor("/1/query_annotations", dataset, id), nil, nil)
+}
@@ -0,0 +1,581 @@
+package honeycomb
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/exp/slices"
+)
+
+// ParseQuery parses a Query written in a compact text form, such as:
+//
+//	P99(duration_ms), COUNT
+//	WHERE service.name = api AND status_code >= 500
+//	GROUP BY route
+//	ORDER BY P99(duration_ms) DESC
+//	LIMIT 20
+//	HAVING COUNT > 10
+//	LAST 2h
+//
+// The calculations come first, followed by any of these clauses in any order:
+//
+//   - WHERE filter [AND|OR filter]..., where a filter is a column, an op and a
+//     value, e.g. route starts-with /api, or region in (eu, us). The exists and
+//     does-not-exist ops take no value.
+//   - GROUP BY column[, column]...
+//   - ORDER BY term [ASC|DESC][, ...], where a term is a breakdown column or a
+//     calculation.
+//   - LIMIT n
+//   - HAVING calculation op number[, ...]
+//   - LAST duration, e.g. 30m, 2h or 7d, or BETWEEN start AND end, where the
+//     times are RFC3339 or seconds since the UNIX epoch.
+//   - GRANULARITY duration
+//
+// Keywords and calculation ops are case-insensitive. Values are numbers,
+// booleans or strings, and need quoting only if they contain spaces or
+// punctuation. Columns with such names can be quoted with backticks.
+//
+// Syntax errors are returned as a *QuerySyntaxError, and the parsed Query is
+// checked with Validate.
+func ParseQuery(input string) (*Query, error) {
+	toks, err := lexQuery(input)
+	if err != nil {
+		return nil, err
+	}
+
+	if toks[0].kind == queryTokenEOF {
+		return nil, &QuerySyntaxError{Input: input, Pos: 0, Msg: "the query is empty"}
+	}
+
+	p := &queryParser{input: input, toks: toks}
+	q, err := p.parse()
+	if err != nil {
+		return nil, err
+	}
+	if err := q.Validate(); err != nil {
+		return nil, err
+	}
+	return q, nil
+}
+
+// QuerySyntaxError describes a problem with the text of a Query, and where in
+// the text it was found.
+type QuerySyntaxError struct {
+	// The text being parsed.
+	Input string
+
+	// The byte offset in Input at which the problem was found.
+	Pos int
+
+	// A description of the problem.
+	Msg string
+}
+
+// Is reports whether target is ErrValidation.
+func (e *QuerySyntaxError) Is(target error) bool {
+	return target == ErrValidation
+}
+
+// Error returns a single line summary of the error.
+func (e *QuerySyntaxError) Error() string {
+	return fmt.Sprintf("syntax error at column %d: %s", e.Pos+1, e.Msg)
+}
+
+// Caret returns the line of Input holding the problem, and a second line
+// pointing at it with a caret.
+func (e *QuerySyntaxError) Caret() string {
+	start := strings.LastIndexByte(e.Input[:e.Pos], '\n') + 1
+	end := len(e.Input)
+	if i := strings.IndexByte(e.Input[e.Pos:], '\n'); i >= 0 {
+		end = e.Pos + i
+	}
+	return e.Input[start:end] + "\n" + strings.Repeat(" ", e.Pos-start) + "^"
+}
+
+type queryTokenKind int
+
+const (
+	queryTokenEOF    queryTokenKind = iota
+	queryTokenWord                  // keywords, ops, columns and unquoted values
+	queryTokenString                // quoted values and backtick-quoted columns
+	queryTokenSymbol                // = != > >= < <=
+	queryTokenLParen
+	queryTokenRParen
+	queryTokenComma
+)
+
+type queryToken struct {
+	kind queryTokenKind
+	text string
+	pos  int
+}
+
+// describe returns the token as it should be quoted in an error message.
+func (t queryToken) describe() string {
+	if t.kind == queryTokenEOF {
+		return "end of query"
+	}
+	return strconv.Quote(t.text)
+}
+
+// isQueryDelimiter reports whether c ends an unquoted word.
+func isQueryDelimiter(c byte) bool {
+	return strings.IndexByte(" \t\r\n(),=!<>\"'`", c) >= 0
+}
+
+func lexQuery(input string) ([]queryToken, error) {
+	var toks []queryToken
+	for i := 0; i < len(input); {
+		c := input[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\r' || c == '\n':
+			i++
+		case c == '(':
+			toks = append(toks, queryToken{queryTokenLParen, "(", i})
+			i++
+		case c == ')':
+			toks = append(toks, queryToken{queryTokenRParen, ")", i})
+			i++
+		case c == ',':
+			toks = append(toks, queryToken{queryTokenComma, ",", i})
+			i++
+		case c == '"' || c == '\'' || c == '`':
+			var b strings.Builder
+			j := i + 1
+			for ; j < len(input) && input[j] != c; j++ {
+				if input[j] == '\\' && j+1 < len(input) {
+					j++
+				}
+				b.WriteByte(input[j])
+			}
+			if j >= len(input) {
+				return nil, &QuerySyntaxError{Input: input, Pos: i, Msg: "unterminated quoted string"}
+			}
+			toks = append(toks, queryToken{queryTokenString, b.String(), i})
+			i = j + 1
+		case c == '=' || c == '!' || c == '<' || c == '>':
+			j := i + 1
+			if j < len(input) && input[j] == '=' && c != '=' {
+				j++
+			}
+			if input[i:j] == "!" {
+				return nil, &QuerySyntaxError{Input: input, Pos: i, Msg: `unexpected "!", did you mean "!="?`}
+			}
+			toks = append(toks, queryToken{queryTokenSymbol, input[i:j], i})
+			i = j
+		default:
+			j := i
+			for j < len(input) && !isQueryDelimiter(input[j]) {
+				j++
+			}
+			toks = append(toks, queryToken{queryTokenWord, input[i:j], i})
+			i = j
+		}
+	}
+	return append(toks, queryToken{queryTokenEOF, "", len(input)}), nil
+}
+
+// queryClauses are the keywords that start a clause, following the
+// calculations.
+var queryClauses = []string{"WHERE", "GROUP", "ORDER", "LIMIT", "HAVING", "LAST", "BETWEEN", "GRANULARITY"}
+
+type queryParser struct {
+	input string
+	toks  []queryToken
+	i     int
+}
+
+func (p *queryParser) peek() queryToken {
+	return p.toks[p.i]
+}
+
+func (p *queryParser) next() queryToken {
+	t := p.toks[p.i]
+	if t.kind != queryTokenEOF {
+		p.i++
+	}
+	return t
+}
+
+func (p *queryParser) errorf(t queryToken, format string, args ...interface{}) error {
+	return &QuerySyntaxError{Input: p.input, Pos: t.pos, Msg: fmt.Sprintf(format, args...)}
+}
+
+// isKeyword reports whether t is the unquoted keyword kw.
+func isKeyword(t queryToken, kw string) bool {
+	return t.kind == queryTokenWord && strings.EqualFold(t.text, kw)
+}
+
+// clauseOf returns the clause keyword t starts, or "" if it starts none.
+func clauseOf(t queryToken) string {
+	if t.kind != queryTokenWord {
+		return ""
+	}
+	kw := strings.ToUpper(t.text)
+	if slices.Contains(queryClauses, kw) {
+		return kw
+	}
+	return ""
+}
+
+func (p *queryParser) expectKeyword(kw string) error {
+	t := p.next()
+	if !isKeyword(t, kw) {
+		return p.errorf(t, "expected %s, found %s", kw, t.describe())
+	}
+	return nil
+}
+
+func (p *queryParser) expect(kind queryTokenKind, what string) (queryToken, error) {
+	t := p.next()
+	if t.kind != kind {
+		return t, p.errorf(t, "expected %s, found %s", what, t.describe())
+	}
+	return t, nil
+}
+
+func (p *queryParser) parse() (*Query, error) {
+	q := &Query{}
+
+	if t := p.peek(); t.kind != queryTokenEOF && clauseOf(t) == "" {
+		if err := p.parseList(func() error {
+			c, err := p.parseCalculation()
+			q.Calculations = append(q.Calculations, c)
+			return err
+		}); err != nil {
+			return nil, err
+		}
+	}
+
+	seen := map[string]bool{}
+	for p.peek().kind != queryTokenEOF {
+		t := p.next()
+		clause := clauseOf(t)
+		if clause == "" {
+			return nil, p.errorf(t, "expected one of WHERE, GROUP BY, ORDER BY, LIMIT, HAVING, LAST, BETWEEN or GRANULARITY, found %s", t.describe())
+		}
+		if seen[clause] {
+			return nil, p.errorf(t, "%s can only be given once", clause)
+		}
+		seen[clause] = true
+
+		var err error
+		switch clause {
+		case "WHERE":
+			err = p.parseWhere(q)
+		case "GROUP":
+			if err = p.expectKeyword("BY"); err == nil {
+				err = p.parseList(func() error {
+					col, err := p.parseColumn()
+					q.Breakdowns = append(q.Breakdowns, col)
+					return err
+				})
+			}
+		case "ORDER":
+			if err = p.expectKeyword("BY"); err == nil {
+				err = p.parseList(func() error {
+					o, err := p.parseOrder()
+					q.Orders = append(q.Orders, o)
+					return err
+				})
+			}
+		case "LIMIT":
+			q.Limit, err = p.parseInt()
+		case "HAVING":
+			err = p.parseHavings(q)
+		case "LAST":
+			if seen["BETWEEN"] {
+				return nil, p.errorf(t, "LAST cannot be used with BETWEEN")
+			}
+			q.TimeRange, err = p.parseDuration()
+		case "BETWEEN":
+			if seen["LAST"] {
+				return nil, p.errorf(t, "BETWEEN cannot be used with LAST")
+			}
+			if q.StartTime, err = p.parseTime(); err == nil {
+				if err = p.expectKeyword("AND"); err == nil {
+					q.EndTime, err = p.parseTime()
+				}
+			}
+		case "GRANULARITY":
+			q.Granularity, err = p.parseDuration()
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return q, nil
+}
+
+// parseList calls item for each element of a comma-separated list.
+func (p *queryParser) parseList(item func() error) error {
+	for {
+		if err := item(); err != nil {
+			return err
+		}
+		if p.peek().kind != queryTokenComma {
+			return nil
+		}
+		p.next()
+	}
+}
+
+// parseColumn parses a column name, which may be quoted with backticks (or
+// any other quotes) if it clashes with a keyword or contains punctuation.
+func (p *queryParser) parseColumn() (string, error) {
+	t := p.next()
+	switch {
+	case t.kind == queryTokenString:
+		return t.text, nil
+	case t.kind == queryTokenWord && clauseOf(t) == "":
+		return t.text, nil
+	default:
+		return "", p.errorf(t, "expected a column, found %s", t.describe())
+	}
+}
+
+// parseCalculation parses an op with an optional column in parentheses, e.g.
+// COUNT or P99(duration_ms).
+func (p *queryParser) parseCalculation() (QueryCalculation, error) {
+	t := p.next()
+	op := strings.ToUpper(t.text)
+	if t.kind != queryTokenWord || !slices.Contains(QueryCalculationOps, op) {
+		return QueryCalculation{}, p.errorf(t, "expected a calculation (one of %s), found %s", strings.Join(QueryCalculationOps, ", "), t.describe())
+	}
+
+	c := QueryCalculation{Op: op}
+	if p.peek().kind == queryTokenLParen {
+		lparen := p.next()
+		if !calculationTakesColumn(op) {
+			return c, p.errorf(lparen, "%s does not take a column", op)
+		}
+		var err error
+		if c.Column, err = p.parseColumn(); err != nil {
+			return c, err
+		}
+		if _, err := p.expect(queryTokenRParen, `")"`); err != nil {
+			return c, err
+		}
+	} else if calculationTakesColumn(op) {
+		return c, p.errorf(p.peek(), "%s requires a column, e.g. %s(duration_ms)", op, op)
+	}
+
+	return c, nil
+}
+
+func (p *queryParser) parseWhere(q *Query) error {
+	for {
+		f, err := p.parseFilter()
+		if err != nil {
+			return err
+		}
+		q.Filters = append(q.Filters, f)
+
+		t := p.peek()
+		var combination string
+		switch {
+		case isKeyword(t, "AND"):
+			combination = "AND"
+		case isKeyword(t, "OR"):
+			combination = "OR"
+		default:
+			return nil
+		}
+		p.next()
+
+		// The API combines all filters with the same operator.
+		if len(q.Filters) > 1 && q.FilterCombination != combination && (q.FilterCombination != "" || combination == "OR") {
+			return p.errorf(t, "AND and OR cannot be mixed, as all filters are combined with the same operator")
+		}
+		if combination == "OR" {
+			q.FilterCombination = "OR"
+		}
+	}
+}
+
+func (p *queryParser) parseFilter() (QueryFilter, error) {
+	col, err := p.parseColumn()
+	if err != nil {
+		return QueryFilter{}, err
+	}
+	f := QueryFilter{Column: col}
+
+	t := p.next()
+	switch t.kind {
+	case queryTokenSymbol:
+		f.Op = t.text
+	case queryTokenWord:
+		f.Op = strings.ToLower(t.text)
+	}
+	if !slices.Contains(QueryFilterOps, f.Op) {
+		return f, p.errorf(t, "expected a filter op (one of %s), found %s", strings.Join(QueryFilterOps, " "), t.describe())
+	}
+
+	switch f.Op {
+	case "exists", "does-not-exist":
+		return f, nil
+	case "in", "not-in":
+		if _, err := p.expect(queryTokenLParen, `"(" to start a list of values`); err != nil {
+			return f, err
+		}
+		var values []interface{}
+		if err := p.parseList(func() error {
+			v, err := p.parseValue()
+			values = append(values, v)
+			return err
+		}); err != nil {
+			return f, err
+		}
+		if _, err := p.expect(queryTokenRParen, `")" to end the list of values`); err != nil {
+			return f, err
+		}
+		f.Value = values
+		return f, nil
+	default:
+		f.Value, err = p.parseValue()
+		return f, err
+	}
+}
+
+// parseValue parses a filter value. Unquoted values are converted to numbers
+// or booleans where possible.
+func (p *queryParser) parseValue() (interface{}, error) {
+	t := p.next()
+	switch {
+	case t.kind == queryTokenString:
+		return t.text, nil
+	case t.kind == queryTokenWord && clauseOf(t) == "" && !isKeyword(t, "AND") && !isKeyword(t, "OR"):
+		if i, err := strconv.ParseInt(t.text, 10, 64); err == nil {
+			return i, nil
+		}
+		if f, err := strconv.ParseFloat(t.text, 64); err == nil && !math.IsInf(f, 0) && !math.IsNaN(f) {
+			return f, nil
+		}
+		if b, err := strconv.ParseBool(t.text); err == nil && (t.text == "true" || t.text == "false") {
+			return b, nil
+		}
+		return t.text, nil
+	default:
+		return nil, p.errorf(t, "expected a value, found %s", t.describe())
+	}
+}
+
+// parseOrder parses a breakdown column or calculation, followed by an optional
+// direction.
+func (p *queryParser) parseOrder() (QueryOrder, error) {
+	var o QueryOrder
+
+	t := p.peek()
+	op := strings.ToUpper(t.text)
+	if t.kind == queryTokenWord && slices.Contains(QueryCalculationOps, op) &&
+		(p.toks[p.i+1].kind == queryTokenLParen || !calculationTakesColumn(op)) {
+		c, err := p.parseCalculation()
+		if err != nil {
+			return o, err
+		}
+		o.Op, o.Column = c.Op, c.Column
+	} else {
+		col, err := p.parseColumn()
+		if err != nil {
+			return o, err
+		}
+		o.Column = col
+	}
+
+	switch t := p.peek(); {
+	case isKeyword(t, "ASC"):
+		p.next()
+		o.Order = "ascending"
+	case isKeyword(t, "DESC"):
+		p.next()
+		o.Order = "descending"
+	}
+
+	return o, nil
+}
+
+func (p *queryParser) parseHavings(q *Query) error {
+	for {
+		c, err := p.parseCalculation()
+		if err != nil {
+			return err
+		}
+		h := QueryHaving{CalculateOp: c.Op, Column: c.Column}
+
+		t := p.next()
+		if t.kind != queryTokenSymbol || !slices.Contains(QueryHavingOps, t.text) {
+			return p.errorf(t, "expected a comparison (one of %s), found %s", strings.Join(QueryHavingOps, " "), t.describe())
+		}
+		h.Op = t.text
+
+		t = p.next()
+		if h.Value, err = strconv.ParseFloat(t.text, 64); err != nil || t.kind != queryTokenWord {
+			return p.errorf(t, "expected a number, found %s", t.describe())
+		}
+		q.Havings = append(q.Havings, h)
+
+		if t := p.peek(); t.kind != queryTokenComma && !isKeyword(t, "AND") {
+			return nil
+		}
+		p.next()
+	}
+}
+
+func (p *queryParser) parseInt() (int, error) {
+	t := p.next()
+	n, err := strconv.Atoi(t.text)
+	if err != nil || t.kind != queryTokenWord || n <= 0 {
+		return 0, p.errorf(t, "expected a positive whole number, found %s", t.describe())
+	}
+	return n, nil
+}
+
+// parseDuration parses a duration into whole seconds. As well as the units
+// accepted by time.ParseDuration, d (days) and w (weeks) are accepted, and a
+// bare number is taken as seconds.
+func (p *queryParser) parseDuration() (int, error) {
+	t := p.next()
+	if t.kind != queryTokenWord {
+		return 0, p.errorf(t, "expected a duration such as 30m, 2h or 7d, found %s", t.describe())
+	}
+
+	var d time.Duration
+	var err error
+	switch unit := t.text[len(t.text)-1]; {
+	case unit >= '0' && unit <= '9':
+		var n int
+		n, err = strconv.Atoi(t.text)
+		d = time.Duration(n) * time.Second
+	case unit == 'd' || unit == 'w':
+		var n float64
+		n, err = strconv.ParseFloat(t.text[:len(t.text)-1], 64)
+		d = time.Duration(n * 24 * float64(time.Hour))
+		if unit == 'w' {
+			d *= 7
+		}
+	default:
+		d, err = time.ParseDuration(t.text)
+	}
+	if err != nil || d < time.Second || d%time.Second != 0 {
+		return 0, p.errorf(t, "expected a duration of whole seconds such as 30m, 2h or 7d, found %s", t.describe())
+	}
+	return int(d / time.Second), nil
+}
+
+// parseTime parses an RFC3339 time, or seconds since the UNIX epoch.
+func (p *queryParser) parseTime() (int, error) {
+	t := p.next()
+	if t.kind == queryTokenWord || t.kind == queryTokenString {
+		if n, err := strconv.Atoi(t.text); err == nil && n > 0 {
+			return n, nil
+		}
+		if ts, err := time.Parse(time.RFC3339, t.text); err == nil {
+			return int(ts.Unix()), nil
+		}
+	}
+	return 0, p.errorf(t, "expected an RFC3339 time or seconds since the UNIX epoch, found %s", t.describe())
+}
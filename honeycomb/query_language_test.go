@@ -0,0 +1,284 @@
+package honeycomb
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseQuery(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  *Query
+	}{
+		{
+			name: "every clause",
+			input: "P99(duration_ms), COUNT WHERE service.name = api AND status_code >= 500 GROUP BY route " +
+				"ORDER BY P99(duration_ms) DESC LIMIT 20 HAVING COUNT > 10 LAST 2h",
+			want: &Query{
+				Calculations: []QueryCalculation{{Op: "P99", Column: "duration_ms"}, {Op: "COUNT"}},
+				Filters: []QueryFilter{
+					{Op: "=", Column: "service.name", Value: "api"},
+					{Op: ">=", Column: "status_code", Value: int64(500)},
+				},
+				Breakdowns: []string{"route"},
+				Orders:     []QueryOrder{{Op: "P99", Column: "duration_ms", Order: "descending"}},
+				Limit:      20,
+				Havings:    []QueryHaving{{CalculateOp: "COUNT", Op: ">", Value: 10}},
+				TimeRange:  7200,
+			},
+		},
+		{
+			name:  "clauses in any order over several lines",
+			input: "count\nlast 1h\ngroup by route\nwhere route exists",
+			want: &Query{
+				Calculations: []QueryCalculation{{Op: "COUNT"}},
+				Filters:      []QueryFilter{{Op: "exists", Column: "route"}},
+				Breakdowns:   []string{"route"},
+				TimeRange:    3600,
+			},
+		},
+		{
+			name:  "calculations",
+			input: "CONCURRENCY, heatmap(duration_ms), count_distinct(`user id`)",
+			want: &Query{
+				Calculations: []QueryCalculation{
+					{Op: "CONCURRENCY"},
+					{Op: "HEATMAP", Column: "duration_ms"},
+					{Op: "COUNT_DISTINCT", Column: "user id"},
+				},
+			},
+		},
+		{
+			name:  "filters without calculations",
+			input: "WHERE route starts-with /api",
+			want: &Query{
+				Filters: []QueryFilter{{Op: "starts-with", Column: "route", Value: "/api"}},
+			},
+		},
+		{
+			name:  "filter values",
+			input: `WHERE a = 1.5 OR b != true OR c = "two words" OR d < 'x' OR ` + "`where` does-not-contain False",
+			want: &Query{
+				Filters: []QueryFilter{
+					{Op: "=", Column: "a", Value: 1.5},
+					{Op: "!=", Column: "b", Value: true},
+					{Op: "=", Column: "c", Value: "two words"},
+					{Op: "<", Column: "d", Value: "x"},
+					{Op: "does-not-contain", Column: "where", Value: "False"},
+				},
+				FilterCombination: "OR",
+			},
+		},
+		{
+			name:  "filter lists",
+			input: "WHERE region in (eu, us, 3) AND error does-not-exist AND zone not-in ('a b')",
+			want: &Query{
+				Filters: []QueryFilter{
+					{Op: "in", Column: "region", Value: []interface{}{"eu", "us", int64(3)}},
+					{Op: "does-not-exist", Column: "error"},
+					{Op: "not-in", Column: "zone", Value: []interface{}{"a b"}},
+				},
+			},
+		},
+		{
+			name:  "breakdowns and orders",
+			input: "COUNT, MAX(duration_ms) GROUP BY route, `status code` ORDER BY route ASC, COUNT, MAX(duration_ms) DESC",
+			want: &Query{
+				Calculations: []QueryCalculation{{Op: "COUNT"}, {Op: "MAX", Column: "duration_ms"}},
+				Breakdowns:   []string{"route", "status code"},
+				Orders: []QueryOrder{
+					{Column: "route", Order: "ascending"},
+					{Op: "COUNT"},
+					{Op: "MAX", Column: "duration_ms", Order: "descending"},
+				},
+			},
+		},
+		{
+			name:  "order by a breakdown named after a calculation",
+			input: "COUNT GROUP BY sum ORDER BY sum",
+			want: &Query{
+				Calculations: []QueryCalculation{{Op: "COUNT"}},
+				Breakdowns:   []string{"sum"},
+				Orders:       []QueryOrder{{Column: "sum"}},
+			},
+		},
+		{
+			name:  "havings",
+			input: "COUNT, AVG(d) HAVING AVG(d) >= 1.5 AND COUNT != 0, COUNT < 100",
+			want: &Query{
+				Calculations: []QueryCalculation{{Op: "COUNT"}, {Op: "AVG", Column: "d"}},
+				Havings: []QueryHaving{
+					{CalculateOp: "AVG", Column: "d", Op: ">=", Value: 1.5},
+					{CalculateOp: "COUNT", Op: "!=", Value: 0},
+					{CalculateOp: "COUNT", Op: "<", Value: 100},
+				},
+			},
+		},
+		{
+			name:  "limit",
+			input: "COUNT LIMIT 1000",
+			want:  &Query{Calculations: []QueryCalculation{{Op: "COUNT"}}, Limit: 1000},
+		},
+		{
+			name:  "last in days",
+			input: "COUNT LAST 7d",
+			want:  &Query{Calculations: []QueryCalculation{{Op: "COUNT"}}, TimeRange: 604800},
+		},
+		{
+			name:  "last in weeks",
+			input: "COUNT LAST 2w",
+			want:  &Query{Calculations: []QueryCalculation{{Op: "COUNT"}}, TimeRange: 1209600},
+		},
+		{
+			name:  "last in seconds",
+			input: "COUNT LAST 90",
+			want:  &Query{Calculations: []QueryCalculation{{Op: "COUNT"}}, TimeRange: 90},
+		},
+		{
+			name:  "between",
+			input: `COUNT BETWEEN 2026-01-01T00:00:00Z AND "1767229200"`,
+			want: &Query{
+				Calculations: []QueryCalculation{{Op: "COUNT"}},
+				StartTime:    1767225600,
+				EndTime:      1767229200,
+			},
+		},
+		{
+			name:  "granularity",
+			input: "COUNT LAST 1d GRANULARITY 5m",
+			want:  &Query{Calculations: []QueryCalculation{{Op: "COUNT"}}, TimeRange: 86400, Granularity: 300},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseQuery(tt.input)
+			if err != nil {
+				t.Fatalf("ParseQuery(%q): %v", tt.input, err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseQuery(%q)\n got %+v\nwant %+v", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseQueryZeroHaving(t *testing.T) {
+	q, err := ParseQuery("COUNT HAVING COUNT > 0")
+	if err != nil {
+		t.Fatalf("ParseQuery: %v", err)
+	}
+	raw, err := json.Marshal(q.Havings)
+	if err != nil {
+		t.Fatalf("Marshal: %v", err)
+	}
+	if !strings.Contains(string(raw), `"value":0`) {
+		t.Errorf("havings encoded as %s, want a value of 0", raw)
+	}
+}
+
+func TestParseQuerySyntaxErrors(t *testing.T) {
+	tests := []struct {
+		input string
+		pos   int
+		msg   string
+	}{
+		{"", 0, "the query is empty"},
+		{"  \n ", 0, "the query is empty"},
+		{"COUNT WHERE name = 'api", 19, "unterminated quoted string"},
+		{"COUNT WHERE a ! 1", 14, `unexpected "!", did you mean "!="?`},
+		{"FOO(x)", 0, "expected a calculation (one of " + strings.Join(QueryCalculationOps, ", ") + `), found "FOO"`},
+		{"COUNT, , P99(d)", 7, "expected a calculation (one of " + strings.Join(QueryCalculationOps, ", ") + `), found ","`},
+		{"P99", 3, "P99 requires a column, e.g. P99(duration_ms)"},
+		{"COUNT(x)", 5, "COUNT does not take a column"},
+		{"P99(duration_ms", 15, `expected ")", found end of query`},
+		{"P99(LIMIT)", 4, `expected a column, found "LIMIT"`},
+		{"COUNT route", 6, `expected one of WHERE, GROUP BY, ORDER BY, LIMIT, HAVING, LAST, BETWEEN or GRANULARITY, found "route"`},
+		{"COUNT WHERE a like b", 14, `expected a filter op (one of ` + strings.Join(QueryFilterOps, " ") + `), found "like"`},
+		{"COUNT WHERE a = 1 AND b = 2 OR c = 3", 28, "AND and OR cannot be mixed, as all filters are combined with the same operator"},
+		{"COUNT WHERE a = 1 OR b = 2 AND c = 3", 27, "AND and OR cannot be mixed, as all filters are combined with the same operator"},
+		{"COUNT WHERE region in eu", 22, `expected "(" to start a list of values, found "eu"`},
+		{"COUNT WHERE region in (eu, us", 29, `expected ")" to end the list of values, found end of query`},
+		{"COUNT WHERE a =", 15, "expected a value, found end of query"},
+		{"COUNT WHERE a = AND", 16, `expected a value, found "AND"`},
+		{"COUNT GROUP route", 12, `expected BY, found "route"`},
+		{"COUNT GROUP BY LIMIT", 15, `expected a column, found "LIMIT"`},
+		{"COUNT ORDER route", 12, `expected BY, found "route"`},
+		{"COUNT LIMIT 0", 12, `expected a positive whole number, found "0"`},
+		{"COUNT LIMIT ten", 12, `expected a positive whole number, found "ten"`},
+		{"COUNT LIMIT 5 LIMIT 6", 14, "LIMIT can only be given once"},
+		{"COUNT HAVING COUNT ~ 1", 19, `expected a comparison (one of = != > >= < <=), found "~"`},
+		{"COUNT HAVING COUNT > ten", 21, `expected a number, found "ten"`},
+		{"COUNT HAVING COUNT > '1'", 21, `expected a number, found "1"`},
+		{"COUNT LAST 2h BETWEEN 1 AND 2", 14, "BETWEEN cannot be used with LAST"},
+		{"COUNT BETWEEN 1 AND 2 LAST 2h", 22, "LAST cannot be used with BETWEEN"},
+		{"COUNT LAST (", 11, `expected a duration such as 30m, 2h or 7d, found "("`},
+		{"COUNT LAST 1.5s", 11, `expected a duration of whole seconds such as 30m, 2h or 7d, found "1.5s"`},
+		{"COUNT LAST 500ms", 11, `expected a duration of whole seconds such as 30m, 2h or 7d, found "500ms"`},
+		{"COUNT GRANULARITY soon", 18, `expected a duration of whole seconds such as 30m, 2h or 7d, found "soon"`},
+		{"COUNT BETWEEN yesterday AND 2", 14, `expected an RFC3339 time or seconds since the UNIX epoch, found "yesterday"`},
+		{"COUNT BETWEEN 1 TO 2", 16, `expected AND, found "TO"`},
+		{"COUNT BETWEEN 1 AND", 19, "expected an RFC3339 time or seconds since the UNIX epoch, found end of query"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			_, err := ParseQuery(tt.input)
+			var syntaxErr *QuerySyntaxError
+			if !errors.As(err, &syntaxErr) {
+				t.Fatalf("ParseQuery(%q) = %v, want a *QuerySyntaxError", tt.input, err)
+			}
+			if syntaxErr.Pos != tt.pos || syntaxErr.Msg != tt.msg {
+				t.Errorf("ParseQuery(%q) error at %d: %s\nwant at %d: %s", tt.input, syntaxErr.Pos, syntaxErr.Msg, tt.pos, tt.msg)
+			}
+			if !errors.Is(err, ErrValidation) {
+				t.Errorf("ParseQuery(%q) error is not ErrValidation", tt.input)
+			}
+		})
+	}
+}
+
+func TestParseQueryValidationErrors(t *testing.T) {
+	tests := []struct {
+		input   string
+		problem string
+	}{
+		{"COUNT GROUP BY a ORDER BY b", "order by b must be one of the breakdowns"},
+		{"COUNT ORDER BY P99(d)", "order by P99(d) must be one of the calculations"},
+		{"COUNT HAVING P99(d) > 1", "having P99(d) must be one of the calculations"},
+		{"COUNT LIMIT 1001", "limit 1001 must be between 1 and 1000, or omitted"},
+		{"COUNT BETWEEN 2 AND 1", "start_time must not be after end_time"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			_, err := ParseQuery(tt.input)
+			var validationErr *ValidationError
+			if !errors.As(err, &validationErr) {
+				t.Fatalf("ParseQuery(%q) = %v, want a *ValidationError", tt.input, err)
+			}
+			if !reflect.DeepEqual(validationErr.Problems, []string{tt.problem}) {
+				t.Errorf("ParseQuery(%q) problems = %q, want [%q]", tt.input, validationErr.Problems, tt.problem)
+			}
+		})
+	}
+}
+
+func TestQuerySyntaxErrorMessage(t *testing.T) {
+	_, err := ParseQuery("COUNT\nWHERE a ! 1")
+	var syntaxErr *QuerySyntaxError
+	if !errors.As(err, &syntaxErr) {
+		t.Fatalf("ParseQuery = %v, want a *QuerySyntaxError", err)
+	}
+
+	if got, want := syntaxErr.Error(), `syntax error at column 15: unexpected "!", did you mean "!="?`; got != want {
+		t.Errorf("Error() = %q, want %q", got, want)
+	}
+	if got, want := syntaxErr.Caret(), "WHERE a ! 1\n        ^"; got != want {
+		t.Errorf("Caret() =\n%s\nwant\n%s", got, want)
+	}
+}
@@ -0,0 +1,160 @@
+package honeycomb
+
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
+const (
+	// queryPollInitialDelay is how long RunQuery waits before first checking
+	// whether a Query Result is complete.
+	queryPollInitialDelay = 250 * time.Millisecond
+
+	// queryPollMaxDelay caps the wait between checks of a Query Result.
+	queryPollMaxDelay = 5 * time.Second
+)
+
+// QueryResultCreateRequest asks for a Query to be run.
+type QueryResultCreateRequest struct {
+	// The ID of a query returned from the Queries endpoint.
+	QueryID string `json:"query_id,omitempty"`
+
+	// If true, will disable calculation and return of the full time-series
+	// data, usually included in the 'series' response field, instead only
+	// returning the summarized 'results'.
+	DisableSeries bool `json:"disable_series,omitempty"`
+
+	// If 'disable_series' is true, then a limit may optionally be given, which
+	// will override the limit normally associated with the query. Unlike normal
+	// query results which are limited to 1_000 results, 'disable_series'
+	// results may have a limit of up to 10_000. If 'disable_series' is false,
+	// then this field will be ignored.
+	Limit int `json:"limit,omitempty"`
+}
+
+// QueryResultLinks are links to the Query Result in the Honeycomb UI.
+type QueryResultLinks struct {
+	QueryURL string `json:"query_url,omitempty"`
+
+	GraphImageURL string `json:"graph_image_url,omitempty"`
+}
+
+// QueryResultSeries is a single point of the time series of a Query Result,
+// for a single group.
+type QueryResultSeries struct {
+	Time string `json:"time,omitempty"`
+
+	Data map[string]interface{} `json:"data,omitempty"`
+}
+
+// QueryResultResults is a single row of the summary table of a Query Result.
+type QueryResultResults struct {
+	Data map[string]interface{} `json:"data,omitempty"`
+}
+
+// QueryResultData holds the time series and summary table of a Query Result.
+type QueryResultData struct {
+	Series []QueryResultSeries `json:"series,omitempty"`
+
+	Results []QueryResultResults `json:"results,omitempty"`
+}
+
+// QueryResult is the outcome of running a Query.
+type QueryResult struct {
+	// The query that this result is for.
+	Query Query `json:"query,omitempty"`
+
+	// The unique identifier (ID) of a Query Result
+	ID string `json:"id,omitempty"`
+
+	// Indicates if the query results are available yet or not. For example, is
+	// the query still being processed or complete?
+	Complete bool `json:"complete"`
+
+	// Data
+	Data QueryResultData `json:"data,omitempty"`
+
+	// Links
+	Links QueryResultLinks `json:"links,omitempty"`
+}
+
+// CreateQueryResult starts running a Query. The result is usually not
+// complete when it is returned; use GetQueryResult to poll for it, or
+// RunQuery to do both.
+// https://docs.honeycomb.io/api/tag/Query-Data#operation/createQueryResult
+func (c *Client) CreateQueryResult(ctx context.Context, dataset string, req *QueryResultCreateRequest) (*QueryResult, error) {
+	var out QueryResult
+	if err := c.Do(ctx, http.MethodPost, pathFor("/1/query_results", dataset), req, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetQueryResult gets a single Query Result by ID.
+// https://docs.honeycomb.io/api/tag/Query-Data#operation/getQueryResult
+func (c *Client) GetQueryResult(ctx context.Context, dataset, id string) (*QueryResult, error) {
+	var out QueryResult
+	if err := c.Do(ctx, http.MethodGet, pathFor("/1/query_results", dataset, id), nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// WaitForQueryResult polls a Query Result, with exponential backoff, until it
+// is complete or ctx is done. Use context.WithTimeout to bound the wait.
+func (c *Client) WaitForQueryResult(ctx context.Context, dataset, id string) (*QueryResult, error) {
+	delay := queryPollInitialDelay
+	for {
+		qr, err := c.GetQueryResult(ctx, dataset, id)
+		if err != nil {
+			return nil, err
+		}
+		if qr.Complete || c.dryRun != nil {
+			return qr, nil
+		}
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+
+		delay *= 2
+		if delay > queryPollMaxDelay {
+			delay = queryPollMaxDelay
+		}
+	}
+}
+
+// RunQuery runs a Query and waits for its result: it creates the Query if it
+// has no ID, creates a Query Result for it, and polls until the result is
+// complete or ctx is done. req may be nil; its QueryID is always replaced.
+func (c *Client) RunQuery(ctx context.Context, dataset string, q *Query, req *QueryResultCreateRequest) (*QueryResult, error) {
+	queryID := q.ID
+	if queryID == "" {
+		created, err := c.CreateQuery(ctx, dataset, q)
+		if err != nil {
+			return nil, err
+		}
+		queryID = created.ID
+	}
+
+	r := QueryResultCreateRequest{}
+	if req != nil {
+		r = *req
+	}
+	r.QueryID = queryID
+
+	qr, err := c.CreateQueryResult(ctx, dataset, &r)
+	if err != nil {
+		return nil, err
+	}
+	if qr.Complete || c.dryRun != nil {
+		return qr, nil
+	}
+
+	return c.WaitForQueryResult(ctx, dataset, qr.ID)
+}
@@ -0,0 +1,213 @@
+package honeycomb
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/mail"
+	"net/url"
+	"time"
+
+	"golang.org/x/exp/slices"
+)
+
+var (
+	// RecipientTypes are the valid values of Recipient.Type.
+	RecipientTypes = []string{"email", "slack", "pagerduty", "webhook", "msteams"}
+
+	// PagerDutySeverities are the valid values of
+	// NotificationRecipientDetails.PagerDutySeverity.
+	PagerDutySeverities = []string{"info", "warning", "error", "critical"}
+)
+
+// RecipientDetails holds the settings of a Recipient. Which fields apply
+// depends on the type of Recipient:
+//
+//   - email: EmailAddress
+//   - slack: SlackChannel
+//   - pagerduty: PagerDutyIntegrationKey and PagerDutyIntegrationName
+//   - webhook: WebhookName, WebhookURL and optionally WebhookSecret
+//   - msteams: WebhookName and WebhookURL
+type RecipientDetails struct {
+	// The email address to notify.
+	EmailAddress string `json:"email_address,omitempty"`
+
+	// The Slack channel to notify, e.g. #alerts.
+	SlackChannel string `json:"slack_channel,omitempty"`
+
+	// The integration key of the PagerDuty service to raise incidents on.
+	PagerDutyIntegrationKey string `json:"pagerduty_integration_key,omitempty"`
+
+	// A name for the PagerDuty integration.
+	PagerDutyIntegrationName string `json:"pagerduty_integration_name,omitempty"`
+
+	// A name for the webhook or Microsoft Teams channel.
+	WebhookName string `json:"webhook_name,omitempty"`
+
+	// The URL notifications are posted to.
+	WebhookURL string `json:"webhook_url,omitempty"`
+
+	// A secret sent with webhook notifications, so that the receiver can
+	// verify they came from Honeycomb.
+	WebhookSecret string `json:"webhook_secret,omitempty"`
+}
+
+// Recipient is a destination for the notifications sent by Triggers and Burn
+// Alerts.
+type Recipient struct {
+	// The unique identifier (ID) of a Recipient.
+	ID string `json:"id,omitempty"`
+
+	// Enum: "email" "slack" "pagerduty" "webhook" "msteams"
+	Type string `json:"type,omitempty"`
+
+	// The settings of the Recipient, which depend on its Type.
+	Details RecipientDetails `json:"details"`
+
+	// The ISO8601-formatted time when the Recipient was created.
+	CreatedAt *time.Time `json:"created_at,omitempty"`
+
+	// The ISO8601-formatted time when the Recipient was updated.
+	UpdatedAt *time.Time `json:"updated_at,omitempty"`
+}
+
+// Validate checks that the Recipient has the details required by its type,
+// and none belonging to other types, so that mistakes are caught before a
+// request is sent.
+func (r *Recipient) Validate() error {
+	var problems []string
+
+	d := r.Details
+	fields := []struct {
+		name  string
+		value string
+		types []string
+	}{
+		{"email_address", d.EmailAddress, []string{"email"}},
+		{"slack_channel", d.SlackChannel, []string{"slack"}},
+		{"pagerduty_integration_key", d.PagerDutyIntegrationKey, []string{"pagerduty"}},
+		{"pagerduty_integration_name", d.PagerDutyIntegrationName, []string{"pagerduty"}},
+		{"webhook_name", d.WebhookName, []string{"webhook", "msteams"}},
+		{"webhook_url", d.WebhookURL, []string{"webhook", "msteams"}},
+	}
+
+	if !slices.Contains(RecipientTypes, r.Type) {
+		problems = append(problems, fmt.Sprintf("type %q must be one of %q", r.Type, RecipientTypes))
+		return newValidationError("recipient", problems)
+	}
+
+	for _, f := range fields {
+		applies := slices.Contains(f.types, r.Type)
+		switch {
+		case applies && f.value == "":
+			problems = append(problems, fmt.Sprintf("%s is required for %s recipients", f.name, r.Type))
+		case !applies && f.value != "":
+			problems = append(problems, fmt.Sprintf("%s cannot be set for %s recipients", f.name, r.Type))
+		}
+	}
+	if r.Type != "webhook" && d.WebhookSecret != "" {
+		problems = append(problems, fmt.Sprintf("webhook_secret cannot be set for %s recipients", r.Type))
+	}
+
+	if d.EmailAddress != "" {
+		if _, err := mail.ParseAddress(d.EmailAddress); err != nil {
+			problems = append(problems, fmt.Sprintf("email_address %q is not a valid email address", d.EmailAddress))
+		}
+	}
+	if d.PagerDutyIntegrationKey != "" && len(d.PagerDutyIntegrationKey) != 32 {
+		problems = append(problems, "pagerduty_integration_key must be 32 characters")
+	}
+	if d.WebhookURL != "" {
+		if u, err := url.Parse(d.WebhookURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			problems = append(problems, fmt.Sprintf("webhook_url %q must be an http or https URL", d.WebhookURL))
+		}
+	}
+
+	return newValidationError("recipient", problems)
+}
+
+// NotificationRecipientDetails holds the settings specific to a type of
+// Recipient when it is attached to an alert.
+type NotificationRecipientDetails struct {
+	// The severity of the incident raised for a PagerDuty Recipient.
+	// Enum: "info" "warning" "error" "critical"
+	PagerDutySeverity string `json:"pagerduty_severity,omitempty"`
+}
+
+// NotificationRecipient is a Recipient attached to a Trigger or Burn Alert.
+// Either the ID of an existing Recipient, or its Type and Target, must be set.
+type NotificationRecipient struct {
+	// The unique identifier (ID) of a Recipient.
+	ID string `json:"id,omitempty"`
+
+	// Enum: "email" "marker" "msteams" "pagerduty" "slack" "webhook"
+	Type string `json:"type,omitempty"`
+
+	// The target of the notification, such as an email address or a Slack
+	// channel.
+	Target string `json:"target,omitempty"`
+
+	// Settings specific to the type of Recipient.
+	Details *NotificationRecipientDetails `json:"details,omitempty"`
+}
+
+// notificationRecipientProblems returns the problems with the Recipients
+// attached to a Trigger or Burn Alert.
+func notificationRecipientProblems(recipients []NotificationRecipient) []string {
+	var problems []string
+	for _, r := range recipients {
+		if r.ID == "" && (r.Type == "" || r.Target == "") {
+			problems = append(problems, "each recipient needs an id, or a type and target")
+		}
+		if r.Details != nil && r.Details.PagerDutySeverity != "" && !slices.Contains(PagerDutySeverities, r.Details.PagerDutySeverity) {
+			problems = append(problems, fmt.Sprintf("recipient pagerduty_severity %q must be one of %q", r.Details.PagerDutySeverity, PagerDutySeverities))
+		}
+	}
+	return problems
+}
+
+// CreateRecipient creates a Recipient.
+// https://docs.honeycomb.io/api/tag/Recipients#operation/createRecipient
+func (c *Client) CreateRecipient(ctx context.Context, r *Recipient) (*Recipient, error) {
+	var out Recipient
+	if err := c.Do(ctx, http.MethodPost, "/1/recipients", r, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// ListRecipients lists all Recipients.
+// https://docs.honeycomb.io/api/tag/Recipients#operation/listRecipients
+func (c *Client) ListRecipients(ctx context.Context) ([]Recipient, error) {
+	var out []Recipient
+	if err := c.Do(ctx, http.MethodGet, "/1/recipients", nil, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// GetRecipient gets a single Recipient by ID.
+// https://docs.honeycomb.io/api/tag/Recipients#operation/getRecipient
+func (c *Client) GetRecipient(ctx context.Context, id string) (*Recipient, error) {
+	var out Recipient
+	if err := c.Do(ctx, http.MethodGet, pathFor("/1/recipients", id), nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// UpdateRecipient replaces the Recipient with the given ID.
+// https://docs.honeycomb.io/api/tag/Recipients#operation/updateRecipient
+func (c *Client) UpdateRecipient(ctx context.Context, id string, r *Recipient) (*Recipient, error) {
+	var out Recipient
+	if err := c.Do(ctx, http.MethodPut, pathFor("/1/recipients", id), r, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// DeleteRecipient deletes the Recipient with the given ID.
+// https://docs.honeycomb.io/api/tag/Recipients#operation/deleteRecipient
+func (c *Client) DeleteRecipient(ctx context.Context, id string) error {
+	return c.Do(ctx, http.MethodDelete, pathFor("/1/recipients", id), nil, nil)
+}
@@ -0,0 +1,203 @@
+package honeycomb
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httputil"
+	"strings"
+)
+
+// RedactedValue replaces any secret masked by a Redactor.
+const RedactedValue = "[REDACTED]"
+
+var (
+	// DefaultRedactedHeaders are the request headers masked by NewRedactor.
+	DefaultRedactedHeaders = []string{
+		"X-Honeycomb-Team",
+		"X-Amz-Firehose-Access-Key",
+		"Authorization",
+		"Cookie",
+	}
+
+	// DefaultRedactedFields are the JSON body fields masked by NewRedactor,
+	// such as the secrets held by webhook and PagerDuty recipients.
+	DefaultRedactedFields = []string{
+		"webhook_secret",
+		"pagerduty_integration_key",
+		"integration_key",
+		"secret",
+		"api_key",
+		"password",
+		"token",
+	}
+)
+
+// Redactor masks secrets in requests, logs and error messages. It knows about
+// three kinds of secret: headers (by name), JSON body fields (by key, at any
+// depth) and literal values, such as the configuration key itself, wherever
+// they appear.
+//
+// Anything that writes a request or response outside the process, such as a
+// dry run dump or an HTTP recording, should pass it through a Redactor first.
+type Redactor struct {
+	headers []string
+	fields  []string
+	values  []string
+}
+
+// NewRedactor creates a Redactor masking the default headers and fields.
+func NewRedactor() *Redactor {
+	r := &Redactor{}
+	r.AddHeaders(DefaultRedactedHeaders...)
+	r.AddFields(DefaultRedactedFields...)
+	return r
+}
+
+// AddHeaders masks the named request and response headers.
+func (r *Redactor) AddHeaders(names ...string) {
+	for _, n := range names {
+		r.headers = append(r.headers, http.CanonicalHeaderKey(n))
+	}
+}
+
+// AddFields masks the named JSON body fields, wherever they appear.
+func (r *Redactor) AddFields(names ...string) {
+	for _, n := range names {
+		r.fields = append(r.fields, strings.ToLower(n))
+	}
+}
+
+// AddValues masks each occurrence of the given literal values. Empty values
+// are ignored.
+func (r *Redactor) AddValues(values ...string) {
+	for _, v := range values {
+		if v != "" {
+			r.values = append(r.values, v)
+		}
+	}
+}
+
+// clone returns a copy of the Redactor that can be modified independently.
+func (r *Redactor) clone() *Redactor {
+	return &Redactor{
+		headers: append([]string(nil), r.headers...),
+		fields:  append([]string(nil), r.fields...),
+		values:  append([]string(nil), r.values...),
+	}
+}
+
+// String masks the literal secret values in s. A nil Redactor returns s
+// unchanged.
+func (r *Redactor) String(s string) string {
+	if r == nil {
+		return s
+	}
+	for _, v := range r.values {
+		s = strings.ReplaceAll(s, v, RedactedValue)
+	}
+	return s
+}
+
+// Header returns a copy of h with the secret headers masked.
+func (r *Redactor) Header(h http.Header) http.Header {
+	out := h.Clone()
+	if r == nil {
+		return out
+	}
+	for name, vals := range out {
+		masked := false
+		for _, rh := range r.headers {
+			if http.CanonicalHeaderKey(name) == rh {
+				masked = true
+				break
+			}
+		}
+		for i := range vals {
+			if masked {
+				vals[i] = RedactedValue
+			} else {
+				vals[i] = r.String(vals[i])
+			}
+		}
+	}
+	return out
+}
+
+// JSON masks the secret fields and values in a JSON document. Documents that
+// contain no secrets, or are not valid JSON, are returned with only their
+// literal values masked.
+func (r *Redactor) JSON(body []byte) []byte {
+	if r == nil || len(body) == 0 {
+		return body
+	}
+
+	var doc interface{}
+	if err := json.Unmarshal(body, &doc); err == nil && r.redactFields(doc) {
+		if out, err := json.Marshal(doc); err == nil {
+			body = out
+		}
+	}
+
+	return []byte(r.String(string(body)))
+}
+
+// redactFields masks the secret fields in a decoded JSON document in place,
+// reporting whether anything was masked.
+func (r *Redactor) redactFields(doc interface{}) bool {
+	changed := false
+	switch d := doc.(type) {
+	case map[string]interface{}:
+		for k, v := range d {
+			if r.isField(k) {
+				if v != nil && v != "" {
+					d[k] = RedactedValue
+					changed = true
+				}
+				continue
+			}
+			if r.redactFields(v) {
+				changed = true
+			}
+		}
+	case []interface{}:
+		for _, v := range d {
+			if r.redactFields(v) {
+				changed = true
+			}
+		}
+	}
+	return changed
+}
+
+func (r *Redactor) isField(name string) bool {
+	name = strings.ToLower(name)
+	for _, f := range r.fields {
+		if name == f {
+			return true
+		}
+	}
+	return false
+}
+
+// DumpRequest returns the wire representation of req, as
+// httputil.DumpRequest would, with its secrets masked. body is the request
+// body, which is read separately as req.Body can only be consumed once.
+func (r *Redactor) DumpRequest(req *http.Request, body []byte) ([]byte, error) {
+	masked := req.Clone(req.Context())
+	masked.Header = r.Header(req.Header)
+
+	// Show compressed bodies as they were before compression.
+	if strings.EqualFold(req.Header.Get("Content-Encoding"), "gzip") {
+		if plain, err := gunzip(body); err == nil {
+			body = plain
+		}
+	}
+
+	maskedBody := r.JSON(body)
+	masked.Body = io.NopCloser(bytes.NewReader(maskedBody))
+	masked.ContentLength = int64(len(maskedBody))
+
+	return httputil.DumpRequest(masked, true)
+}
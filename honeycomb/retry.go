@@ -0,0 +1,156 @@
+package honeycomb
+
+import (
+	"context"
+	"math"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/exp/slices"
+)
+
+// RetryPolicy controls how failed requests are retried.
+//
+// Requests that fail with 429 Too Many Requests are always retried, as the
+// server did not process them. Network errors and 502, 503 and 504 responses
+// are only retried for idempotent methods, so that a POST is never sent twice.
+type RetryPolicy struct {
+	// The maximum number of attempts, including the first. A value of 1 or
+	// less disables retries.
+	MaxAttempts int
+
+	// The delay before the first retry. Each subsequent retry doubles it.
+	BaseDelay time.Duration
+
+	// The maximum delay between attempts, including delays requested by the
+	// server through the Retry-After or rate limit headers.
+	MaxDelay time.Duration
+
+	// The fraction, between 0 and 1, of each delay that is randomised to
+	// avoid many clients retrying in lockstep.
+	Jitter float64
+}
+
+var (
+	// DefaultRetryPolicy is the RetryPolicy used when no other is specified.
+	DefaultRetryPolicy = RetryPolicy{
+		MaxAttempts: 4,
+		BaseDelay:   500 * time.Millisecond,
+		MaxDelay:    30 * time.Second,
+		Jitter:      0.2,
+	}
+
+	// NoRetries disables retrying failed requests.
+	NoRetries = RetryPolicy{MaxAttempts: 1}
+
+	idempotentMethods = []string{
+		http.MethodGet,
+		http.MethodHead,
+		http.MethodPut,
+		http.MethodDelete,
+		http.MethodOptions,
+		http.MethodTrace,
+	}
+
+	retryableStatusCodes = []int{
+		http.StatusBadGateway,
+		http.StatusServiceUnavailable,
+		http.StatusGatewayTimeout,
+	}
+)
+
+// WithRetryPolicy sets the policy used to retry failed requests.
+func WithRetryPolicy(p RetryPolicy) Option {
+	return func(c *Client) error {
+		c.retryPolicy = p
+		return nil
+	}
+}
+
+// shouldRetry reports whether a request that produced resp or err should be
+// attempted again.
+func (p RetryPolicy) shouldRetry(method string, resp *http.Response, err error) bool {
+	if err != nil {
+		return slices.Contains(idempotentMethods, method)
+	}
+	if resp.StatusCode == http.StatusTooManyRequests {
+		return true
+	}
+	return slices.Contains(retryableStatusCodes, resp.StatusCode) && slices.Contains(idempotentMethods, method)
+}
+
+// delay returns how long to wait before the given retry, where retry 1 is the
+// first retry. A delay requested by the server takes precedence over the
+// exponential backoff, but both are capped at MaxDelay.
+func (p RetryPolicy) delay(retry int, resp *http.Response) time.Duration {
+	d, ok := serverDelay(resp)
+	if !ok {
+		d = time.Duration(float64(p.BaseDelay) * math.Pow(2, float64(retry-1)))
+		if p.Jitter > 0 {
+			d += time.Duration(p.Jitter * float64(d) * (rand.Float64()*2 - 1))
+		}
+	}
+
+	if p.MaxDelay > 0 && d > p.MaxDelay {
+		d = p.MaxDelay
+	}
+	if d < 0 {
+		d = 0
+	}
+	return d
+}
+
+// serverDelay reads the delay requested by the server from the Retry-After
+// header, or from Honeycomb's rate limit headers.
+func serverDelay(resp *http.Response) (time.Duration, bool) {
+	if resp == nil {
+		return 0, false
+	}
+
+	if ra := resp.Header.Get("Retry-After"); ra != "" {
+		if secs, err := strconv.Atoi(strings.TrimSpace(ra)); err == nil {
+			return time.Duration(secs) * time.Second, true
+		}
+		if t, err := http.ParseTime(ra); err == nil {
+			return time.Until(t), true
+		}
+	}
+
+	// RateLimit-Reset: 30
+	if reset := resp.Header.Get("RateLimit-Reset"); reset != "" {
+		if secs, err := strconv.Atoi(strings.TrimSpace(reset)); err == nil {
+			return time.Duration(secs) * time.Second, true
+		}
+	}
+
+	// RateLimit: limit=100, remaining=0, reset=30
+	if rl := resp.Header.Get("RateLimit"); rl != "" {
+		for _, part := range strings.Split(rl, ",") {
+			key, val, ok := strings.Cut(strings.TrimSpace(part), "=")
+			if !ok || key != "reset" {
+				continue
+			}
+			if secs, err := strconv.Atoi(val); err == nil {
+				return time.Duration(secs) * time.Second, true
+			}
+		}
+	}
+
+	return 0, false
+}
+
+// sleep waits for d, returning early with an error if ctx is done first.
+func sleep(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
@@ -0,0 +1,248 @@
+package honeycomb
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// testRetryPolicy retries quickly, so that tests do not wait on real backoff.
+var testRetryPolicy = RetryPolicy{
+	MaxAttempts: 3,
+	BaseDelay:   time.Millisecond,
+	MaxDelay:    20 * time.Millisecond,
+}
+
+// scriptedServer responds to each request with the next of statuses, calling
+// header first to set any headers on the response. Once the script runs out
+// it responds with 200 OK. It returns the server and the count of requests.
+func scriptedServer(t *testing.T, statuses []int, header func(http.Header)) (*httptest.Server, *int32) {
+	t.Helper()
+	var calls int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := int(atomic.AddInt32(&calls, 1))
+		if n > len(statuses) {
+			w.WriteHeader(http.StatusOK)
+			w.Write([]byte(`{"ok": true}`))
+			return
+		}
+		if header != nil {
+			header(w.Header())
+		}
+		w.WriteHeader(statuses[n-1])
+		w.Write([]byte(`{"error": "scripted failure"}`))
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &calls
+}
+
+func newTestClient(t *testing.T, host string, p RetryPolicy) *Client {
+	t.Helper()
+	c, err := NewClient(WithAPIHost(host), WithConfigKey("test-key"), WithRetryPolicy(p))
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+	return c
+}
+
+func TestRetryTooManyRequestsWithRetryAfter(t *testing.T) {
+	srv, calls := scriptedServer(t, []int{http.StatusTooManyRequests}, func(h http.Header) {
+		h.Set("Retry-After", "1")
+	})
+	c := newTestClient(t, srv.URL, testRetryPolicy)
+
+	// Retry-After asks for a second, which MaxDelay caps, and the request is
+	// retried even though POST is not idempotent.
+	start := time.Now()
+	var out struct{ OK bool }
+	if err := c.Do(context.Background(), http.MethodPost, "/1/markers/api", map[string]string{}, &out); err != nil {
+		t.Fatalf("Do: %v", err)
+	}
+	if got := atomic.LoadInt32(calls); got != 2 {
+		t.Errorf("got %d requests, want 2", got)
+	}
+	if !out.OK {
+		t.Errorf("response of the retry was not decoded")
+	}
+	if elapsed := time.Since(start); elapsed >= time.Second {
+		t.Errorf("waited %s, want at most MaxDelay", elapsed)
+	}
+}
+
+func TestRetryServiceUnavailable(t *testing.T) {
+	tests := []struct {
+		method    string
+		wantCalls int32
+		wantErr   bool
+	}{
+		{method: http.MethodGet, wantCalls: 2},
+		{method: http.MethodDelete, wantCalls: 2},
+		{method: http.MethodPost, wantCalls: 1, wantErr: true},
+		{method: http.MethodPatch, wantCalls: 1, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.method, func(t *testing.T) {
+			srv, calls := scriptedServer(t, []int{http.StatusServiceUnavailable}, nil)
+			c := newTestClient(t, srv.URL, testRetryPolicy)
+
+			err := c.Do(context.Background(), tt.method, "/1/boards", nil, nil)
+			if got := atomic.LoadInt32(calls); got != tt.wantCalls {
+				t.Errorf("got %d requests, want %d", got, tt.wantCalls)
+			}
+			if !tt.wantErr {
+				if err != nil {
+					t.Errorf("Do: %v", err)
+				}
+				return
+			}
+			var apiErr *APIError
+			if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusServiceUnavailable {
+				t.Errorf("got error %v, want a 503 APIError", err)
+			}
+		})
+	}
+}
+
+func TestRetryMaxAttemptsExhausted(t *testing.T) {
+	srv, calls := scriptedServer(t, []int{
+		http.StatusBadGateway,
+		http.StatusServiceUnavailable,
+		http.StatusGatewayTimeout,
+		http.StatusServiceUnavailable,
+	}, nil)
+	c := newTestClient(t, srv.URL, testRetryPolicy)
+
+	err := c.Do(context.Background(), http.MethodGet, "/1/boards", nil, nil)
+	if got := atomic.LoadInt32(calls); got != int32(testRetryPolicy.MaxAttempts) {
+		t.Errorf("got %d requests, want %d", got, testRetryPolicy.MaxAttempts)
+	}
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusGatewayTimeout {
+		t.Errorf("got error %v, want the 504 APIError of the last attempt", err)
+	}
+}
+
+func TestNoRetries(t *testing.T) {
+	srv, calls := scriptedServer(t, []int{http.StatusTooManyRequests}, nil)
+	c := newTestClient(t, srv.URL, NoRetries)
+
+	if err := c.Do(context.Background(), http.MethodGet, "/1/boards", nil, nil); err == nil {
+		t.Errorf("Do succeeded, want the 429 APIError")
+	}
+	if got := atomic.LoadInt32(calls); got != 1 {
+		t.Errorf("got %d requests, want 1", got)
+	}
+}
+
+func TestRetryDelay(t *testing.T) {
+	p := RetryPolicy{BaseDelay: 100 * time.Millisecond, MaxDelay: 10 * time.Second}
+
+	tests := []struct {
+		name   string
+		policy RetryPolicy
+		retry  int
+		header http.Header
+		want   time.Duration
+	}{
+		{
+			name:   "first retry",
+			policy: p,
+			retry:  1,
+			want:   100 * time.Millisecond,
+		},
+		{
+			name:   "backoff doubles",
+			policy: p,
+			retry:  3,
+			want:   400 * time.Millisecond,
+		},
+		{
+			name:   "backoff capped at MaxDelay",
+			policy: p,
+			retry:  10,
+			want:   10 * time.Second,
+		},
+		{
+			name:   "Retry-After seconds",
+			policy: p,
+			retry:  1,
+			header: http.Header{"Retry-After": {"3"}},
+			want:   3 * time.Second,
+		},
+		{
+			name:   "Retry-After capped at MaxDelay",
+			policy: p,
+			retry:  1,
+			header: http.Header{"Retry-After": {"120"}},
+			want:   10 * time.Second,
+		},
+		{
+			name:   "Retry-After in the past",
+			policy: p,
+			retry:  1,
+			header: http.Header{"Retry-After": {"Mon, 02 Jan 2006 15:04:05 GMT"}},
+			want:   0,
+		},
+		{
+			name:   "RateLimit-Reset",
+			policy: p,
+			retry:  1,
+			header: http.Header{"Ratelimit-Reset": {" 5 "}},
+			want:   5 * time.Second,
+		},
+		{
+			name:   "RateLimit reset",
+			policy: p,
+			retry:  1,
+			header: http.Header{"Ratelimit": {"limit=100, remaining=0, reset=7"}},
+			want:   7 * time.Second,
+		},
+		{
+			name:   "Retry-After takes precedence",
+			policy: p,
+			retry:  1,
+			header: http.Header{"Retry-After": {"2"}, "Ratelimit-Reset": {"5"}},
+			want:   2 * time.Second,
+		},
+		{
+			name:   "unparseable headers fall back to backoff",
+			policy: p,
+			retry:  2,
+			header: http.Header{"Retry-After": {"soon"}, "Ratelimit": {"limit=100, reset=later"}},
+			want:   200 * time.Millisecond,
+		},
+		{
+			name:   "no MaxDelay",
+			policy: RetryPolicy{BaseDelay: time.Second},
+			retry:  1,
+			header: http.Header{"Retry-After": {"120"}},
+			want:   120 * time.Second,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var resp *http.Response
+			if tt.header != nil {
+				resp = &http.Response{StatusCode: http.StatusTooManyRequests, Header: tt.header}
+			}
+			if got := tt.policy.delay(tt.retry, resp); got != tt.want {
+				t.Errorf("delay(%d) = %s, want %s", tt.retry, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRetryDelayJitter(t *testing.T) {
+	p := RetryPolicy{BaseDelay: time.Second, MaxDelay: time.Minute, Jitter: 0.2}
+	for i := 0; i < 100; i++ {
+		if got := p.delay(1, nil); got < 800*time.Millisecond || got > 1200*time.Millisecond {
+			t.Fatalf("delay(1) = %s, want within 20%% of 1s", got)
+		}
+	}
+}
@@ -0,0 +1,119 @@
+package honeycomb
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net/http"
+	"time"
+)
+
+// SLI is the Service Level Indicator of an SLO: a derived column evaluating
+// to true for a good event, false for a bad one, and null for events that
+// should not count.
+type SLI struct {
+	// The alias of the Derived Column used as the SLI.
+	Alias string `json:"alias"`
+}
+
+// SLO is a Service Level Objective: a target proportion of qualified events
+// that should succeed over a rolling time period.
+type SLO struct {
+	// The unique identifier (ID) of an SLO.
+	ID string `json:"id,omitempty"`
+
+	// The name of the SLO.
+	Name string `json:"name,omitempty"`
+
+	// A description of the SLO.
+	Description string `json:"description,omitempty"`
+
+	// The SLI that decides whether each event succeeded.
+	SLI SLI `json:"sli"`
+
+	// The length of the rolling time period, in days.
+	TimePeriodDays int `json:"time_period_days,omitempty"`
+
+	// The target proportion of successful events, per million. For example,
+	// a target of 99.9% is 999000.
+	TargetPerMillion int `json:"target_per_million"`
+
+	// The ISO8601-formatted time when the SLO was created.
+	CreatedAt *time.Time `json:"created_at,omitempty"`
+
+	// The ISO8601-formatted time when the SLO was updated.
+	UpdatedAt *time.Time `json:"updated_at,omitempty"`
+}
+
+// PercentToPerMillion converts a percentage, such as 99.9, to the per-million
+// form used by SLO.TargetPerMillion and the Burn Alert budget rate threshold.
+func PercentToPerMillion(percent float64) int {
+	return int(math.Round(percent * 10000))
+}
+
+// Validate checks the SLO against the constraints documented by the API, so
+// that mistakes are caught before a request is sent.
+func (s *SLO) Validate() error {
+	var problems []string
+
+	if s.Name == "" {
+		problems = append(problems, "name is required")
+	}
+	if s.SLI.Alias == "" {
+		problems = append(problems, "sli alias is required")
+	}
+	if s.TimePeriodDays < 1 || s.TimePeriodDays > 90 {
+		problems = append(problems, fmt.Sprintf("time_period_days %d must be between 1 and 90", s.TimePeriodDays))
+	}
+	if s.TargetPerMillion < 1 || s.TargetPerMillion > 999999 {
+		problems = append(problems, fmt.Sprintf("target_per_million %d must be between 1 and 999999", s.TargetPerMillion))
+	}
+
+	return newValidationError("slo", problems)
+}
+
+// CreateSLO creates an SLO in the given dataset.
+// https://docs.honeycomb.io/api/tag/SLOs#operation/createSlo
+func (c *Client) CreateSLO(ctx context.Context, dataset string, s *SLO) (*SLO, error) {
+	var out SLO
+	if err := c.Do(ctx, http.MethodPost, pathFor("/1/slos", dataset), s, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// ListSLOs lists all SLOs in the given dataset.
+// https://docs.honeycomb.io/api/tag/SLOs#operation/listSlos
+func (c *Client) ListSLOs(ctx context.Context, dataset string) ([]SLO, error) {
+	var out []SLO
+	if err := c.Do(ctx, http.MethodGet, pathFor("/1/slos", dataset), nil, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// GetSLO gets a single SLO by ID.
+// https://docs.honeycomb.io/api/tag/SLOs#operation/getSlo
+func (c *Client) GetSLO(ctx context.Context, dataset, id string) (*SLO, error) {
+	var out SLO
+	if err := c.Do(ctx, http.MethodGet, pathFor("/1/slos", dataset, id), nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// UpdateSLO updates the SLO with the given ID.
+// https://docs.honeycomb.io/api/tag/SLOs#operation/updateSlo
+func (c *Client) UpdateSLO(ctx context.Context, dataset, id string, s *SLO) (*SLO, error) {
+	var out SLO
+	if err := c.Do(ctx, http.MethodPut, pathFor("/1/slos", dataset, id), s, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// DeleteSLO deletes the SLO with the given ID.
+// https://docs.honeycomb.io/api/tag/SLOs#operation/deleteSlo
+func (c *Client) DeleteSLO(ctx context.Context, dataset, id string) error {
+	return c.Do(ctx, http.MethodDelete, pathFor("/1/slos", dataset, id), nil, nil)
+}
@@ -0,0 +1,218 @@
+package honeycomb
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"golang.org/x/exp/slices"
+)
+
+var (
+	// TriggerThresholdOps are the valid comparisons for a Trigger threshold.
+	TriggerThresholdOps = []string{">", ">=", "<", "<="}
+
+	// TriggerAlertTypes are the valid values of Trigger.AlertType.
+	TriggerAlertTypes = []string{"on_change", "on_true"}
+
+	// TriggerEvaluationScheduleTypes are the valid values of
+	// Trigger.EvaluationScheduleType.
+	TriggerEvaluationScheduleTypes = []string{"frequency", "window"}
+
+	// DaysOfWeek are the valid values of TriggerEvaluationWindow.DaysOfWeek.
+	DaysOfWeek = []string{"sunday", "monday", "tuesday", "wednesday", "thursday", "friday", "saturday"}
+)
+
+// TriggerThreshold is the threshold over which a Trigger fires.
+type TriggerThreshold struct {
+	// Enum: ">" ">=" "<" "<="
+	Op string `json:"op"`
+
+	// The value to compare the result of the Trigger's calculation against.
+	Value float64 `json:"value"`
+
+	// The number of times the threshold must be met before an alert is sent.
+	// Must be between 1 and 5; defaults to 1.
+	ExceededLimit int `json:"exceeded_limit,omitempty"`
+}
+
+// TriggerEvaluationWindow restricts when a Trigger is evaluated.
+type TriggerEvaluationWindow struct {
+	// The days of the week on which the Trigger is evaluated.
+	// Enum: "sunday" "monday" "tuesday" "wednesday" "thursday" "friday"
+	//		"saturday"
+	DaysOfWeek []string `json:"days_of_week,omitempty"`
+
+	// The UTC time at which evaluation starts, in HH:mm format.
+	StartTime string `json:"start_time,omitempty"`
+
+	// The UTC time at which evaluation ends, in HH:mm format.
+	EndTime string `json:"end_time,omitempty"`
+}
+
+// TriggerEvaluationSchedule holds the window of a Trigger with the "window"
+// evaluation schedule type.
+type TriggerEvaluationSchedule struct {
+	Window TriggerEvaluationWindow `json:"window"`
+}
+
+// Trigger sends an alert when the result of a Query crosses a threshold.
+type Trigger struct {
+	// The unique identifier (ID) of a Trigger.
+	ID string `json:"id,omitempty"`
+
+	// The slug of the dataset the Trigger belongs to.
+	DatasetSlug string `json:"dataset_slug,omitempty"`
+
+	// The name of the Trigger.
+	Name string `json:"name,omitempty"`
+
+	// A description of the Trigger.
+	Description string `json:"description,omitempty"`
+
+	// Whether the Trigger is disabled, in which case it is not evaluated and
+	// sends no alerts.
+	Disabled bool `json:"disabled"`
+
+	// Whether the Trigger is currently firing. Read only.
+	Triggered bool `json:"triggered,omitempty"`
+
+	// An inline Query Specification. It may contain only a single
+	// calculation, and may not contain orders, havings or a limit. Cannot be
+	// used with QueryID.
+	Query *Query `json:"query,omitempty"`
+
+	// The ID of a saved Query. Cannot be used with Query.
+	QueryID string `json:"query_id,omitempty"`
+
+	// Whether to alert only when the Trigger changes state ("on_change"), or
+	// on every evaluation where the threshold is met ("on_true").
+	// Enum: "on_change" "on_true"
+	AlertType string `json:"alert_type,omitempty"`
+
+	// How often, in seconds, to evaluate the Trigger. Must be a multiple of 60
+	// between 60 and 86400.
+	Frequency int `json:"frequency,omitempty"`
+
+	// The threshold over which the Trigger fires.
+	Threshold *TriggerThreshold `json:"threshold,omitempty"`
+
+	// Enum: "frequency" "window"
+	EvaluationScheduleType string `json:"evaluation_schedule_type,omitempty"`
+
+	// The evaluation window, when EvaluationScheduleType is "window".
+	EvaluationSchedule *TriggerEvaluationSchedule `json:"evaluation_schedule,omitempty"`
+
+	// The Recipients notified when the Trigger fires.
+	Recipients []NotificationRecipient `json:"recipients,omitempty"`
+
+	// The ISO8601-formatted time when the Trigger was created.
+	CreatedAt *time.Time `json:"created_at,omitempty"`
+
+	// The ISO8601-formatted time when the Trigger was updated.
+	UpdatedAt *time.Time `json:"updated_at,omitempty"`
+}
+
+// Validate checks the Trigger against the constraints documented by the API,
+// so that mistakes are caught before a request is sent.
+func (t *Trigger) Validate() error {
+	var problems []string
+
+	if t.Name == "" {
+		problems = append(problems, "name is required")
+	}
+	if t.Query == nil && t.QueryID == "" {
+		problems = append(problems, "one of query or query_id is required")
+	}
+	if t.Query != nil && t.QueryID != "" {
+		problems = append(problems, "query and query_id cannot both be set")
+	}
+	if t.Query != nil && len(t.Query.Calculations) != 1 {
+		problems = append(problems, fmt.Sprintf("query must have exactly one calculation, not %d", len(t.Query.Calculations)))
+	}
+	if t.Threshold == nil {
+		problems = append(problems, "threshold is required")
+	} else {
+		if !slices.Contains(TriggerThresholdOps, t.Threshold.Op) {
+			problems = append(problems, fmt.Sprintf("threshold op %q must be one of %q", t.Threshold.Op, TriggerThresholdOps))
+		}
+		if t.Threshold.ExceededLimit != 0 && (t.Threshold.ExceededLimit < 1 || t.Threshold.ExceededLimit > 5) {
+			problems = append(problems, fmt.Sprintf("threshold exceeded_limit %d must be between 1 and 5", t.Threshold.ExceededLimit))
+		}
+	}
+	if t.AlertType != "" && !slices.Contains(TriggerAlertTypes, t.AlertType) {
+		problems = append(problems, fmt.Sprintf("alert_type %q must be one of %q", t.AlertType, TriggerAlertTypes))
+	}
+	if t.Frequency != 0 && (t.Frequency < 60 || t.Frequency > 86400 || t.Frequency%60 != 0) {
+		problems = append(problems, fmt.Sprintf("frequency %d must be a multiple of 60 between 60 and 86400", t.Frequency))
+	}
+	if t.EvaluationScheduleType != "" && !slices.Contains(TriggerEvaluationScheduleTypes, t.EvaluationScheduleType) {
+		problems = append(problems, fmt.Sprintf("evaluation_schedule_type %q must be one of %q", t.EvaluationScheduleType, TriggerEvaluationScheduleTypes))
+	}
+	if t.EvaluationScheduleType == "window" && t.EvaluationSchedule == nil {
+		problems = append(problems, "evaluation_schedule is required when evaluation_schedule_type is window")
+	}
+	if t.EvaluationSchedule != nil {
+		for _, d := range t.EvaluationSchedule.Window.DaysOfWeek {
+			if !slices.Contains(DaysOfWeek, d) {
+				problems = append(problems, fmt.Sprintf("evaluation window day %q must be one of %q", d, DaysOfWeek))
+			}
+		}
+		for _, hm := range []string{t.EvaluationSchedule.Window.StartTime, t.EvaluationSchedule.Window.EndTime} {
+			if _, err := time.Parse("15:04", hm); err != nil {
+				problems = append(problems, fmt.Sprintf("evaluation window time %q must be in HH:mm format", hm))
+			}
+		}
+	}
+
+	problems = append(problems, notificationRecipientProblems(t.Recipients)...)
+
+	return newValidationError("trigger", problems)
+}
+
+// CreateTrigger creates a Trigger in the given dataset.
+// https://docs.honeycomb.io/api/tag/Triggers#operation/createTrigger
+func (c *Client) CreateTrigger(ctx context.Context, dataset string, t *Trigger) (*Trigger, error) {
+	var out Trigger
+	if err := c.Do(ctx, http.MethodPost, pathFor("/1/triggers", dataset), t, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// ListTriggers lists all Triggers in the given dataset.
+// https://docs.honeycomb.io/api/tag/Triggers#operation/listTriggers
+func (c *Client) ListTriggers(ctx context.Context, dataset string) ([]Trigger, error) {
+	var out []Trigger
+	if err := c.Do(ctx, http.MethodGet, pathFor("/1/triggers", dataset), nil, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// GetTrigger gets a single Trigger by ID.
+// https://docs.honeycomb.io/api/tag/Triggers#operation/getTrigger
+func (c *Client) GetTrigger(ctx context.Context, dataset, id string) (*Trigger, error) {
+	var out Trigger
+	if err := c.Do(ctx, http.MethodGet, pathFor("/1/triggers", dataset, id), nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// UpdateTrigger replaces the Trigger with the given ID.
+// https://docs.honeycomb.io/api/tag/Triggers#operation/updateTrigger
+func (c *Client) UpdateTrigger(ctx context.Context, dataset, id string, t *Trigger) (*Trigger, error) {
+	var out Trigger
+	if err := c.Do(ctx, http.MethodPut, pathFor("/1/triggers", dataset, id), t, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// DeleteTrigger deletes the Trigger with the given ID.
+// https://docs.honeycomb.io/api/tag/Triggers#operation/deleteTrigger
+func (c *Client) DeleteTrigger(ctx context.Context, dataset, id string) error {
+	return c.Do(ctx, http.MethodDelete, pathFor("/1/triggers", dataset, id), nil, nil)
+}
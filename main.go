@@ -5,5 +5,5 @@ import (
 )
 
 func main() {
-	cmd.NewHoneybadgerCmd().Execute()
+	cmd.Execute()
 }